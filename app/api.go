@@ -0,0 +1,225 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/types"
+	"github.com/arjunmoola/go-spotify/utils"
+)
+
+const apiTokenFile = "api_token"
+
+// apiToken returns the token of the http api, the configured one or the one
+// generated on first use. An empty token file is treated as missing, an
+// empty token would let every request in.
+func apiToken(a *App) (string, error) {
+	if token := strings.TrimSpace(a.config.API.Token); token != "" {
+		return token, nil
+	}
+
+	path := filepath.Join(utils.ConfigDir(), apiTokenFile)
+
+	data, err := os.ReadFile(path)
+
+	if token := strings.TrimSpace(string(data)); err == nil && token != "" {
+		return token, nil
+	}
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	b := make([]byte, 24)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	token := hex.EncodeToString(b)
+
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// daemonPlayer controls playback for the http api and mpris through the
+// daemon, which keeps the token and the playback state.
+type daemonPlayer struct {
+	d *daemonServer
+}
+
+func (p daemonPlayer) NowPlaying(ctx context.Context) (output.NowPlaying, error) {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+
+	if p.d.pollErr != nil && p.d.snapshot.FetchedAt.IsZero() {
+		return output.NowPlaying{}, p.d.pollErr
+	}
+
+	return p.d.snapshot.At(time.Now()), nil
+}
+
+// device is the active device, spotify needs it for every action.
+func (p daemonPlayer) device() (output.NowPlaying, error) {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+
+	n := p.d.snapshot.NowPlaying
+
+	if n.Device.Id == "" {
+		return n, client.SpotifyError{ Status: http.StatusNotFound, Message: "no active device, start playing on one first" }
+	}
+
+	return n, nil
+}
+
+// do runs f with a fresh token and the active device, then polls so the
+// change is reported right away.
+func (p daemonPlayer) do(ctx context.Context, f func(ctx context.Context, n output.NowPlaying) error) error {
+	n, err := p.device()
+
+	if err != nil {
+		return err
+	}
+
+	ctx, err = p.d.ctx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if err := f(ctx, n); err != nil {
+		return err
+	}
+
+	p.d.wakeUp()
+
+	return nil
+}
+
+func (p daemonPlayer) playback(ctx context.Context, action string) error {
+	return p.do(ctx, func(ctx context.Context, n output.NowPlaying) error {
+		return p.d.a.client.PlaybackAction(ctx, client.PlaybackActionParams{
+			DeviceId: n.Device.Id,
+			Action: action,
+			Other: types.Optional[client.OtherParams]{},
+		})
+	})
+}
+
+func (p daemonPlayer) Play(ctx context.Context) error {
+	return p.playback(ctx, "play")
+}
+
+func (p daemonPlayer) Pause(ctx context.Context) error {
+	return p.playback(ctx, "pause")
+}
+
+func (p daemonPlayer) Toggle(ctx context.Context) error {
+	n, err := p.NowPlaying(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if n.Playing {
+		return p.Pause(ctx)
+	}
+
+	return p.Play(ctx)
+}
+
+// Like adds the track with uri to the liked songs.
+func (p daemonPlayer) Like(ctx context.Context, uri string) error {
+	ctx, err := p.d.ctx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return likeTrack(ctx, p.d.a, uri)
+}
+
+func (p daemonPlayer) Skip(ctx context.Context, direction string) error {
+	return p.do(ctx, func(ctx context.Context, n output.NowPlaying) error {
+		return p.d.a.client.SkipSong(ctx, client.SkipSongParams{
+			DeviceId: n.Device.Id,
+			Direction: direction,
+		})
+	})
+}
+
+func (p daemonPlayer) SetVolume(ctx context.Context, percent int) error {
+	return p.do(ctx, func(ctx context.Context, n output.NowPlaying) error {
+		return p.d.a.client.SetPlaybackVolume(ctx, client.SetPlaybackVolumeParams{
+			DeviceId: n.Device.Id,
+			Percent: percent,
+		})
+	})
+}
+
+func (p daemonPlayer) Seek(ctx context.Context, positionMs int) error {
+	return p.do(ctx, func(ctx context.Context, n output.NowPlaying) error {
+		return p.d.a.client.SeekToPosition(ctx, client.SeekToPositionParams{
+			DeviceId: n.Device.Id,
+			PositionMs: positionMs,
+		})
+	})
+}
+
+func (p daemonPlayer) SetShuffle(ctx context.Context, on bool) error {
+	return p.do(ctx, func(ctx context.Context, n output.NowPlaying) error {
+		return p.d.a.client.TogglePlaybackShuffle(ctx, client.TogglePlaybackShuffleParams{
+			DeviceId: n.Device.Id,
+			State: on,
+		})
+	})
+}
+
+func (p daemonPlayer) SetRepeat(ctx context.Context, state string) error {
+	return p.do(ctx, func(ctx context.Context, n output.NowPlaying) error {
+		return p.d.a.client.SetRepeatMode(ctx, client.SetRepeatModeParams{
+			DeviceId: n.Device.Id,
+			State: state,
+		})
+	})
+}
+
+func (p daemonPlayer) Queue(ctx context.Context) ([]output.QueueItem, error) {
+	return p.d.cachedQueue(ctx)
+}
+
+func (p daemonPlayer) AddToQueue(ctx context.Context, uri string) error {
+	return p.do(ctx, func(ctx context.Context, n output.NowPlaying) error {
+		return p.d.a.client.AddItemToQueue(ctx, client.AddItemToQueueParams{
+			DeviceId: n.Device.Id,
+			Uri: uri,
+		})
+	})
+}
+
+func (p daemonPlayer) Search(ctx context.Context, query string, limit int) ([]output.SearchResult, error) {
+	ctx, err := p.d.ctx(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return searchSpotify(ctx, p.d.a, query, limit)
+}
+
+func (p daemonPlayer) Devices(ctx context.Context) ([]output.Device, error) {
+	return p.d.cachedDevices(ctx)
+}
@@ -2,7 +2,6 @@ package app
 
 import (
 	"strings"
-	"flag"
 	"github.com/charmbracelet/lipgloss"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -20,6 +19,13 @@ import (
 	"path/filepath"
 	"github.com/arjunmoola/go-spotify/database"
 	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/config"
+	"github.com/arjunmoola/go-spotify/daemon"
+	"github.com/arjunmoola/go-spotify/hooks"
+	"github.com/arjunmoola/go-spotify/notify"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/poller"
+	"github.com/arjunmoola/go-spotify/utils"
 	"github.com/arjunmoola/go-spotify/models/grid"
 	"github.com/arjunmoola/go-spotify/models/media"
 	nested "github.com/arjunmoola/go-spotify/models/list"
@@ -27,7 +33,12 @@ import (
 	_ "github.com/tursodatabase/go-libsql"
 	"errors"
 	//"log"
+	"net/http"
 	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 )
 
 var logger *slog.Logger
@@ -64,14 +75,13 @@ func (state AppState) String() string {
 }
 
 var debug bool = true
-var ddl string
+var ddl []string
 
 func init() {
-	ddl = schema.Get()
+	ddl = schema.Statements()
 }
 
 const (
-	defaultConfigDirName = ".go-spotify"
 	defaultDbName = "go-spotify.db"
 	dbDriver = "libsql"
 )
@@ -86,8 +96,7 @@ func getDbUrl(configDir string) string {
 }
 
 func getConfigDir() string {
-	userHome, _ := os.UserHomeDir()
-	return filepath.Join(userHome, defaultConfigDirName)
+	return utils.ConfigDir()
 }
 
 func (a *App) initializeConfigDir() error {
@@ -152,10 +161,10 @@ func initializeDbCmd(a *App) tea.Cmd {
 			return initializeDbMsg{ err: err }
 		}
 
-		_, err = db.Exec(ddl)
-
-		if err != nil {
-			return initializeDbMsg{ err: err }
+		for _, stmt := range ddl {
+			if _, err := db.Exec(stmt); err != nil {
+				return initializeDbMsg{ err: err }
+			}
 		}
 
 		return initializeDbMsg{
@@ -178,10 +187,10 @@ func (a *App) initializeDb() error {
 		return err
 	}
 
-	_, err = db.Exec(ddl)
-
-	if err != nil {
-		return err
+	for _, stmt := range ddl {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
 	}
 
 	a.db = db
@@ -340,9 +349,12 @@ type AppStyles struct {
 	progressBarStyle lipgloss.Style
 	gridStyle lipgloss.Style
 	infoStyle lipgloss.Style
+	link lipgloss.Style
+	errMsg lipgloss.Style
 }
 
-func NewAppStyles() AppStyles {
+func NewAppStyles(theme config.Theme) AppStyles {
+	accent := lipgloss.Color(theme.Accent)
 	defaultStyle := lipgloss.NewStyle()
 	generalModelStyle := lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder())
 	currentlyPlaying := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder())
@@ -353,10 +365,12 @@ func NewAppStyles() AppStyles {
 
 	return AppStyles{
 		infoStyle: defaultStyle.Align(lipgloss.Center),
-		title: defaultStyle.BorderStyle(lipgloss.HiddenBorder()).Height(1).Foreground(lipgloss.Color("200")),
+		title: defaultStyle.BorderStyle(lipgloss.HiddenBorder()).Height(1).Foreground(accent),
 		artist: generalModelStyle,
 		track: generalModelStyle,
-		focusedModel: generalModelStyle.BorderForeground(lipgloss.Color("200")),
+		focusedModel: generalModelStyle.BorderForeground(accent),
+		link: defaultStyle.Foreground(lipgloss.Color(theme.Link)),
+		errMsg: defaultStyle.Foreground(lipgloss.Color(theme.Error)),
 		currentlyPlaying: currentlyPlaying,
 		artistStyle: artistStyle,
 		skipButtonStyle: skipButtonsStyle,
@@ -452,8 +466,23 @@ type App struct {
 	msgs []string
 	styles AppStyles
 
-	retrying bool
-	currentlyPlayingRetryCount int
+	// playback polls the playback state for the tui and sends every poll
+	// to playbackResults
+	playback *poller.Poller
+	playbackResults chan poller.Result
+	// playbackViaDaemon is set while gsp daemon answers the polls, which
+	// then runs the hooks itself
+	playbackViaDaemon atomic.Bool
+
+	// hooks is created by hookRunner, which the daemon may call from
+	// several goroutines
+	hooks *hooks.Runner
+	hooksOnce sync.Once
+	// hookErrors carries failed hooks to the messages pane of the tui
+	hookErrors chan error
+
+	notifier *notify.Notifier
+	notifyActions chan NotificationActionResult
 
 	data map[string]any
 
@@ -474,6 +503,23 @@ type App struct {
 	
 	inputValue Optional[string]
 	searchResults Optional[types.SearchResult]
+
+	config config.Config
+	keymap config.Keymap
+	configPath string
+	configModTime time.Time
+	cliOptions CliOptions
+	// daemon is the connection the cli keeps to gsp daemon while a
+	// command runs, nil when no daemon is running
+	daemon *daemon.Client
+	startupViewShown bool
+	restoredTable string
+	savedCursor Optional[grid.Position]
+	duplicates Optional[duplicates]
+	diffView Optional[diffView]
+	// lastChangeId is the newest row of the playlist change log the tui has
+	// shown
+	lastChangeId Optional[int64]
 }
 
 type Optional[T any] struct {
@@ -618,6 +664,10 @@ func (a *App) SetCurrentlyPlaying(c types.CurrentlyPlaying) {
 	}
 }
 
+func (a *App) UnsetCurrentlyPlaying() {
+	a.currentlyPlaying = Optional[types.CurrentlyPlaying]{}
+}
+
 func (a *App) CurrentlyPlaying() (types.CurrentlyPlaying, bool) {
 	return a.currentlyPlaying.Value, a.currentlyPlaying.Valid
 }
@@ -787,6 +837,7 @@ func New(db *sql.DB) *App {
 		nested.NewItem("Top Tracks", nil, false),
 		nested.NewItem("Playlists", nil, true),
 		nested.NewItem("Recently Played", nil, false),
+		nested.NewItem("Liked Songs", nil, false),
 		//nested.NewItem("Current Session", nil, false),
 	}
 
@@ -799,14 +850,19 @@ func New(db *sql.DB) *App {
 
 	viewMap["default"] = table1
 	viewMap["recently_played"] = table2
+	viewMap["local_search"] = NewTable[Rower](localSearchColumns())
+	viewMap["duplicates"] = NewTable[Rower](duplicatesColumns())
 
 	viewMapKeys := make(map[string]string)
 	viewMapKeys["Top Artists"] = "default"
 	viewMapKeys["Top Tracks"] = "default"
 	viewMapKeys["Playlists"] = "default"
 	viewMapKeys["Recently Played"] = "recently_played"
+	viewMapKeys["Liked Songs"] = "default"
 	//viewMapKeys["Current Session"] = "recently_played"
 	viewMapKeys["Playlist Items"] = "default"
+	viewMapKeys["Local Search"] = "local_search"
+	viewMapKeys[duplicatesTitle] = "duplicates"
 
 	input := textinput.New()
 
@@ -843,7 +899,7 @@ func New(db *sql.DB) *App {
 
 	loginModel := newLoginModel()
 
-	return &App{
+	a := &App{
 		db: db,
 		spinner: spinner,
 		client: client,
@@ -861,12 +917,16 @@ func New(db *sql.DB) *App {
 		//playlists: playlists,
 		//devices: devices,
 		grid: g,
-		styles: NewAppStyles(),
 		progress: progress,
 		data: make(map[string]any),
 		sessionStart: time.Now(),
 		cachedPlaylists: make(map[string]types.Playlist),
+		configPath: config.Path(),
 	}
+
+	a.SetConfig(config.Default())
+
+	return a
 }
 
 func (a *App) Run() error {
@@ -896,7 +956,7 @@ func (a *App) SetupCli() error {
 	}
 
 	if a.newLogin {
-		return fmt.Errorf("use the tui to finish authentication")
+		return fmt.Errorf("not logged in, run gsp without a command to log in")
 	}
 
 	if !a.tokenExpired {
@@ -964,11 +1024,12 @@ type GetCurrentlyPlayingTrackResult struct {
 	retry bool
 }
 
-type GetCurrentlyPlayingResult struct {
-	result types.CurrentlyPlaying
-	retry bool
+type PlaybackResult struct {
+	result poller.Result
 }
 
+type PlaybackChangedResult struct{}
+
 type GetUsersQueueResult struct {
 	result types.UsersQueue
 }
@@ -1096,6 +1157,19 @@ func RenewRefreshTokenCmd(a *App) tea.Cmd {
 }
 
 func renewRefreshToken(a *App) tea.Msg {
+	var tok daemonToken
+
+	// a running daemon already keeps the token fresh, refreshing it here
+	// as well would only race it
+	if ok, err := a.callDaemon(context.Background(), "token", nil, &tok); ok && err == nil {
+		return RenewRefreshTokenResult{
+			result: types.SpotifyRefreshTokenResponse{
+				AccessToken: tok.AccessToken,
+				ExpiresIn: int(time.Until(tok.ExpiresAt).Seconds()),
+			},
+		}
+	}
+
 	ctx := defaultRefreshTokenCtx(a)
 	resp, err := a.client.RefreshToken(ctx)
 
@@ -1155,7 +1229,7 @@ func GetUsersPlaylist(a *App) tea.Cmd {
 	return func() tea.Msg {
 		ctx := defaultAccessTokenCtx(a)
 
-		playlists, err := a.client.GetCurrentUsersPlaylists(ctx, 10, 0)
+		playlists, err := a.client.GetCurrentUsersPlaylists(ctx, a.config.PageSizes.Playlists, 0)
 
 		if err != nil {
 			return AppErr(err)
@@ -1210,21 +1284,55 @@ func GetAvailableDevices(a *App) tea.Cmd {
 	}
 }
 
-func GetCurrentlyPlayingCmd(a *App) tea.Cmd {
-	return func() tea.Msg {
-		return GetCurrentlyPlaying(a)
+// WatchPlaybackCmd starts polling the playback state. Token renewal is left
+// to RenewRefreshTokenTick, a poll with an expired token fails and is
+// retried by the poller.
+func WatchPlaybackCmd(a *App) tea.Cmd {
+	if a.playback != nil {
+		return nil
+	}
+
+	fetch := func(ctx context.Context) (types.CurrentlyPlaying, error) {
+		var playing types.CurrentlyPlaying
+
+		ok, err := a.callDaemon(ctx, "currently_playing", nil, &playing)
+		a.playbackViaDaemon.Store(ok)
+
+		if ok {
+			return playing, err
+		}
+
+		return getCurrentlyPlaying(client.WithAccessToken(ctx, a.AccessToken()), a)
 	}
+
+	a.playback = poller.New(fetch, poller.Options{
+		Interval: a.config.Intervals.Playback.Duration,
+		Paused: a.config.Intervals.PlaybackPaused.Duration,
+	})
+	a.playbackResults = make(chan poller.Result)
+
+	go a.playback.Run(context.Background(), a.playbackResults)
+
+	return waitForPlaybackCmd(a.playbackResults)
 }
 
-func GetCurrentlyPlaying(a *App) tea.Msg {
-	ctx := defaultAccessTokenCtx(a)
-	currentlyPlaying, err := a.client.GetCurrentlyPlaying(ctx)
+func waitForPlaybackCmd(results chan poller.Result) tea.Cmd {
+	return func() tea.Msg {
+		result, ok := <-results
 
-	if err != nil {
-		return AppErr(err)
+		if !ok {
+			return nil
+		}
+
+		return PlaybackResult{ result: result }
 	}
+}
 
-	return GetCurrentlyPlayingResult{ result: currentlyPlaying, retry: false }
+// refreshPlayback polls right away after playback was changed.
+func (a *App) refreshPlayback() {
+	if a.playback != nil {
+		a.playback.Refresh()
+	}
 }
 
 func StartResumePlaybackCmd(a *App) tea.Cmd {
@@ -1245,7 +1353,7 @@ func StartResumePlaybackCmd(a *App) tea.Cmd {
 			return AppErr(err)
 		}
 
-		return nil
+		return PlaybackChangedResult{}
 	}
 }
 
@@ -1267,7 +1375,7 @@ func PausePlaybackCmd(a *App) tea.Cmd {
 			return AppErr(err)
 		}
 
-		return nil
+		return PlaybackChangedResult{}
 	}
 }
 
@@ -1579,68 +1687,143 @@ func (a *App) updateConfigDb(auth AuthorizationInfo) error {
 	return nil
 }
 
-type CliCommandHandler func(args ...string) error
+// getCurrentlyPlaying asks spotify what is playing. Nothing playing is an
+// empty state rather than an error.
+func getCurrentlyPlaying(ctx context.Context, a *App) (types.CurrentlyPlaying, error) {
+	status, err := a.client.GetCurrentlyPlaying(ctx)
+
+	var spotifyErr client.SpotifyError
 
-type CliCommands struct {
-	Commands map[string]CliCommandHandler
+	if errors.As(err, &spotifyErr) && spotifyErr.Status == http.StatusNoContent {
+		return types.CurrentlyPlaying{}, nil
+	}
+
+	return status, err
 }
 
-func (c *CliCommands) RegisterHandler(cmd string, f CliCommandHandler) {
-	c.Commands[cmd] = f
+func DevicesHandler(a *App) *CliCommand {
+	cmd := NewCliCommand("devices", "", "list the spotify connect devices")
+	cmd.Structured = true
+	cmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		devices, err := listDevices(defaultAccessTokenCtx(a), a)
+
+		if err != nil {
+			return err
+		}
+
+		if p := a.printer(); !p.Text() {
+			return output.List(p, devices)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(tw, "\tNAME\tTYPE\tVOLUME")
+
+		for _, device := range devices {
+			active, volume := "", "-"
+
+			if device.Active {
+				active = "*"
+			}
+
+			if device.Volume != nil {
+				volume = fmt.Sprintf("%d%%", *device.Volume)
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", active, device.Name, device.Type, volume)
+		}
+
+		return tw.Flush()
+	}
+
+	return cmd
 }
 
-func NewCliCommands(a *App) *CliCommands {
-	commands := &CliCommands{
-		Commands: make(map[string]CliCommandHandler),
+// listDevices asks the daemon for the devices when one runs and spotify
+// otherwise.
+func listDevices(ctx context.Context, a *App) ([]output.Device, error) {
+	var devices []output.Device
+
+	if ok, err := a.callDaemon(ctx, "devices", nil, &devices); ok {
+		return devices, err
 	}
-	commands.RegisterHandler("player", PlayerHandler(a))
-	return commands
+
+	available, err := a.client.GetAvailableDevices(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	devices = make([]output.Device, 0, len(available.Devices))
+
+	for _, device := range available.Devices {
+		devices = append(devices, output.NewDevice(device))
+	}
+
+	return devices, nil
 }
 
-func (c *CliCommands) Run(cmd string, args ...string) error {
-	handler, ok := c.Commands[cmd]
-	if !ok {
-		return fmt.Errorf("handler for command %s not found", cmd)
+// listQueue asks the daemon for the queue when one runs and spotify
+// otherwise.
+func listQueue(ctx context.Context, a *App) ([]output.QueueItem, error) {
+	var items []output.QueueItem
+
+	if ok, err := a.callDaemon(ctx, "queue", nil, &items); ok {
+		return items, err
 	}
 
-	return handler(args...)
+	queue, err := a.client.GetQueue(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return output.NewQueue(queue), nil
 }
 
-func StatusHandler(a *App) CliCommandHandler {
-	statusCmd := flag.NewFlagSet("status", flag.ExitOnError)
-	return func(args ...string) error {
-		if err := statusCmd.Parse(args); err != nil {
-			statusCmd.Usage()
-			return err
+func QueueHandler(a *App) *CliCommand {
+	cmd := NewCliCommand("queue", "", "list what is playing and what plays next")
+	cmd.Structured = true
+	cmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
 		}
 
-		ctx := client.WithAccessToken(context.Background(), a.AccessToken())
-
-		status, err := a.client.GetCurrentlyPlaying(ctx)
+		items, err := listQueue(defaultAccessTokenCtx(a), a)
 
 		if err != nil {
 			return err
 		}
 
-		if !status.Item.Valid {
-			fmt.Println("there is nothing playing currently")
+		if p := a.printer(); !p.Text() {
+			return output.List(p, items)
+		}
+
+		if len(items) == 0 {
+			fmt.Println("the queue is empty")
 			return nil
 		}
 
-		item := status.Item.Value
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
-		var name string
+		for _, item := range items {
+			position := strconv.Itoa(item.Position)
 
-		if item.Type == "track" {
-			name = item.Track.Name
-		} else {
-			name = item.Episode.Name
-		}
+			if item.Position == 0 {
+				position = "now"
+			}
 
-		fmt.Printf("currently playing: %s", name)
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", position, item.Title, strings.Join(item.Artists, ", "), output.FormatDuration(item.DurationMs))
+		}
 
-		return nil
+		return tw.Flush()
 	}
+
+	return cmd
 }
 
 func defaultAccessTokenCtx(a *App) context.Context {
@@ -1657,24 +1840,23 @@ func defaultAuthorizationCtx(a *App) context.Context {
 	return client.ContextWithAuthorization(context.Background(), auth.clientId, auth.clientSecret, auth.redirectUri)
 }
 
-func PlayerHandler(a *App) CliCommandHandler {
+func PlayerHandler(a *App) *CliCommand {
 	var playpause bool
 	var nextSong bool
 	var prevSong bool
-	playerCmd := flag.NewFlagSet("player", flag.ExitOnError)
-	playerCmd.BoolVar(&playpause, "p", false, "play/pause")
-	playerCmd.BoolVar(&nextSong, "next", false, "next song")
-	playerCmd.BoolVar(&prevSong, "prev", false, "previous song")
-	return func(args ...string) error {
-		if err := playerCmd.Parse(args); err != nil {
-			playerCmd.Usage()
-			return err
+	cmd := NewCliCommand("player", "", "show the current track or control playback on the active device")
+	cmd.Structured = true
+	cmd.Flags.BoolVar(&playpause, "p", false, "play/pause")
+	cmd.Flags.BoolVar(&nextSong, "next", false, "next song")
+	cmd.Flags.BoolVar(&prevSong, "prev", false, "previous song")
+	cmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
 		}
 
-
 		ctx := defaultAccessTokenCtx(a)
 
-		currentlyPlaying, err := a.client.GetCurrentlyPlaying(ctx)
+		currentlyPlaying, err := getCurrentlyPlaying(ctx, a)
 
 		if err != nil {
 			return err
@@ -1705,11 +1887,22 @@ func PlayerHandler(a *App) CliCommandHandler {
 		activeDeviceId := activeDevice.Id.Value
 
 
-		if len(args) == 0 {
-			showArtistInfoCli(currentlyPlaying, activeDevice)
-			return nil
+		if !playpause && !nextSong && !prevSong {
+			return showArtistInfoCli(a, currentlyPlaying, activeDevice)
 		}
 
+		// the cached status would show the state before the action
+		if err := statusCache().Clear(); err != nil {
+			return err
+		}
+
+		// and the daemon would until its next poll
+		defer func() {
+			if _, err := a.callDaemon(context.Background(), "poll", nil, nil); err != nil {
+				logger.Error("could not ask the daemon to poll", "err", err)
+			}
+		}()
+
 		if playpause {
 
 			var action string
@@ -1747,13 +1940,13 @@ func PlayerHandler(a *App) CliCommandHandler {
 
 			<-time.After(250*time.Millisecond)
 
-			nextSong, err := a.client.GetCurrentlyPlaying(ctx)
+			nextSong, err := getCurrentlyPlaying(ctx, a)
 
 			if err != nil {
 				return err
 			}
 
-			showArtistInfoCli(nextSong, activeDevice)
+			return showArtistInfoCli(a, nextSong, activeDevice)
 
 		}
 
@@ -1770,28 +1963,42 @@ func PlayerHandler(a *App) CliCommandHandler {
 
 		return nil
 	}
+
+	return cmd
 }
 
-func showArtistInfoCli(currentlyPlaying types.CurrentlyPlaying, activeDevice types.Device) {
+func showArtistInfoCli(a *App, currentlyPlaying types.CurrentlyPlaying, activeDevice types.Device) error {
+	if p := a.printer(); !p.Text() {
+		currentlyPlaying.Device = activeDevice
+		return output.Print(p, output.NewNowPlaying(currentlyPlaying))
+	}
+
 	var name string
 	var artists string
 
 	item := currentlyPlaying.Item.Value
 
-	if currentlyPlaying.Item.Value.Type == "track" {
+	switch {
+	case !currentlyPlaying.Item.Valid || (item.Track == nil && item.Episode == nil):
+		fmt.Println("nothing is playing")
+		fmt.Printf("device: %s\n", activeDevice.Name)
+		return nil
+	case item.Track != nil:
 		name = item.Track.Name
 
-		var a []string
+		var names []string
 
 		for _, artist := range item.Track.Artists {
-			a = append(a, artist.Name)
+			names = append(names, artist.Name)
 		}
-		artists = strings.Join(a, ",")
-	} else {
+		artists = strings.Join(names, ",")
+	default:
 		name = item.Episode.Name
 	}
 
 	fmt.Printf("playing: %s\nartists: %s\n", name, artists)
 	fmt.Printf("device: %s\n", activeDevice.Name)
+
+	return nil
 }
 
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+type backupOptions struct {
+	owned bool
+	force bool
+}
+
+func backupPlaylists(ctx context.Context, a *App, dir string, opts backupOptions) error {
+	playlists, err := a.client.GetAllCurrentUsersPlaylists(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if opts.owned {
+		user, err := a.client.GetCurrentUserProfile(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		var owned []types.SimplifiedPlaylistObject
+
+		for _, p := range playlists {
+			if p.Owner.Id == user.Id || p.Collaborative {
+				owned = append(owned, p)
+			}
+		}
+
+		playlists = owned
+	}
+
+	printer := a.printer()
+	records := []output.BackupEntry{}
+
+	result, err := playlist.BackupPlaylists(ctx, a.client, a.config.Market, dir, playlists, opts.force, func(p types.SimplifiedPlaylistObject, status playlist.BackupStatus) {
+		if !printer.Text() {
+			records = append(records, output.BackupEntry{ Status: string(status), Id: p.Id, Name: p.Name })
+			return
+		}
+
+		if status != playlist.BackupUnchanged {
+			fmt.Printf("%s\t%s\n", status, p.Name)
+		}
+	})
+
+	if !printer.Text() {
+		return errors.Join(output.List(printer, records), err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("backed up %d playlists to %s: %s\n", len(playlists), dir, result)
+
+	return nil
+}
+
+type restoreOptions struct {
+	asNew bool
+	name string
+	yes bool
+}
+
+// restorePlaylist resets the playlist in the backup to the backed up items
+// and details, or recreates it when it no longer exists or asNew is set.
+func restorePlaylist(ctx context.Context, a *App, path string, opts restoreOptions) error {
+	b, err := playlist.ReadBackup(path)
+
+	if err != nil {
+		return err
+	}
+
+	if opts.name != "" {
+		opts.asNew = true
+		b.Name = opts.name
+	}
+
+	if !opts.asNew {
+		err := resetPlaylist(ctx, a, b, opts.yes)
+
+		var spotifyErr client.SpotifyError
+
+		if !errors.As(err, &spotifyErr) || spotifyErr.Status != http.StatusNotFound {
+			return err
+		}
+
+		fmt.Printf("%s no longer exists, restoring it as a new playlist\n", b.Name)
+	}
+
+	return recreatePlaylist(ctx, a, b)
+}
+
+func resetPlaylist(ctx context.Context, a *App, b playlist.Backup, yes bool) error {
+	current, err := a.client.GetPlaylist(ctx, client.GetPlaylistParams{
+		Id: b.Id,
+		Fields: []string{ "snapshot_id", "name", "description", "public", "collaborative" },
+	})
+
+	if err != nil {
+		return err
+	}
+
+	p := types.SimplifiedPlaylistObject{
+		Id: b.Id,
+		Name: current.Name,
+		SnapshotId: current.SnapshotId,
+	}
+
+	items, err := a.client.GetAllPlaylistItems(ctx, b.Id, a.config.Market)
+
+	if err != nil {
+		return err
+	}
+
+	uris, skipped := playlist.RestoreUris(items, b)
+
+	ops, err := playlist.Plan(playlist.EditUris(items), uris)
+
+	if err != nil {
+		return err
+	}
+
+	detailsChanged := current.Name != b.Name || current.Description != b.Description || current.Public != b.Public || current.Collaborative != b.Collaborative
+
+	for _, e := range skipped {
+		fmt.Printf("skipping the local file %s, it cannot be added back\n", entryName(e))
+	}
+
+	if len(ops) == 0 && !detailsChanged {
+		fmt.Printf("%s already matches the backup\n", p.Name)
+		return nil
+	}
+
+	fmt.Printf("%s has %d items, the backup from %s has %d\n", p.Name, len(items), b.BackedUpAt, len(b.Items))
+	fmt.Printf("changes: %s\n", describeOps(ops))
+
+	if detailsChanged {
+		fmt.Printf("the name, description and visibility are reset to %q\n", b.Name)
+	}
+
+	if !yes && !confirm(fmt.Sprintf("reset %s to the backup?", p.Name)) {
+		fmt.Println("nothing changed")
+		return nil
+	}
+
+	if len(ops) > 0 {
+		if _, err := playlist.ApplyEdit(ctx, a.client, p.Id, p.SnapshotId, ops); err != nil {
+			return err
+		}
+	}
+
+	if detailsChanged {
+		err := a.client.ChangePlaylistDetails(ctx, client.ChangePlaylistDetailsParams{
+			Id: b.Id,
+			Name: b.Name,
+			Description: b.Description,
+			Public: b.Public,
+			Collaborative: b.Collaborative,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("restored %s\n", b.Name)
+
+	return nil
+}
+
+func recreatePlaylist(ctx context.Context, a *App, b playlist.Backup) error {
+	user, err := a.client.GetCurrentUserProfile(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// a collaborative playlist cannot be public
+	created, err := a.client.CreatePlaylist(ctx, client.CreatePlaylistParams{
+		UserId: user.Id,
+		Name: b.Name,
+		Description: b.Description,
+		Public: b.Public && !b.Collaborative,
+		Collaborative: b.Collaborative,
+	})
+
+	if err != nil {
+		return fmt.Errorf("creating playlist %s: %w", b.Name, err)
+	}
+
+	var uris []string
+
+	for _, e := range b.Items {
+		if strings.HasPrefix(e.Uri, "spotify:local:") {
+			fmt.Printf("skipping the local file %s, it cannot be added back\n", entryName(e))
+			continue
+		}
+
+		uris = append(uris, e.Uri)
+	}
+
+	if len(uris) > 0 {
+		if _, err := playlist.AddUris(ctx, a.client, created.Id, uris); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("restored %s with %d items as %s\n", b.Name, len(uris), created.Uri)
+
+	return nil
+}
+
+func BackupHandler(a *App) *CliCommand {
+	var opts backupOptions
+	cmd := NewCliCommand("backup", "<dir>", "write every playlist to a json file in dir")
+	cmd.Flags.BoolVar(&opts.owned, "owned", false, "only playlists you own or collaborate on")
+	cmd.Flags.BoolVar(&opts.force, "force", false, "rewrite every backup even if the playlist did not change")
+	cmd.Structured = true
+	cmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		return backupPlaylists(defaultAccessTokenCtx(a), a, args[0], opts)
+	}
+
+	return cmd
+}
+
+func RestoreHandler(a *App) *CliCommand {
+	var opts restoreOptions
+	cmd := NewCliCommand("restore", "<file>", "reset a playlist to a backup or restore it as a new playlist")
+	cmd.Flags.BoolVar(&opts.asNew, "new", false, "create a new playlist instead of resetting the original")
+	cmd.Flags.StringVar(&opts.name, "name", "", "name of the new playlist, implies -new")
+	cmd.Flags.BoolVar(&opts.yes, "yes", false, "reset without asking")
+	cmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		return restorePlaylist(defaultAccessTokenCtx(a), a, args[0], opts)
+	}
+
+	return cmd
+}
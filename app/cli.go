@@ -0,0 +1,470 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+)
+
+// Exit codes of the cli, one per class of error so scripts can tell a typo
+// from an expired login or spotify being down.
+const (
+	ExitOK = 0
+	ExitFailure = 1
+	ExitUsage = 2
+	ExitAuth = 3
+	ExitNotFound = 4
+	ExitSpotify = 5
+	ExitConfig = 6
+)
+
+// ExitError attaches an exit code to an error.
+type ExitError struct {
+	Code int
+	Err error
+}
+
+func (e ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ExitError) Unwrap() error {
+	return e.Err
+}
+
+// UsageError is returned when a command is called with the wrong arguments.
+// The usage of the command is printed after the message.
+type UsageError struct {
+	Msg string
+}
+
+func (e UsageError) Error() string {
+	if e.Msg == "" {
+		return "invalid arguments"
+	}
+
+	return e.Msg
+}
+
+func usageError(format string, args ...any) error {
+	return UsageError{ Msg: fmt.Sprintf(format, args...) }
+}
+
+var errUsage = UsageError{}
+
+// ExitCode returns the exit code the cli uses for err.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr ExitError
+	var usageErr UsageError
+	var notFound playlist.NotFoundError
+	var spotifyErr client.SpotifyError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	case errors.As(err, &notFound), errors.Is(err, os.ErrNotExist), errors.Is(err, sql.ErrNoRows):
+		return ExitNotFound
+	case errors.Is(err, ErrInvalidClientInfo), errors.Is(err, ErrClientInfoNotFound), errors.Is(err, client.ErrAccessTokenNotFound):
+		return ExitAuth
+	case errors.As(err, &spotifyErr):
+		switch spotifyErr.Status {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ExitAuth
+		case http.StatusNotFound:
+			return ExitNotFound
+		}
+		return ExitSpotify
+	case errors.As(err, &netErr):
+		return ExitSpotify
+	}
+
+	return ExitFailure
+}
+
+// CliOptions are the global flags given before the command.
+type CliOptions struct {
+	// Profile keeps the login, database and config of a profile in its own
+	// directory so several accounts can be used side by side.
+	Profile string
+	Output output.Format
+	// Format is a go template every record is written through instead of
+	// the output format.
+	Format string
+	Verbose bool
+	// ConfigPath replaces the config file of the profile.
+	ConfigPath string
+	// NoDaemon talks to spotify directly even when gsp daemon is running.
+	NoDaemon bool
+
+	template *template.Template
+}
+
+func (o *CliOptions) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("gsp", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&o.Profile, "profile", os.Getenv("GSP_PROFILE"), "profile to use, defaults to $GSP_PROFILE")
+	fs.Func("output", "text, json, ndjson or tsv, for the commands that report results (default text)", func(s string) error {
+		f, err := output.ParseFormat(s)
+		o.Output = f
+		return err
+	})
+	fs.StringVar(&o.Format, "format", "", "go template to write every result with, e.g. '{{.Title}} - {{join \", \" .Artists}}'")
+	fs.BoolVar(&o.Verbose, "v", false, "also write the log to stderr")
+	fs.StringVar(&o.ConfigPath, "config", "", "config file to use instead of the one of the profile")
+	fs.BoolVar(&o.NoDaemon, "no-daemon", false, "do not use a running gsp daemon")
+	return fs
+}
+
+// ParseCliOptions parses the global flags and returns the command and its
+// arguments that follow them. Asking for help returns the help command.
+func ParseCliOptions(args []string) (CliOptions, []string, error) {
+	opts := CliOptions{ Output: output.FormatText }
+
+	fs := opts.flags()
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return opts, []string{ "help" }, nil
+		}
+		return opts, nil, usageError("%v", err)
+	}
+
+	if opts.Format != "" {
+		tmpl, err := output.ParseTemplate(opts.Format)
+
+		if err != nil {
+			return opts, nil, usageError("-format: %v", err)
+		}
+
+		opts.template = tmpl
+	}
+
+	if strings.ContainsAny(opts.Profile, `/\`) || opts.Profile == "." || opts.Profile == ".." {
+		return opts, nil, usageError("invalid profile name %s", opts.Profile)
+	}
+
+	return opts, fs.Args(), nil
+}
+
+// printer writes the results of a command in the format given on the
+// command line.
+func (a *App) printer() *output.Printer {
+	return output.New(os.Stdout, a.cliOptions.Output, a.cliOptions.template)
+}
+
+// SetCliOptions remembers the global flags for the commands.
+func (a *App) SetCliOptions(opts CliOptions) {
+	a.cliOptions = opts
+}
+
+type CliCommandHandler func(args ...string) error
+
+// CliCommand is a command of the cli. It either runs a handler with the
+// arguments left after its flags, or groups subcommands like the export
+// command of "gsp playlist export".
+type CliCommand struct {
+	Name string
+	// Args describes the positional arguments, e.g. "<playlist>".
+	Args string
+	Summary string
+	Flags *flag.FlagSet
+	Run CliCommandHandler
+	// Offline commands work without logging in to spotify, and so do their
+	// subcommands.
+	Offline bool
+	// Structured commands write their results through the printer and so
+	// support every output format. The others only write text.
+	Structured bool
+
+	parent *CliCommand
+	subcommands []*CliCommand
+}
+
+func NewCliCommand(name, args, summary string) *CliCommand {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	return &CliCommand{
+		Name: name,
+		Args: args,
+		Summary: summary,
+		Flags: fs,
+	}
+}
+
+// Add adds subcommands to c.
+func (c *CliCommand) Add(subcommands ...*CliCommand) *CliCommand {
+	for _, sub := range subcommands {
+		sub.parent = c
+		c.subcommands = append(c.subcommands, sub)
+	}
+
+	return c
+}
+
+// Lookup returns the subcommand called name.
+func (c *CliCommand) Lookup(name string) (*CliCommand, bool) {
+	for _, sub := range c.subcommands {
+		if sub.Name == name {
+			return sub, true
+		}
+	}
+
+	return nil, false
+}
+
+// Path is the full command line of c, e.g. "gsp playlist export".
+func (c *CliCommand) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+
+	return c.parent.Path() + " " + c.Name
+}
+
+func (c *CliCommand) NeedsAuth() bool {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.Offline {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *CliCommand) hasFlags() bool {
+	n := 0
+	c.Flags.VisitAll(func(*flag.Flag) { n++ })
+	return n > 0
+}
+
+func (c *CliCommand) UsageLine() string {
+	line := "usage: " + c.Path()
+
+	if c.hasFlags() {
+		line += " [flags]"
+	}
+
+	if c.Args != "" {
+		line += " " + c.Args
+	} else if len(c.subcommands) > 0 && c.Run == nil {
+		line += " <command>"
+	}
+
+	return line
+}
+
+// PrintUsage writes the usage line, summary, flags and subcommands of c.
+func (c *CliCommand) PrintUsage(w io.Writer) {
+	fmt.Fprintln(w, c.UsageLine())
+
+	if c.Summary != "" {
+		fmt.Fprintf(w, "\n%s\n", c.Summary)
+	}
+
+	if len(c.subcommands) > 0 {
+		fmt.Fprintln(w, "\ncommands:")
+
+		tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+
+		for _, sub := range c.subcommands {
+			fmt.Fprintf(tw, "  %s\t%s\n", sub.Name, sub.Summary)
+		}
+
+		tw.Flush()
+	}
+
+	if c.hasFlags() {
+		fmt.Fprintln(w, "\nflags:")
+		c.Flags.SetOutput(w)
+		c.Flags.PrintDefaults()
+		c.Flags.SetOutput(io.Discard)
+	}
+
+	if len(c.subcommands) > 0 {
+		fmt.Fprintf(w, "\nrun \"gsp help%s <command>\" for the usage of a command\n", strings.TrimPrefix(c.Path(), "gsp"))
+	}
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// CliCommands is the command tree of the cli.
+type CliCommands struct {
+	app *App
+	root *CliCommand
+	helpCmd *CliCommand
+	loggedIn bool
+}
+
+func NewCliCommands(a *App) *CliCommands {
+	c := &CliCommands{ app: a }
+
+	c.root = NewCliCommand("gsp", "", "gsp without a command starts the tui.")
+	c.root.Flags = (&CliOptions{}).flags()
+
+	c.helpCmd = NewCliCommand("help", "[command...]", "show the usage of a command")
+	c.helpCmd.Offline = true
+	c.helpCmd.Run = c.help
+
+	c.root.Add(
+		PlayerHandler(a),
+		StatusHandler(a),
+		PlaybackWatchHandler(a),
+		DevicesHandler(a),
+		QueueHandler(a),
+		SearchHandler(a),
+		PlaylistHandler(a),
+		SmartHandler(a),
+		SaveHandler(a),
+		BackupHandler(a),
+		RestoreHandler(a),
+		SyncHandler(a),
+		HistoryHandler(a),
+		ConfigHandler(a),
+		DaemonHandler(a),
+		ShellHandler(a),
+		c.helpCmd,
+	)
+
+	return c
+}
+
+// Find walks the tree along the leading arguments naming commands and
+// returns the command they lead to and the rest of the arguments.
+func (c *CliCommands) Find(args []string) (*CliCommand, []string) {
+	cmd := c.root
+
+	for len(args) > 0 {
+		sub, ok := cmd.Lookup(args[0])
+
+		if !ok {
+			break
+		}
+
+		cmd = sub
+		args = args[1:]
+	}
+
+	return cmd, args
+}
+
+// Run runs the command named by args. The flags are checked before logging
+// in so a typo does not wait for a token refresh.
+func (c *CliCommands) Run(args ...string) error {
+	cmd, rest := c.Find(args)
+
+	if cmd == c.root {
+		if len(rest) == 0 {
+			return errUsage
+		}
+
+		return usageError("unknown command %s", rest[0])
+	}
+
+	if cmd.Run == nil {
+		if len(rest) == 0 {
+			return errUsage
+		}
+
+		if isHelpFlag(rest[0]) {
+			cmd.PrintUsage(os.Stdout)
+			return nil
+		}
+
+		return usageError("unknown %s command %s", strings.TrimPrefix(cmd.Path(), "gsp "), rest[0])
+	}
+
+	positional, err := parseInterspersed(cmd.Flags, rest)
+
+	if errors.Is(err, flag.ErrHelp) {
+		cmd.PrintUsage(os.Stdout)
+		return nil
+	}
+
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	if !cmd.Structured && cmd != c.helpCmd && !c.app.printer().Text() {
+		return usageError("%s only writes text, -output and -format are not supported", strings.TrimPrefix(cmd.Path(), "gsp "))
+	}
+
+	if cmd.NeedsAuth() && !c.loggedIn && c.app.connectDaemon() {
+		c.loggedIn = true
+	}
+
+	if cmd.NeedsAuth() && !c.loggedIn {
+		if err := c.app.SetupCli(); err != nil {
+			return ExitError{ Code: ExitAuth, Err: err }
+		}
+
+		c.loggedIn = true
+	}
+
+	return cmd.Run(positional...)
+}
+
+// Execute runs the command named by args, reports its error on stderr and
+// returns the exit code.
+func (c *CliCommands) Execute(args []string) int {
+	err := c.Run(args...)
+
+	if err == nil {
+		return ExitOK
+	}
+
+	var usageErr UsageError
+
+	if errors.As(err, &usageErr) {
+		cmd, _ := c.Find(args)
+
+		if usageErr.Msg != "" {
+			fmt.Fprintf(os.Stderr, "gsp: %s\n\n", usageErr.Msg)
+		}
+
+		cmd.PrintUsage(os.Stderr)
+	} else {
+		fmt.Fprintf(os.Stderr, "gsp: %v\n", err)
+	}
+
+	return ExitCode(err)
+}
+
+func (c *CliCommands) help(args ...string) error {
+	cmd, rest := c.Find(args)
+
+	if len(rest) > 0 {
+		return usageError("unknown command %s", strings.Join(args, " "))
+	}
+
+	cmd.PrintUsage(os.Stdout)
+
+	if cmd == c.root {
+		fmt.Println("\nexit codes:")
+		fmt.Println("  0 success, 1 failure, 2 invalid arguments, 3 not logged in,")
+		fmt.Println("  4 not found, 5 spotify error or unreachable, 6 invalid config")
+	}
+
+	return nil
+}
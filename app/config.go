@@ -0,0 +1,203 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/arjunmoola/go-spotify/config"
+	"github.com/arjunmoola/go-spotify/models/media"
+	nested "github.com/arjunmoola/go-spotify/models/list"
+)
+
+type ConfigReloadResult struct {
+	config config.Config
+	modTime time.Time
+	changed bool
+	err error
+}
+
+func (r ConfigReloadResult) Err() error {
+	return r.err
+}
+
+type SyncLibraryTick struct{}
+
+// LoadConfig reads the config file at path and remembers it so the tui can
+// reload it when it changes.
+func (a *App) LoadConfig(path string) error {
+	a.configPath = path
+	a.configModTime = configModTime(path)
+
+	cfg, err := config.Load(path)
+
+	if err != nil {
+		return err
+	}
+
+	a.SetConfig(cfg)
+
+	return nil
+}
+
+// SetConfig replaces the configuration of the app. It is safe to call while
+// the tui is running.
+func (a *App) SetConfig(cfg config.Config) {
+	a.config = cfg
+	a.keymap = cfg.Keys.Keymap()
+	a.applyTheme(cfg.Theme)
+
+	a.hookRunner().SetConfig(cfg.Hooks)
+}
+
+// showStartupView shows the configured startup view once, as soon as its
+// data has arrived.
+func (a *App) showStartupView(show func()) {
+	if a.startupViewShown {
+		return
+	}
+
+	a.startupViewShown = true
+	show()
+}
+
+func (a *App) applyTheme(theme config.Theme) {
+	accent := lipgloss.Color(theme.Accent)
+
+	a.styles = NewAppStyles(theme)
+	selectedItemStyle = lipgloss.NewStyle().Foreground(accent)
+	a.grid.SetAccentColor(accent)
+
+	if m, ok := GetModel[nested.NestedList](a, "sidebar"); ok {
+		m.SetAccentColor(accent)
+		SetModel(a, m, "sidebar")
+	}
+
+	if m, ok := GetModel[media.Model](a, "media"); ok {
+		m.SetAccentColor(accent)
+		SetModel(a, m, "media")
+	}
+
+	for _, key := range []string{ "devices", "queue", "messages" } {
+		if m, ok := GetModel[List](a, key); ok {
+			m.SetTitleColor(accent)
+			SetModel(a, m, key)
+		}
+	}
+}
+
+func configModTime(path string) time.Time {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
+
+// WatchConfigCmd checks the config file for changes once per reload interval
+// and reloads it when it was modified.
+func WatchConfigCmd(a *App) tea.Cmd {
+	path := a.configPath
+	last := a.configModTime
+
+	return tea.Tick(a.config.Intervals.Reload.Duration, func(_ time.Time) tea.Msg {
+		modTime := configModTime(path)
+
+		if modTime.Equal(last) {
+			return ConfigReloadResult{ modTime: modTime }
+		}
+
+		cfg, err := config.Load(path)
+
+		return ConfigReloadResult{
+			config: cfg,
+			modTime: modTime,
+			changed: true,
+			err: err,
+		}
+	})
+}
+
+// SyncLibraryTickCmd schedules the next library sync when periodic syncing is
+// enabled.
+func SyncLibraryTickCmd(a *App) tea.Cmd {
+	d := a.config.Intervals.Sync.Duration
+
+	if d == 0 {
+		return nil
+	}
+
+	return tea.Tick(d, func(_ time.Time) tea.Msg {
+		return SyncLibraryTick{}
+	})
+}
+
+func ConfigHandler(a *App) *CliCommand {
+	pathCmd := NewCliCommand("path", "", "print where the config file is")
+	pathCmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		fmt.Println(a.configPath)
+
+		return nil
+	}
+
+	getCmd := NewCliCommand("get", "[key]", "print a setting, or every setting without a key")
+	getCmd.Run = func(args ...string) error {
+		if len(args) > 1 {
+			return errUsage
+		}
+
+		cfg, err := config.Load(a.configPath)
+
+		if err != nil {
+			return ExitError{ Code: ExitConfig, Err: err }
+		}
+
+		if len(args) == 0 {
+			for _, setting := range cfg.Settings() {
+				fmt.Printf("%s = %s\n", setting.Key, setting)
+			}
+			return nil
+		}
+
+		value, err := cfg.Get(args[0])
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(value)
+
+		return nil
+	}
+
+	setCmd := NewCliCommand("set", "<key> <value>", "change a setting in the config file")
+	setCmd.Run = func(args ...string) error {
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		cfg, err := config.Load(a.configPath)
+
+		if err != nil {
+			return ExitError{ Code: ExitConfig, Err: err }
+		}
+
+		if err := cfg.Set(args[0], args[1]); err != nil {
+			return err
+		}
+
+		return cfg.Save(a.configPath)
+	}
+
+	cmd := NewCliCommand("config", "", "read and change the config file")
+	cmd.Offline = true
+
+	return cmd.Add(getCmd, setCmd, pathCmd)
+}
@@ -0,0 +1,686 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/daemon"
+	"github.com/arjunmoola/go-spotify/httpapi"
+	"github.com/arjunmoola/go-spotify/mpris"
+	"github.com/arjunmoola/go-spotify/notify"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/poller"
+	"github.com/arjunmoola/go-spotify/smart"
+	"github.com/arjunmoola/go-spotify/statusline"
+	"github.com/arjunmoola/go-spotify/types"
+	"github.com/arjunmoola/go-spotify/utils"
+)
+
+// daemonCacheFor is how long the daemon reuses devices and the queue.
+const daemonCacheFor = 5*time.Second
+
+// smartCheckInterval is how often the daemon looks for smart playlists whose
+// own refresh interval has passed.
+const smartCheckInterval = time.Minute
+
+// tokenMargin renews the access token this long before it expires so no
+// request goes out with a token about to run out.
+const tokenMargin = time.Minute
+
+type daemonToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type daemonInfo struct {
+	Pid int `json:"pid"`
+	Socket string `json:"socket"`
+	StartedAt time.Time `json:"started_at"`
+	Polls int `json:"polls"`
+	PolledAt time.Time `json:"polled_at"`
+	LastError string `json:"last_error,omitempty"`
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+}
+
+func (i daemonInfo) record() output.DaemonStatus {
+	s := output.DaemonStatus{
+		Pid: i.Pid,
+		Socket: i.Socket,
+		StartedAt: i.StartedAt.UTC().Format(time.RFC3339),
+		Polls: i.Polls,
+		LastError: i.LastError,
+		TokenExpiresAt: i.TokenExpiresAt.UTC().Format(time.RFC3339),
+	}
+
+	if !i.PolledAt.IsZero() {
+		s.PolledAt = i.PolledAt.UTC().Format(time.RFC3339)
+	}
+
+	return s
+}
+
+// daemonServer is the state gsp daemon keeps between requests. mu guards
+// the tokens of the app as well, handlers run concurrently.
+type daemonServer struct {
+	a *App
+	interval time.Duration
+	socket string
+	started time.Time
+	poller *poller.Poller
+	stop context.CancelFunc
+	// onChange is told about every change of the playback state and the
+	// events of the poll that found it
+	onChange []func(output.NowPlaying, []poller.Event)
+	notifier *notify.Notifier
+
+	mu sync.Mutex
+	playing types.CurrentlyPlaying
+	snapshot statusline.Snapshot
+	polls int
+	pollErr error
+	devices []output.Device
+	devicesAt time.Time
+	queue []output.QueueItem
+	queueAt time.Time
+}
+
+func (d *daemonServer) token() (daemonToken, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if time.Until(d.a.ExpiresAt()) < tokenMargin {
+		if err := d.a.refreshTokens(); err != nil {
+			return daemonToken{}, err
+		}
+	}
+
+	return daemonToken{
+		AccessToken: d.a.AccessToken(),
+		ExpiresAt: d.a.ExpiresAt(),
+	}, nil
+}
+
+func (d *daemonServer) ctx(parent context.Context) (context.Context, error) {
+	tok, err := d.token()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return client.WithAccessToken(parent, tok.AccessToken), nil
+}
+
+func (d *daemonServer) fetch(ctx context.Context) (types.CurrentlyPlaying, error) {
+	ctx, err := d.ctx(ctx)
+
+	if err != nil {
+		return types.CurrentlyPlaying{}, err
+	}
+
+	return getCurrentlyPlaying(ctx, d.a)
+}
+
+// record caches a poll. The hooks, the notifier and the onChange callbacks
+// run after d.mu was released, since they may block.
+func (d *daemonServer) record(r poller.Result) {
+	d.mu.Lock()
+
+	d.polls++
+	d.pollErr = r.Err
+
+	if r.Err != nil {
+		d.mu.Unlock()
+		logger.Error("daemon poll failed", "err", r.Err)
+		return
+	}
+
+	for _, event := range r.Events {
+		switch event.Kind {
+		case poller.TrackChanged:
+			d.queueAt = time.Time{}
+		case poller.DeviceChanged, poller.VolumeChanged:
+			d.devicesAt = time.Time{}
+		}
+	}
+
+	d.playing = r.Raw
+	d.snapshot = statusline.Snapshot{
+		NowPlaying: r.State,
+		FetchedAt: r.Time,
+	}
+
+	notifier := d.notifier
+	onChange := d.onChange
+
+	d.mu.Unlock()
+
+	for _, event := range r.Events {
+		d.a.runHooks(playbackHookEvent(event))
+
+		if event.Kind == poller.TrackChanged && notifier != nil {
+			notifier.TrackChanged(event.State)
+		}
+	}
+
+	if len(r.Events) > 0 {
+		for _, f := range onChange {
+			f(r.State, r.Events)
+		}
+	}
+}
+
+// notificationAction runs the button pressed on a notification.
+func (d *daemonServer) notificationAction(action notify.Action, n output.NowPlaying) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var err error
+
+	switch action {
+	case notify.Skip:
+		err = daemonPlayer{ d: d }.Skip(ctx, "next")
+	case notify.Like:
+		err = daemonPlayer{ d: d }.Like(ctx, n.Uri)
+	}
+
+	if err != nil {
+		logger.Error("notification action failed", "action", action, "err", err)
+	}
+}
+
+// wakeUp polls right away and drops the cached devices and queue, after
+// playback was changed.
+func (d *daemonServer) wakeUp() {
+	d.mu.Lock()
+	d.queueAt = time.Time{}
+	d.devicesAt = time.Time{}
+	d.mu.Unlock()
+
+	d.poller.Refresh()
+}
+
+// run records every poll of the playback state until ctx is done.
+func (d *daemonServer) run(ctx context.Context) {
+	results := make(chan poller.Result)
+
+	go d.poller.Run(ctx, results)
+
+	for r := range results {
+		d.record(r)
+	}
+}
+
+// schedule runs the background jobs of the tui while the daemon is up, so
+// they run once no matter how many tuis are open.
+func (d *daemonServer) schedule(ctx context.Context) {
+	smartTicker := time.NewTicker(smartCheckInterval)
+	defer smartTicker.Stop()
+
+	var watch <-chan time.Time
+
+	if interval := d.a.config.Intervals.Watch.Duration; interval > 0 {
+		watchTicker := time.NewTicker(interval)
+		defer watchTicker.Stop()
+
+		watch = watchTicker.C
+		d.checkWatched(ctx)
+	}
+
+	d.refreshSmartPlaylists(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-watch:
+			d.checkWatched(ctx)
+		case <-smartTicker.C:
+			d.refreshSmartPlaylists(ctx)
+		}
+	}
+}
+
+func (d *daemonServer) checkWatched(ctx context.Context) {
+	ctx, err := d.ctx(ctx)
+
+	if err != nil {
+		logger.Error("daemon could not check watched playlists", "err", err)
+		return
+	}
+
+	changes, err := playlist.CheckWatched(ctx, d.a.db, d.a.client, d.a.config.Market)
+
+	for _, change := range changes {
+		logger.Info("playlist changed", "change", change.String())
+		d.a.runHooks(playlistHookEvent(change))
+	}
+
+	if err != nil {
+		logger.Error("daemon could not check watched playlists", "err", err)
+	}
+}
+
+func (d *daemonServer) refreshSmartPlaylists(ctx context.Context) {
+	ctx, err := d.ctx(ctx)
+
+	if err != nil {
+		logger.Error("daemon could not refresh smart playlists", "err", err)
+		return
+	}
+
+	results, err := smart.RefreshDue(ctx, d.a.db, d.a.client, time.Now())
+
+	for _, r := range results {
+		logger.Info("smart playlist refreshed", "result", r.String())
+	}
+
+	if err != nil {
+		logger.Error("daemon could not refresh smart playlists", "err", err)
+	}
+}
+
+func (d *daemonServer) cachedDevices(ctx context.Context) ([]output.Device, error) {
+	d.mu.Lock()
+	if time.Since(d.devicesAt) < daemonCacheFor {
+		defer d.mu.Unlock()
+		return d.devices, nil
+	}
+	d.mu.Unlock()
+
+	ctx, err := d.ctx(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := listDevices(ctx, d.a)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.devices = devices
+	d.devicesAt = time.Now()
+
+	return devices, nil
+}
+
+func (d *daemonServer) cachedQueue(ctx context.Context) ([]output.QueueItem, error) {
+	d.mu.Lock()
+	if time.Since(d.queueAt) < daemonCacheFor {
+		defer d.mu.Unlock()
+		return d.queue, nil
+	}
+	d.mu.Unlock()
+
+	ctx, err := d.ctx(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := listQueue(ctx, d.a)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.queue = items
+	d.queueAt = time.Now()
+
+	return items, nil
+}
+
+func (d *daemonServer) register(s *daemon.Server) {
+	s.Handle("token", func(context.Context, json.RawMessage) (any, error) {
+		return d.token()
+	})
+
+	s.Handle("now_playing", func(context.Context, json.RawMessage) (any, error) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if d.pollErr != nil && d.snapshot.FetchedAt.IsZero() {
+			return nil, d.pollErr
+		}
+
+		return d.snapshot, nil
+	})
+
+	s.Handle("currently_playing", func(context.Context, json.RawMessage) (any, error) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if d.pollErr != nil && d.snapshot.FetchedAt.IsZero() {
+			return nil, d.pollErr
+		}
+
+		return d.playing, nil
+	})
+
+	s.Handle("devices", func(ctx context.Context, _ json.RawMessage) (any, error) {
+		return d.cachedDevices(ctx)
+	})
+
+	s.Handle("queue", func(ctx context.Context, _ json.RawMessage) (any, error) {
+		return d.cachedQueue(ctx)
+	})
+
+	// poll is called after changing playback so the next request sees it
+	s.Handle("poll", func(context.Context, json.RawMessage) (any, error) {
+		d.wakeUp()
+		return nil, nil
+	})
+
+	s.Handle("info", func(context.Context, json.RawMessage) (any, error) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		info := daemonInfo{
+			Pid: os.Getpid(),
+			Socket: d.socket,
+			StartedAt: d.started,
+			Polls: d.polls,
+			PolledAt: d.snapshot.FetchedAt,
+			TokenExpiresAt: d.a.ExpiresAt(),
+		}
+
+		if d.pollErr != nil {
+			info.LastError = d.pollErr.Error()
+		}
+
+		return info, nil
+	})
+
+	s.Handle("stop", func(context.Context, json.RawMessage) (any, error) {
+		d.stop()
+		return nil, nil
+	})
+}
+
+func runDaemon(a *App, interval time.Duration, addr string, bus bool, notifications bool) error {
+	if err := a.SetupCli(); err != nil {
+		return ExitError{ Code: ExitAuth, Err: err }
+	}
+
+	// the daemon answers for itself, it must never call another one
+	a.cliOptions.NoDaemon = true
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	d := &daemonServer{
+		a: a,
+		interval: interval,
+		socket: daemon.SocketPath(utils.ConfigDir()),
+		started: time.Now(),
+		stop: stop,
+	}
+
+	d.poller = poller.New(d.fetch, poller.Options{
+		Interval: interval,
+		Paused: max(interval, a.config.Intervals.PlaybackPaused.Duration),
+	})
+
+	server := daemon.NewServer(logger)
+	d.register(server)
+
+	if bus {
+		player, err := mpris.Serve(daemonPlayer{ d: d }, a.cliOptions.Profile, logger)
+
+		if err != nil {
+			return err
+		}
+
+		defer player.Close()
+
+		d.onChange = append(d.onChange, player.Update)
+
+		fmt.Fprintf(os.Stderr, "gsp daemon controllable over mpris as %s\n", player.Name())
+	}
+
+	if notifications {
+		notifier, err := newNotifier(a, d.notificationAction)
+
+		if err != nil {
+			// playback keeps working without them
+			fmt.Fprintf(os.Stderr, "gsp daemon: %v\n", err)
+		} else {
+			d.notifier = notifier
+			defer notifier.Close()
+		}
+	}
+
+	apiErr := make(chan error, 1)
+
+	if addr != "" {
+		token, err := apiToken(a)
+
+		if err != nil {
+			return fmt.Errorf("could not set up the api token: %w", err)
+		}
+
+		api := httpapi.NewServer(daemonPlayer{ d: d }, token, a.config.API.AllowOrigin, logger)
+		d.onChange = append(d.onChange, func(n output.NowPlaying, _ []poller.Event) {
+			api.Publish(n)
+		})
+
+		go func() {
+			err := api.ListenAndServe(ctx, addr)
+
+			if err != nil {
+				stop()
+			}
+
+			apiErr <- err
+		}()
+
+		fmt.Fprintf(os.Stderr, "gsp daemon serving the http api on %s, gsp daemon api-token prints the token\n", addr)
+	} else {
+		apiErr <- nil
+	}
+
+	go d.run(ctx)
+	go d.schedule(ctx)
+
+	fmt.Fprintf(os.Stderr, "gsp daemon listening on %s\n", d.socket)
+
+	err := server.Serve(ctx, d.socket)
+
+	stop()
+
+	return errors.Join(err, <-apiErr)
+}
+
+// callDaemon calls method on the daemon of the profile. ok is false when no
+// daemon is running, the caller then talks to spotify itself.
+func (a *App) callDaemon(ctx context.Context, method string, params any, result any) (bool, error) {
+	if a.cliOptions.NoDaemon {
+		return false, nil
+	}
+
+	c := a.daemon
+
+	if c == nil {
+		conn, err := daemon.Dial(daemon.SocketPath(utils.ConfigDir()))
+
+		if err != nil {
+			return false, nil
+		}
+
+		defer conn.Close()
+
+		c = conn
+	}
+
+	return true, c.Call(ctx, method, params, result)
+}
+
+// daemonRunning reports whether a daemon of the profile is up, the tui then
+// leaves the background jobs to it.
+func (a *App) daemonRunning() bool {
+	ok, err := a.callDaemon(context.Background(), "info", nil, nil)
+	return ok && err == nil
+}
+
+// connectDaemon logs the cli in with the token of a running daemon and
+// keeps the connection for the rest of the command.
+func (a *App) connectDaemon() bool {
+	if a.cliOptions.NoDaemon {
+		return false
+	}
+
+	c, err := daemon.Dial(daemon.SocketPath(utils.ConfigDir()))
+
+	if err != nil {
+		return false
+	}
+
+	var tok daemonToken
+
+	if err := c.Call(context.Background(), "token", nil, &tok); err != nil {
+		logger.Error("daemon did not hand out a token", "err", err)
+		c.Close()
+		return false
+	}
+
+	a.daemon = c
+	a.SetAccessToken(tok.AccessToken)
+	a.SetExpiresAt(tok.ExpiresAt)
+
+	return true
+}
+
+// renewAccessToken takes a new token from the daemon when one runs and
+// refreshes it with spotify otherwise.
+func (a *App) renewAccessToken() error {
+	var tok daemonToken
+
+	ok, err := a.callDaemon(context.Background(), "token", nil, &tok)
+
+	if ok && err == nil {
+		a.SetAccessToken(tok.AccessToken)
+		a.SetExpiresAt(tok.ExpiresAt)
+		return nil
+	}
+
+	return a.refreshTokens()
+}
+
+func DaemonHandler(a *App) *CliCommand {
+	var interval time.Duration
+	var addr string
+	var bus bool
+	var notifications bool
+	cmd := NewCliCommand("daemon", "", "keep the login, playback state and caches in one background process")
+	cmd.Offline = true
+	cmd.Flags.DurationVar(&interval, "interval", 2*time.Second, "how often to ask spotify for the playback state while playing")
+	cmd.Flags.StringVar(&addr, "http", a.config.API.Address, "serve the http api on this host:port, defaults to api.address of the config")
+	cmd.Flags.BoolVar(&bus, "mpris", a.config.MPRIS.Enabled, "control playback over mpris on the session bus, defaults to mpris.enabled of the config")
+	cmd.Flags.BoolVar(&notifications, "notify", a.config.Notifications.Enabled, "show a desktop notification when the track changes, defaults to notifications.enabled of the config")
+	cmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		if interval < 500*time.Millisecond {
+			return usageError("-interval must be at least 500ms")
+		}
+
+		if addr != "" {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				return usageError("-http must be host:port, e.g. 127.0.0.1:8765")
+			}
+		}
+
+		return runDaemon(a, interval, addr, bus, notifications)
+	}
+
+	statusCmd := NewCliCommand("status", "", "show whether the daemon runs and what it is doing")
+	statusCmd.Structured = true
+	statusCmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		var info daemonInfo
+
+		ok, err := a.callDaemon(context.Background(), "info", nil, &info)
+
+		if !ok {
+			return ExitError{ Code: ExitNotFound, Err: fmt.Errorf("no daemon is running, start one with gsp daemon") }
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if p := a.printer(); !p.Text() {
+			return output.Print(p, info.record())
+		}
+
+		fmt.Printf("pid: %d\nsocket: %s\nrunning since: %s\npolls: %d\n", info.Pid, info.Socket, info.StartedAt.Local().Format(time.DateTime), info.Polls)
+
+		if !info.PolledAt.IsZero() {
+			fmt.Printf("last poll: %s\n", info.PolledAt.Local().Format(time.DateTime))
+		}
+
+		fmt.Printf("token expires: %s\n", info.TokenExpiresAt.Local().Format(time.DateTime))
+
+		if info.LastError != "" {
+			fmt.Printf("last error: %s\n", info.LastError)
+		}
+
+		return nil
+	}
+
+	stopCmd := NewCliCommand("stop", "", "stop the running daemon")
+	stopCmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		ok, err := a.callDaemon(context.Background(), "stop", nil, nil)
+
+		if !ok {
+			return ExitError{ Code: ExitNotFound, Err: fmt.Errorf("no daemon is running") }
+		}
+
+		return err
+	}
+
+	tokenCmd := NewCliCommand("api-token", "", "print the token clients of the http api authorize with")
+	tokenCmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		token, err := apiToken(a)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(token)
+
+		return nil
+	}
+
+	return cmd.Add(statusCmd, stopCmd, tokenCmd)
+}
@@ -0,0 +1,365 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+const duplicatesTitle = "Duplicates"
+
+// duplicates are the findings for a playlist together with the snapshot they
+// were found in, so removing them can detect changes made since. selected
+// holds the positions the user picked in the tui.
+type duplicates struct {
+	playlist types.SimplifiedPlaylistObject
+	uris []string
+	findings []playlist.Finding
+	selected map[int]bool
+}
+
+// removable returns the findings the api can remove.
+func (d duplicates) removable() []playlist.Finding {
+	var removable []playlist.Finding
+
+	for _, f := range d.findings {
+		if f.Removable() {
+			removable = append(removable, f)
+		}
+	}
+
+	return removable
+}
+
+// selectedRemovable returns the removable findings selected in the tui.
+func (d duplicates) selectedRemovable() []playlist.Finding {
+	var selected []playlist.Finding
+
+	for _, f := range d.removable() {
+		if d.selected[f.Position] {
+			selected = append(selected, f)
+		}
+	}
+
+	return selected
+}
+
+func (d duplicates) items() []duplicateItem {
+	items := make([]duplicateItem, 0, len(d.findings))
+
+	for _, f := range d.findings {
+		items = append(items, duplicateItem{ Finding: f, selected: d.selected[f.Position] })
+	}
+
+	return items
+}
+
+func findingRow(f playlist.Finding) table.Row {
+	original := ""
+
+	if f.Original >= 0 {
+		original = strconv.Itoa(f.Original+1)
+	}
+
+	name := f.Name
+
+	if name == "" {
+		name = "(no longer available)"
+	}
+
+	return table.Row{ strconv.Itoa(f.Position+1), name, string(f.Reason), original }
+}
+
+// duplicateItem is a finding in the Duplicates table, marked when it is
+// selected for removal.
+type duplicateItem struct {
+	playlist.Finding
+	selected bool
+}
+
+func (i duplicateItem) Row() table.Row {
+	row := findingRow(i.Finding)
+
+	switch {
+	case !i.Removable():
+		row[0] = "    " + row[0]
+	case i.selected:
+		row[0] = "[x] " + row[0]
+	default:
+		row[0] = "[ ] " + row[0]
+	}
+
+	return row
+}
+
+func findDuplicates(ctx context.Context, a *App, p types.SimplifiedPlaylistObject, reasons []playlist.Reason) (duplicates, error) {
+	p, items, err := loadPlaylistItems(ctx, a, p)
+
+	if err != nil {
+		return duplicates{}, err
+	}
+
+	return duplicates{
+		playlist: p,
+		uris: playlist.EditUris(items),
+		findings: playlist.FindDuplicates(items, reasons),
+		selected: make(map[int]bool),
+	}, nil
+}
+
+// removeDuplicates removes the findings in a single batch that fails if the
+// playlist changed after the duplicates were found.
+func removeDuplicates(ctx context.Context, a *App, d duplicates, findings []playlist.Finding) ([]playlist.Op, error) {
+	positions := make([]int, 0, len(findings))
+
+	for _, f := range findings {
+		if f.Removable() {
+			positions = append(positions, f.Position)
+		}
+	}
+
+	ops, err := playlist.Plan(d.uris, playlist.WithoutPositions(d.uris, positions))
+
+	if err != nil || len(ops) == 0 {
+		return ops, err
+	}
+
+	if _, err := playlist.ApplyEdit(ctx, a.client, d.playlist.Id, d.playlist.SnapshotId, ops); err != nil {
+		return nil, err
+	}
+
+	return ops, nil
+}
+
+type FindDuplicatesResult struct {
+	duplicates duplicates
+	err error
+}
+
+func (r FindDuplicatesResult) Err() error {
+	return r.err
+}
+
+type RemoveDuplicatesResult struct {
+	playlist types.SimplifiedPlaylistObject
+	removed int
+	err error
+}
+
+func (r RemoveDuplicatesResult) Err() error {
+	return r.err
+}
+
+func FindDuplicatesCmd(a *App, p types.SimplifiedPlaylistObject) tea.Cmd {
+	return func() tea.Msg {
+		d, err := findDuplicates(defaultAccessTokenCtx(a), a, p, playlist.DefaultReasons)
+
+		return FindDuplicatesResult{
+			duplicates: d,
+			err: err,
+		}
+	}
+}
+
+func RemoveDuplicatesCmd(a *App, d duplicates) tea.Cmd {
+	return func() tea.Msg {
+		findings := d.selectedRemovable()
+		_, err := removeDuplicates(defaultAccessTokenCtx(a), a, d, findings)
+
+		return RemoveDuplicatesResult{
+			playlist: d.playlist,
+			removed: len(findings),
+			err: err,
+		}
+	}
+}
+
+// toggleDuplicate selects or unselects the finding under the cursor of the
+// Duplicates table for removal.
+func toggleDuplicate(a *App, t Table[Rower]) {
+	item, ok := t.SelectedItem().(duplicateItem)
+
+	if !ok || !a.duplicates.Valid {
+		return
+	}
+
+	if !item.Removable() {
+		a.AppendMessage("this item is no longer available and cannot be removed")
+		return
+	}
+
+	d := a.duplicates.Value
+	d.selected[item.Position] = !d.selected[item.Position]
+
+	SetTableItems(&t, toRows(d.items()))
+	SetModel(a, t, "table")
+}
+
+// handleDedupe lists the duplicates of the selected playlist. Pressing the
+// key again while they are shown removes the ones selected with enter.
+func handleDedupe(a *App) tea.Cmd {
+	if t, ok := a.grid.At(a.grid.Cursor()).(Table[Rower]); ok && t.Title() == duplicatesTitle && a.duplicates.Valid {
+		d := a.duplicates.Value
+
+		if len(d.selectedRemovable()) == 0 {
+			a.AppendMessage("nothing selected, press enter on the items to remove from " + d.playlist.Name)
+			return nil
+		}
+
+		a.duplicates = Optional[duplicates]{}
+		a.AppendMessage(fmt.Sprintf("removing %d items from %s", len(d.selectedRemovable()), d.playlist.Name))
+
+		return RemoveDuplicatesCmd(a, d)
+	}
+
+	p, ok := a.selectedPlaylist()
+
+	if !ok {
+		a.AppendMessage("select a playlist to find duplicates in")
+		return nil
+	}
+
+	if !a.canEdit(p) {
+		a.AppendMessage(p.Name + " belongs to " + p.Owner.Id + " and cannot be edited")
+		return nil
+	}
+
+	return FindDuplicatesCmd(a, p)
+}
+
+// parsePositions parses a selection like "all", "none" or "3,5-7" of the
+// one based positions in findings.
+func parsePositions(s string, findings []playlist.Finding) ([]playlist.Finding, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	switch s {
+	case "all", "a", "y", "yes":
+		return findings, nil
+	case "", "none", "n", "no":
+		return nil, nil
+	}
+
+	byPosition := make(map[int]playlist.Finding)
+
+	for _, f := range findings {
+		byPosition[f.Position+1] = f
+	}
+
+	var selected []playlist.Finding
+
+	for _, field := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(field), "-")
+		from, err := strconv.Atoi(first)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid position %q", field)
+		}
+
+		to := from
+
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil || to < from {
+				return nil, fmt.Errorf("invalid range %q", field)
+			}
+		}
+
+		for pos := from; pos <= to; pos++ {
+			f, ok := byPosition[pos]
+
+			if !ok {
+				if !isRange {
+					return nil, fmt.Errorf("%d is not one of the listed positions", pos)
+				}
+				continue
+			}
+
+			selected = append(selected, f)
+		}
+	}
+
+	return selected, nil
+}
+
+func printFindings(findings []playlist.Finding) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "POSITION\tREASON\tDUPLICATE OF\tITEM")
+
+	for _, f := range findings {
+		row := findingRow(f)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", row[0], row[2], row[3], row[1])
+	}
+
+	return tw.Flush()
+}
+
+type dedupeOptions struct {
+	by string
+	yes bool
+	dryRun bool
+}
+
+func dedupePlaylist(ctx context.Context, a *App, p types.SimplifiedPlaylistObject, opts dedupeOptions) error {
+	reasons, err := playlist.ParseReasons(opts.by)
+
+	if err != nil {
+		return err
+	}
+
+	d, err := findDuplicates(ctx, a, p, reasons)
+
+	if err != nil {
+		return err
+	}
+
+	if len(d.findings) == 0 {
+		fmt.Printf("no duplicates in %s\n", p.Name)
+		return nil
+	}
+
+	if err := printFindings(d.findings); err != nil {
+		return err
+	}
+
+	removable := d.removable()
+
+	if len(removable) < len(d.findings) {
+		fmt.Printf("\n%d items are no longer available and can only be removed in the spotify app\n", len(d.findings)-len(removable))
+	}
+
+	if opts.dryRun || len(removable) == 0 {
+		return nil
+	}
+
+	selected := removable
+
+	if !opts.yes {
+		fmt.Printf("\nremove which items from %s? [all/none/positions like 3,5-7] ", p.Name)
+
+		selected, err = parsePositions(readLine(), removable)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("nothing removed")
+		return nil
+	}
+
+	if _, err := removeDuplicates(ctx, a, d, selected); err != nil {
+		return err
+	}
+
+	fmt.Printf("removed %d items from %s\n", len(selected), p.Name)
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/arjunmoola/go-spotify/playlist"
+)
+
+func testDuplicates() duplicates {
+	return duplicates{
+		findings: []playlist.Finding{
+			{ Position: 1, Uri: "spotify:track:a", Reason: playlist.ReasonUri, Original: 0 },
+			{ Position: 2, Reason: playlist.ReasonUnavailable, Original: -1 },
+			{ Position: 3, Uri: "spotify:track:b", Reason: playlist.ReasonTitle, Original: 0 },
+		},
+		selected: make(map[int]bool),
+	}
+}
+
+func positions(findings []playlist.Finding) []int {
+	var p []int
+
+	for _, f := range findings {
+		p = append(p, f.Position)
+	}
+
+	return p
+}
+
+func TestDuplicatesRemovableIgnoresSelection(t *testing.T) {
+	d := testDuplicates()
+
+	if got := positions(d.removable()); len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Errorf("removable positions %v, want [1 3]", got)
+	}
+
+	if got := d.selectedRemovable(); len(got) != 0 {
+		t.Errorf("selected %v before anything was selected", positions(got))
+	}
+}
+
+func TestDuplicatesSelectedRemovable(t *testing.T) {
+	d := testDuplicates()
+	d.selected[2] = true
+	d.selected[3] = true
+
+	if got := positions(d.selectedRemovable()); len(got) != 1 || got[0] != 3 {
+		t.Errorf("selected removable positions %v, want [3]", got)
+	}
+
+	if got := positions(d.removable()); len(got) != 2 {
+		t.Errorf("removable positions %v, want every removable finding", got)
+	}
+}
@@ -0,0 +1,451 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+type playlistDiff struct {
+	a types.SimplifiedPlaylistObject
+	b types.SimplifiedPlaylistObject
+	diff playlist.Diff
+}
+
+func playlistEntries(ctx context.Context, a *App, ref string) (types.SimplifiedPlaylistObject, []playlist.Entry, error) {
+	p, err := playlist.Resolve(ctx, a.db, a.client, ref)
+
+	if err != nil {
+		return p, nil, err
+	}
+
+	items, err := a.client.GetAllPlaylistItems(ctx, p.Id, a.config.Market)
+
+	if err != nil {
+		return p, nil, err
+	}
+
+	return p, playlist.Entries(items), nil
+}
+
+func diffPlaylists(ctx context.Context, a *App, refA, refB string) (playlistDiff, error) {
+	pa, entriesA, err := playlistEntries(ctx, a, refA)
+
+	if err != nil {
+		return playlistDiff{}, err
+	}
+
+	pb, entriesB, err := playlistEntries(ctx, a, refB)
+
+	if err != nil {
+		return playlistDiff{}, err
+	}
+
+	return playlistDiff{
+		a: pa,
+		b: pb,
+		diff: playlist.Compare(entriesA, entriesB),
+	}, nil
+}
+
+func entryName(e playlist.Entry) string {
+	if artists := e.ArtistNames(); artists != "" {
+		return artists + " - " + e.Name
+	}
+
+	return e.Name
+}
+
+func (d playlistDiff) records() []output.DiffEntry {
+	sides := []struct {
+		side string
+		entries []playlist.Entry
+	}{
+		{ "a", d.diff.OnlyA },
+		{ "b", d.diff.OnlyB },
+		{ "both", d.diff.Common },
+	}
+
+	var records []output.DiffEntry
+
+	for _, s := range sides {
+		for _, e := range s.entries {
+			records = append(records, output.DiffEntry{
+				Side: s.side,
+				Uri: e.Uri,
+				Title: e.Name,
+				Artists: append([]string{}, e.Artists...),
+				Album: e.Album,
+			})
+		}
+	}
+
+	return records
+}
+
+func printDiff(d playlistDiff) {
+	sections := []struct {
+		title string
+		entries []playlist.Entry
+	}{
+		{ "only in " + d.a.Name, d.diff.OnlyA },
+		{ "only in " + d.b.Name, d.diff.OnlyB },
+		{ "in both", d.diff.Common },
+	}
+
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("%s (%d)\n", section.title, len(section.entries))
+
+		for _, e := range section.entries {
+			fmt.Printf("\t%s\t%s\n", entryName(e), e.Uri)
+		}
+	}
+}
+
+type mergeOptions struct {
+	into string
+	mode string
+	dedupe bool
+}
+
+// mergePlaylists appends the merge of a and b to the playlist into, which is
+// created when there is no playlist with that name.
+func mergePlaylists(ctx context.Context, a *App, refA, refB string, opts mergeOptions) error {
+	mode, err := playlist.ParseMergeMode(opts.mode)
+
+	if err != nil {
+		return err
+	}
+
+	_, entriesA, err := playlistEntries(ctx, a, refA)
+
+	if err != nil {
+		return err
+	}
+
+	_, entriesB, err := playlistEntries(ctx, a, refB)
+
+	if err != nil {
+		return err
+	}
+
+	merged := playlist.Merge(entriesA, entriesB, mode)
+
+	target, existing, err := playlistEntries(ctx, a, opts.into)
+
+	var notFound playlist.NotFoundError
+
+	switch {
+	case errors.As(err, &notFound):
+		user, err := a.client.GetCurrentUserProfile(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		p, err := a.client.CreatePlaylist(ctx, client.CreatePlaylistParams{
+			UserId: user.Id,
+			Name: opts.into,
+			Description: fmt.Sprintf("%s of %s and %s", mode, refA, refB),
+		})
+
+		if err != nil {
+			return fmt.Errorf("creating playlist %s: %w", opts.into, err)
+		}
+
+		target.Id = p.Id
+		target.Name = p.Name
+	case err != nil:
+		return err
+	}
+
+	if opts.dedupe {
+		merged = playlist.Dedupe(merged, existing)
+	}
+
+	uris := make([]string, 0, len(merged))
+
+	for _, e := range merged {
+		// local files only exist on the device that added them
+		if !strings.HasPrefix(e.Uri, "spotify:local:") {
+			uris = append(uris, e.Uri)
+		}
+	}
+
+	if len(uris) == 0 {
+		fmt.Printf("nothing to add to %s\n", target.Name)
+		return nil
+	}
+
+	if _, err := playlist.AddUris(ctx, a.client, target.Id, uris); err != nil {
+		return err
+	}
+
+	fmt.Printf("added %d tracks to %s\n", len(uris), target.Name)
+
+	return nil
+}
+
+// parseInterspersed parses flags that come after positional arguments too,
+// so "merge A B -into C" works like "merge -into C A B".
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// splitArgs splits a command typed in the tui into words, keeping quoted
+// playlist names together.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	var quote rune
+	inWord := false
+
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+
+	if inWord {
+		args = append(args, word.String())
+	}
+
+	return args, nil
+}
+
+type DiffPlaylistsResult struct {
+	diff playlistDiff
+	err error
+}
+
+func (r DiffPlaylistsResult) Err() error {
+	return r.err
+}
+
+func DiffPlaylistsCmd(a *App, refA, refB string) tea.Cmd {
+	return func() tea.Msg {
+		d, err := diffPlaylists(defaultAccessTokenCtx(a), a, refA, refB)
+
+		return DiffPlaylistsResult{
+			diff: d,
+			err: err,
+		}
+	}
+}
+
+// diffView shows a diff side by side in place of the grid until it is closed
+// with esc.
+type diffView struct {
+	diff playlistDiff
+	offset int
+}
+
+func (v *diffView) scroll(n int) {
+	rows := max(len(v.diff.diff.OnlyA), len(v.diff.diff.OnlyB), len(v.diff.diff.Common))
+	v.offset = max(0, min(v.offset+n, rows-1))
+}
+
+func (v diffView) View(styles AppStyles, width, height int) string {
+	columnWidth := max(width/3-2, 10)
+	rows := max(height-4, 1)
+
+	column := func(title string, entries []playlist.Entry) string {
+		lines := []string{ fmt.Sprintf("%s (%d)", title, len(entries)), "" }
+
+		for i := v.offset; i < len(entries) && i < v.offset+rows; i++ {
+			name := entryName(entries[i])
+
+			if r := []rune(name); len(r) > columnWidth {
+				name = string(r[:columnWidth-1]) + "…"
+			}
+
+			lines = append(lines, name)
+		}
+
+		return styles.artist.Width(columnWidth).Height(rows+2).Render(strings.Join(lines, "\n"))
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		column("Only in " + v.diff.a.Name, v.diff.diff.OnlyA),
+		column("In both", v.diff.diff.Common),
+		column("Only in " + v.diff.b.Name, v.diff.diff.OnlyB),
+	)
+}
+
+// updateDiffView handles keys while the diff is shown and reports whether it
+// consumed msg.
+func (a *App) updateDiffView(msg tea.KeyMsg) bool {
+	if !a.diffView.Valid {
+		return false
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		a.diffView = Optional[diffView]{}
+	case "down", "j":
+		a.diffView.Value.scroll(1)
+	case "up", "k":
+		a.diffView.Value.scroll(-1)
+	case "pgdown", "ctrl+d":
+		a.diffView.Value.scroll(10)
+	case "pgup", "ctrl+u":
+		a.diffView.Value.scroll(-10)
+	case "ctrl+c":
+		return false
+	}
+
+	return true
+}
+
+const commandUsage = "commands: diff <playlist> <playlist>, sort <key> [reverse], shuffle [count], save <queue|session> [default|name]"
+
+// runCommand runs a command entered after pressing the command key.
+func runCommand(a *App, input string) tea.Cmd {
+	args, err := splitArgs(input)
+
+	if err != nil {
+		a.AppendMessage(err.Error())
+		return nil
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	switch args[0] {
+	case "diff":
+		if len(args) != 3 {
+			a.AppendMessage("usage: diff <playlist> <playlist>, quote names with spaces")
+			return nil
+		}
+
+		a.AppendMessage(fmt.Sprintf("comparing %s and %s", args[1], args[2]))
+
+		return DiffPlaylistsCmd(a, args[1], args[2])
+	case "sort":
+		if len(args) < 2 || len(args) > 3 || (len(args) == 3 && args[2] != "reverse") {
+			a.AppendMessage("usage: sort <artist|album|release|added|popularity|duration|random> [reverse]")
+			return nil
+		}
+
+		key, err := playlist.ParseSortKey(args[1])
+
+		if err != nil {
+			a.AppendMessage(err.Error())
+			return nil
+		}
+
+		p, ok := a.selectedPlaylist()
+
+		if !ok {
+			a.AppendMessage("select a playlist to sort")
+			return nil
+		}
+
+		if !a.canEdit(p) {
+			a.AppendMessage(p.Name + " belongs to " + p.Owner.Id + " and cannot be edited")
+			return nil
+		}
+
+		a.AppendMessage(fmt.Sprintf("sorting %s by %s", p.Name, key))
+
+		return SortPlaylistCmd(a, p, sortOptions{
+			by: string(key),
+			reverse: len(args) == 3,
+			seed: uint64(time.Now().UnixNano()),
+		})
+	case "shuffle":
+		count := defaultQueueCount
+
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+
+			if err != nil || n < 1 {
+				a.AppendMessage("usage: shuffle [count]")
+				return nil
+			}
+
+			count = n
+		}
+
+		p, ok := a.selectedPlaylist()
+
+		if !ok {
+			a.AppendMessage("select a playlist to shuffle into the queue")
+			return nil
+		}
+
+		a.AppendMessage(fmt.Sprintf("queueing a shuffle of %s", p.Name))
+
+		return QueueShuffleCmd(a, p, count)
+	case "save":
+		if len(args) < 2 || (args[1] != sourceQueue && args[1] != sourceSession) {
+			a.AppendMessage("usage: save <queue|session> [default|name]")
+			return nil
+		}
+
+		opts := saveOptions{ source: args[1], since: a.sessionStart }
+
+		if len(args) > 2 {
+			if args[2] == "default" && len(args) == 3 {
+				opts.toDefault = true
+			} else {
+				opts.name = strings.Join(args[2:], " ")
+			}
+		}
+
+		a.AppendMessage("saving the " + opts.source + " as a playlist")
+
+		return SaveTracksCmd(a, opts)
+	}
+
+	a.AppendMessage("unknown command " + args[0] + ", " + commandUsage)
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// playlistEdit is a playlist written to a temporary file for editing.
+type playlistEdit struct {
+	playlist types.SimplifiedPlaylistObject
+	uris []string
+	path string
+}
+
+func (e playlistEdit) discard() {
+	os.Remove(e.path)
+}
+
+// editorCommand runs $VISUAL or $EDITOR on path, falling back to vi. The
+// variables may contain arguments like "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	args := strings.Fields(editor)
+
+	if len(args) == 0 {
+		args = []string{ "vi" }
+	}
+
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// loadPlaylistItems returns the items of p with the snapshot they belong to.
+// The snapshot is read before the items so a change in between is reported
+// as a conflict when applying instead of being overwritten.
+func loadPlaylistItems(ctx context.Context, a *App, p types.SimplifiedPlaylistObject) (types.SimplifiedPlaylistObject, []types.PlaylistItemUnion, error) {
+	current, err := a.client.GetPlaylist(ctx, client.GetPlaylistParams{
+		Id: p.Id,
+		Fields: []string{ "snapshot_id" },
+	})
+
+	if err != nil {
+		return p, nil, err
+	}
+
+	p.SnapshotId = current.SnapshotId
+
+	items, err := a.client.GetAllPlaylistItems(ctx, p.Id, a.config.Market)
+
+	return p, items, err
+}
+
+func prepareEdit(ctx context.Context, a *App, p types.SimplifiedPlaylistObject) (playlistEdit, error) {
+	p, items, err := loadPlaylistItems(ctx, a, p)
+
+	if err != nil {
+		return playlistEdit{}, err
+	}
+
+	file, err := os.CreateTemp("", "gsp-playlist-*.txt")
+
+	if err != nil {
+		return playlistEdit{}, err
+	}
+
+	defer file.Close()
+
+	if err := playlist.WriteEditFile(file, p, items); err != nil {
+		os.Remove(file.Name())
+		return playlistEdit{}, err
+	}
+
+	return playlistEdit{
+		playlist: p,
+		uris: playlist.EditUris(items),
+		path: file.Name(),
+	}, nil
+}
+
+// finishEdit applies the edited file. The file is removed unless applying
+// failed, so the changes are not lost.
+func finishEdit(ctx context.Context, a *App, e playlistEdit) ([]playlist.Op, error) {
+	file, err := os.Open(e.path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	uris, err := playlist.ParseEditFile(file)
+	file.Close()
+
+	if err == nil {
+		var ops []playlist.Op
+
+		ops, err = playlist.Plan(e.uris, uris)
+
+		if err == nil && len(ops) > 0 {
+			_, err = playlist.ApplyEdit(ctx, a.client, e.playlist.Id, e.playlist.SnapshotId, ops)
+		}
+
+		if err == nil {
+			os.Remove(e.path)
+			return ops, nil
+		}
+	}
+
+	if errors.Is(err, playlist.ErrConflict) {
+		return nil, fmt.Errorf("%w, reload it and apply your edit from %s again", err, e.path)
+	}
+
+	return nil, fmt.Errorf("%w, your edit is kept in %s", err, e.path)
+}
+
+func describeOps(ops []playlist.Op) string {
+	if len(ops) == 0 {
+		return "no changes"
+	}
+
+	descriptions := make([]string, 0, len(ops))
+
+	for _, op := range ops {
+		descriptions = append(descriptions, op.String())
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+type PrepareEditResult struct {
+	edit playlistEdit
+	err error
+}
+
+func (r PrepareEditResult) Err() error {
+	return r.err
+}
+
+type EditorClosedResult struct {
+	edit playlistEdit
+	err error
+}
+
+func (r EditorClosedResult) Err() error {
+	return r.err
+}
+
+type EditPlaylistResult struct {
+	playlist types.SimplifiedPlaylistObject
+	ops []playlist.Op
+	err error
+}
+
+func (r EditPlaylistResult) Err() error {
+	return r.err
+}
+
+func PrepareEditCmd(a *App, p types.SimplifiedPlaylistObject) tea.Cmd {
+	return func() tea.Msg {
+		edit, err := prepareEdit(defaultAccessTokenCtx(a), a, p)
+
+		return PrepareEditResult{
+			edit: edit,
+			err: err,
+		}
+	}
+}
+
+// OpenEditorCmd suspends the program while the editor runs.
+func OpenEditorCmd(e playlistEdit) tea.Cmd {
+	return tea.ExecProcess(editorCommand(e.path), func(err error) tea.Msg {
+		return EditorClosedResult{
+			edit: e,
+			err: err,
+		}
+	})
+}
+
+func ApplyEditCmd(a *App, e playlistEdit) tea.Cmd {
+	return func() tea.Msg {
+		ops, err := finishEdit(defaultAccessTokenCtx(a), a, e)
+
+		return EditPlaylistResult{
+			playlist: e.playlist,
+			ops: ops,
+			err: err,
+		}
+	}
+}
+
+// canEdit reports whether the user can change the items of p. It is true
+// before the profile is loaded and lets the api have the last word.
+func (a *App) canEdit(p types.SimplifiedPlaylistObject) bool {
+	user, ok := a.profile()
+
+	return !ok || p.Owner.Id == user || p.Collaborative
+}
+
+func handleEdit(a *App) tea.Cmd {
+	p, ok := a.selectedPlaylist()
+
+	if !ok {
+		a.AppendMessage("select a playlist to edit")
+		return nil
+	}
+
+	if !a.canEdit(p) {
+		a.AppendMessage(p.Name + " belongs to " + p.Owner.Id + " and cannot be edited")
+		return nil
+	}
+
+	return PrepareEditCmd(a, p)
+}
+
+// editPlaylist is the cli version of the tui flow, the editor runs on the
+// terminal gsp was started from.
+func editPlaylist(ctx context.Context, a *App, p types.SimplifiedPlaylistObject) error {
+	edit, err := prepareEdit(ctx, a, p)
+
+	if err != nil {
+		return err
+	}
+
+	cmd := editorCommand(edit.path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		edit.discard()
+		return fmt.Errorf("editor: %w", err)
+	}
+
+	ops, err := finishEdit(ctx, a, edit)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %s\n", p.Name, describeOps(ops))
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/history"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+type RecordPlayHistoryResult struct {
+	recorded int
+	err error
+}
+
+func (r RecordPlayHistoryResult) Err() error {
+	return r.err
+}
+
+func RecordPlayHistoryCmd(a *App, items []types.PlayHistory) tea.Cmd {
+	return func() tea.Msg {
+		plays := make([]history.Play, 0, len(items))
+
+		for _, item := range items {
+			play, err := history.FromPlayHistory(item)
+
+			if err != nil {
+				return RecordPlayHistoryResult{ err: err }
+			}
+
+			plays = append(plays, play)
+		}
+
+		n, err := history.Record(context.Background(), database.New(a.db), plays)
+
+		return RecordPlayHistoryResult{
+			recorded: n,
+			err: err,
+		}
+	}
+}
+
+func HistoryHandler(a *App) *CliCommand {
+	importCmd := NewCliCommand("import", "<dir|zip>", "import the streaming history of a spotify data export")
+	importCmd.Structured = true
+	importCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		printer := a.printer()
+		importer := history.NewImporter(a.db)
+
+		if printer.Text() {
+			importer.OnProgress(func(p history.Progress) {
+				fmt.Printf("[%d/%d] %s: %d plays, %d new, %d duplicates\n", p.FileIndex, p.FileCount, p.File, p.Plays, p.Imported, p.Duplicates)
+			})
+		}
+
+		result, err := importer.Import(context.Background(), args[0])
+
+		if err != nil {
+			return err
+		}
+
+		if !printer.Text() {
+			return output.Print(printer, output.HistoryImport{
+				Files: result.Files,
+				Plays: result.Plays,
+				Imported: result.Imported,
+				Duplicates: result.Duplicates,
+			})
+		}
+
+		fmt.Printf("imported %d of %d plays from %d files (%d already recorded)\n", result.Imported, result.Plays, result.Files, result.Duplicates)
+
+		return nil
+	}
+
+	cmd := NewCliCommand("history", "", "manage the local play history")
+	cmd.Offline = true
+
+	return cmd.Add(importCmd)
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/config"
+	"github.com/arjunmoola/go-spotify/hooks"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/poller"
+)
+
+type HookFailedResult struct {
+	err error
+}
+
+// runHooks starts the hooks of e. Failures go to the messages pane in the
+// tui and to stderr otherwise, so the failures of hooks run by gsp daemon
+// only show up in the daemon's output.
+func (a *App) runHooks(e hooks.Event) {
+	if len(a.config.Hooks.Commands) == 0 {
+		return
+	}
+
+	a.hookRunner().Fire(e)
+}
+
+// hookRunner returns the runner of the hooks, which is created once.
+func (a *App) hookRunner() *hooks.Runner {
+	a.hooksOnce.Do(func() {
+		a.hooks = hooks.New(a.config.Hooks, logger, func(err error) {
+			if a.hookErrors == nil {
+				fmt.Fprintf(os.Stderr, "gsp: %v\n", err)
+				return
+			}
+
+			select {
+			case a.hookErrors <- err:
+			default:
+			}
+		})
+	})
+
+	return a.hooks
+}
+
+// waitForHooks lets running hooks finish before a command exits.
+func (a *App) waitForHooks() {
+	a.hookRunner().Wait()
+}
+
+// WatchHookErrorsCmd reports failed hooks to the tui.
+func WatchHookErrorsCmd(a *App) tea.Cmd {
+	if a.hookErrors == nil {
+		a.hookErrors = make(chan error, 16)
+	}
+
+	return func() tea.Msg {
+		return HookFailedResult{ err: <-a.hookErrors }
+	}
+}
+
+func playbackHookEvent(e poller.Event) hooks.Event {
+	n := e.State
+	env := map[string]string{
+		"GSP_PLAYING": strconv.FormatBool(n.Playing),
+		"GSP_TYPE": n.Type,
+		"GSP_URI": n.Uri,
+		"GSP_TITLE": n.Title,
+		"GSP_ARTIST": strings.Join(n.Artists, ", "),
+		"GSP_ALBUM": n.Album,
+		"GSP_DURATION_MS": strconv.Itoa(n.DurationMs),
+		"GSP_PROGRESS_MS": strconv.Itoa(n.ProgressMs),
+		"GSP_SHUFFLE": strconv.FormatBool(n.Shuffle),
+		"GSP_REPEAT": n.Repeat,
+		"GSP_CONTEXT": n.Context,
+		"GSP_DEVICE": n.Device.Name,
+		"GSP_DEVICE_ID": n.Device.Id,
+		"GSP_VOLUME": volumeText(n.Device),
+		"GSP_IMAGE": n.Image,
+	}
+
+	return hooks.Event{ Name: string(e.Kind), Data: e, Env: env }
+}
+
+func volumeText(d output.Device) string {
+	if d.Volume == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*d.Volume)
+}
+
+// playlistHookEvent is the playlist_modified event of a change found in a
+// watched playlist.
+func playlistHookEvent(c playlist.Change) hooks.Event {
+	data := struct {
+		Event string `json:"event"`
+		playlist.Change
+	}{ config.PlaylistModified, c }
+
+	env := map[string]string{
+		"GSP_PLAYLIST_ID": c.PlaylistId,
+		"GSP_PLAYLIST_NAME": c.PlaylistName,
+		"GSP_ADDED": strconv.Itoa(len(c.Added)),
+		"GSP_REMOVED": strconv.Itoa(len(c.Removed)),
+		"GSP_ADDED_BY": strings.Join(c.AddedBy(), ", "),
+	}
+
+	return hooks.Event{ Name: config.PlaylistModified, Data: data, Env: env }
+}
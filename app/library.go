@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/library"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+type LoadLibraryResult struct {
+	playlists []types.SimplifiedPlaylistObject
+	items map[string][]types.PlaylistItemUnion
+	savedTracks []types.SavedTrack
+	err error
+}
+
+func (r LoadLibraryResult) Err() error {
+	return r.err
+}
+
+type SyncLibraryResult struct {
+	result library.SyncResult
+	err error
+}
+
+func (r SyncLibraryResult) Err() error {
+	return r.err
+}
+
+// LoadLibraryCmd reads the local mirror of the library so the tui can render
+// it before the api has been contacted.
+func LoadLibraryCmd(a *App) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		q := database.New(a.db)
+
+		playlists, err := library.Playlists(ctx, q)
+
+		if err != nil {
+			return LoadLibraryResult{ err: err }
+		}
+
+		items := make(map[string][]types.PlaylistItemUnion)
+
+		for _, playlist := range playlists {
+			playlistItems, err := library.PlaylistItems(ctx, q, playlist.Id)
+
+			if err != nil {
+				return LoadLibraryResult{ err: err }
+			}
+
+			items[playlist.Id] = playlistItems
+		}
+
+		savedTracks, err := library.SavedTracks(ctx, q)
+
+		if err != nil {
+			return LoadLibraryResult{ err: err }
+		}
+
+		return LoadLibraryResult{
+			playlists: playlists,
+			items: items,
+			savedTracks: savedTracks,
+		}
+	}
+}
+
+func SyncLibraryCmd(a *App) tea.Cmd {
+	return func() tea.Msg {
+		syncer := library.NewSyncer(a.db, a.client, a.config.Market)
+		result, err := syncer.Sync(defaultAccessTokenCtx(a))
+
+		return SyncLibraryResult{
+			result: result,
+			err: err,
+		}
+	}
+}
+
+func (a *App) setLibrary(msg LoadLibraryResult) {
+	if len(msg.playlists) > 0 {
+		a.data["playlists"] = msg.playlists
+		SetSideBarItems(a, "Playlists", msg.playlists)
+	}
+
+	for id, items := range msg.items {
+		a.data[id] = items
+	}
+
+	if len(msg.savedTracks) > 0 {
+		a.data["saved_tracks"] = msg.savedTracks
+	}
+}
+
+func SyncHandler(a *App) *CliCommand {
+	var full bool
+	cmd := NewCliCommand("sync", "", "mirror the library into the local database")
+	cmd.Flags.BoolVar(&full, "full", false, "refetch everything instead of only what changed")
+	cmd.Structured = true
+	cmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		printer := a.printer()
+		syncer := library.NewSyncer(a.db, a.client, a.config.Market)
+		syncer.SetFull(full)
+
+		if printer.Text() {
+			syncer.OnProgress(func(msg string) {
+				fmt.Println(msg)
+			})
+		}
+
+		result, err := syncer.Sync(defaultAccessTokenCtx(a))
+
+		if !printer.Text() {
+			record := output.SyncResult{
+				Playlists: result.Playlists,
+				PlaylistsUpdated: result.PlaylistsUpdated,
+				PlaylistsUnchanged: result.PlaylistsSkipped,
+				PlaylistsRemoved: result.PlaylistsRemoved,
+				SavedTracks: result.SavedTracks,
+				SavedAlbums: result.SavedAlbums,
+				FollowedArtists: result.FollowedArtists,
+				Warnings: append([]string{}, result.Warnings...),
+			}
+
+			return errors.Join(output.Print(printer, record), err)
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "gsp: %s\n", warning)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("synced %s\n", result)
+
+		return nil
+	}
+
+	return cmd
+}
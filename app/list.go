@@ -242,6 +242,10 @@ func (l *List) SetTitle(title string) {
 	l.l.Title = title
 }
 
+func (l *List) SetTitleColor(c lipgloss.TerminalColor) {
+	l.l.Styles.Title = l.l.Styles.Title.Foreground(c)
+}
+
 func (l List) Init() tea.Cmd {
 	return nil
 }
@@ -307,6 +311,42 @@ func playHistoryColumnsWidth(w int) []table.Column {
 	}
 }
 
+func localSearchColumns() []table.Column {
+	return []table.Column{
+		{ Title: "Name", Width: 30 },
+		{ Title: "Artist", Width: 20 },
+		{ Title: "Album", Width: 20 },
+		{ Title: "Playlists", Width: 30 },
+	}
+}
+
+func localSearchColumnsWidth(w int) []table.Column {
+	return []table.Column{
+		{ Title: "Name", Width: int(float64(w)*0.30) },
+		{ Title: "Artist", Width: int(float64(w)*0.20) },
+		{ Title: "Album", Width: int(float64(w)*0.20) },
+		{ Title: "Playlists", Width: int(float64(w)*0.30) },
+	}
+}
+
+func duplicatesColumns() []table.Column {
+	return []table.Column{
+		{ Title: "Position", Width: 10 },
+		{ Title: "Item", Width: 50 },
+		{ Title: "Reason", Width: 15 },
+		{ Title: "Duplicate Of", Width: 15 },
+	}
+}
+
+func duplicatesColumnsWidth(w int) []table.Column {
+	return []table.Column{
+		{ Title: "Position", Width: int(float64(w)*0.10) },
+		{ Title: "Item", Width: int(float64(w)*0.55) },
+		{ Title: "Reason", Width: int(float64(w)*0.15) },
+		{ Title: "Duplicate Of", Width: int(float64(w)*0.20) },
+	}
+}
+
 func NewTable[T Rower](columns []table.Column) Table[T] {
 	t := table.New()
 	t.SetColumns(columns)
@@ -337,6 +377,10 @@ func (t *Table[T]) SetTitleAndColumns(title string) {
 	switch title {
 	case "Recently Played", "Current Session":
 		t.t.SetColumns(playHistoryColumns())
+	case "Local Search":
+		t.t.SetColumns(localSearchColumns())
+	case duplicatesTitle:
+		t.t.SetColumns(duplicatesColumns())
 	default:
 		t.t.SetColumns(defaultColumns())
 	}
@@ -372,6 +416,10 @@ func (t *Table[T]) SetWidth(w int) {
 
 	if len(t.Columns()) == 5 {
 		columns = playHistoryColumnsWidth(w)
+	} else if t.title == "Local Search" {
+		columns = localSearchColumnsWidth(w)
+	} else if t.title == duplicatesTitle {
+		columns = duplicatesColumnsWidth(w)
 	} else {
 		columns = defaultColumnsWithWidth(w)
 	}
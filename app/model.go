@@ -1,7 +1,6 @@
 package app
 
 import (
-	"time"
 	"fmt"
 	"strings"
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,6 +10,9 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/arjunmoola/go-spotify/models/textinput"
 	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/config"
+	"github.com/arjunmoola/go-spotify/poller"
+	"github.com/arjunmoola/go-spotify/notify"
 	"github.com/arjunmoola/go-spotify/models/grid"
 	"github.com/arjunmoola/go-spotify/types"
 	"github.com/arjunmoola/go-spotify/models/media"
@@ -40,11 +42,22 @@ func (a *App) getSpotifyData() tea.Cmd {
 	b.Append(GetUsersTopTracks(a))
 	b.Append(GetUsersTopArtists(a))
 	b.Append(GetUserProfile(a))
-	b.Append(GetUsersPlaylist(a))
+	b.Append(LoadLibraryCmd(a))
+	b.Append(SyncLibraryCmd(a))
 	b.Append(GetAvailableDevices(a))
-	b.Append(GetCurrentlyPlayingCmd(a))
+	b.Append(WatchPlaybackCmd(a))
 	b.Append(RenewRefreshTokenTick(a, a.GetAuthorizationInfo()))
 	b.Append(GetUsersQueueCmd(a))
+	b.Append(WatchConfigCmd(a))
+	b.Append(SyncLibraryTickCmd(a))
+	b.Append(CheckWatchedCmd(a))
+	b.Append(WatchHookErrorsCmd(a))
+	b.Append(WatchNotificationActionsCmd(a))
+	if a.config.StartupView == config.StartupRecentlyPlayed {
+		b.Append(GetUsersRecentlyPlayedCmd(a, client.RecentlyPlayedTracksParams{
+			Limit: a.config.PageSizes.RecentlyPlayed,
+		}))
+	}
 	return b.Cmd()
 }
 
@@ -84,15 +97,186 @@ func (a *App) updateResults(msg tea.Msg, b *Batch) {
 	case GetUserResult:
 		a.SetUser(msg.result)
 		a.AppendMessage("got setProfile result")
+		if profile, ok := a.profile(); ok {
+			push(LoadUiStateCmd(a, profile))
+		}
+	case LoadUiStateResult:
+		if a.checkError(msg) {
+			logger.Error("unable to load ui state", "error", msg.Err())
+			break
+		}
+		if msg.found {
+			a.restoreUiState(msg.state, b)
+		}
+	case SaveDefaultPlaylistResult:
+		if a.checkError(msg) {
+			a.AppendMessage("unable to save default playlist: " + msg.Err().Error())
+			break
+		}
+		logger.Debug("saved default playlist", "name", msg.name)
 	case GetUsersTopItems[types.Artist]:
 		a.data["top_artists"] = msg.result.Items
 		SetSideBarItems(a, "Top Artists", msg.result.Items)
 	case GetUsersTopItems[types.Track]:
 		a.data["top_tracks"] = msg.result.Items
+		if a.config.StartupView == config.StartupTopTracks {
+			a.showStartupView(func() { SetTable(a, msg.result.Items, "Top Tracks") })
+		}
+		a.restoreTable()
 		//SetSideBarItems(a, "Top Tracks", msg.result.Items)
 	case GetUsersPlaylistsResult:
 		a.data["playlists"] = msg.result.Items
 		SetSideBarItems(a, "Playlists", msg.result.Items)
+	case LoadLibraryResult:
+		if a.checkError(msg) {
+			logger.Error("unable to load library", "error", msg.Err())
+			break
+		}
+		a.setLibrary(msg)
+		if a.config.StartupView == config.StartupLikedSongs && len(msg.savedTracks) > 0 {
+			a.showStartupView(func() { SetTable(a, msg.savedTracks, "Liked Songs") })
+		}
+		a.restoreTable()
+	case LocalSearchResult:
+		if a.checkError(msg) {
+			a.AppendMessage("local search failed: " + msg.Err().Error())
+			break
+		}
+		a.AppendMessage(fmt.Sprintf("%d local results for %q", len(msg.results), msg.query))
+		SetTable(a, msg.results, "Local Search")
+	case SyncLibraryResult:
+		for _, warning := range msg.result.Warnings {
+			a.AppendMessage(warning)
+		}
+		if a.checkError(msg) {
+			a.AppendMessage("library sync failed: " + msg.Err().Error())
+			push(GetUsersPlaylist(a))
+			break
+		}
+		a.AppendMessage("library synced: " + msg.result.String())
+		push(LoadLibraryCmd(a))
+		push(RefreshDueSmartPlaylistsCmd(a))
+	case RefreshSmartPlaylistsResult:
+		for _, r := range msg.results {
+			a.AppendMessage("smart playlist " + r.String())
+		}
+		if a.checkError(msg) {
+			a.AppendMessage("smart playlist refresh failed: " + msg.Err().Error())
+		}
+	case ExportPlaylistResult:
+		if a.checkError(msg) {
+			a.AppendMessage("export failed: " + msg.Err().Error())
+			break
+		}
+		a.AppendMessage(fmt.Sprintf("exported %d items of %s to %s", msg.items, msg.name, msg.path))
+	case PrepareEditResult:
+		if a.checkError(msg) {
+			a.AppendMessage("edit failed: " + msg.Err().Error())
+			break
+		}
+		push(OpenEditorCmd(msg.edit))
+	case EditorClosedResult:
+		if a.checkError(msg) {
+			msg.edit.discard()
+			a.AppendMessage("editor failed: " + msg.Err().Error())
+			break
+		}
+		push(ApplyEditCmd(a, msg.edit))
+	case EditPlaylistResult:
+		if a.checkError(msg) {
+			a.AppendMessage("edit failed: " + msg.Err().Error())
+			break
+		}
+		a.AppendMessage(msg.playlist.Name + ": " + describeOps(msg.ops))
+		if len(msg.ops) == 0 {
+			break
+		}
+		delete(a.data, msg.playlist.Id)
+		if t, ok := a.grid.At(a.grid.Cursor()).(Table[Rower]); ok && t.Title() == msg.playlist.Name {
+			push(GetPlaylistItemsCmd(a, msg.playlist.Id, msg.playlist.Name))
+		}
+	case QueueShuffleResult:
+		if msg.queued > 0 {
+			a.AppendMessage(fmt.Sprintf("queued %d shuffled tracks from %s", msg.queued, msg.playlist.Name))
+			push(GetUsersQueueCmd(a))
+		}
+		if a.checkError(msg) {
+			a.AppendMessage("shuffle failed: " + msg.Err().Error())
+		}
+	case FindDuplicatesResult:
+		if a.checkError(msg) {
+			a.AppendMessage("finding duplicates failed: " + msg.Err().Error())
+			break
+		}
+		d := msg.duplicates
+		if len(d.findings) == 0 {
+			a.AppendMessage("no duplicates in " + d.playlist.Name)
+			break
+		}
+		a.duplicates = Optional[duplicates]{ Value: d, Valid: true }
+		SetTable(a, d.items(), duplicatesTitle)
+		a.AppendMessage(fmt.Sprintf("%d duplicates in %s, select them with enter and press %s again to remove them", len(d.findings), d.playlist.Name, a.config.Keys.Dedupe[0]))
+	case SaveTracksResult:
+		if a.checkError(msg) {
+			a.AppendMessage("saving failed: " + msg.Err().Error())
+			break
+		}
+		a.AppendMessage(msg.result.String())
+		if a.DefaultPlaylistIsValid() && msg.result.playlist == a.DefaultPlaylistName() {
+			delete(a.data, a.DefaultPlaylistId())
+		}
+	case RemoveDuplicatesResult:
+		if a.checkError(msg) {
+			a.AppendMessage("removing duplicates failed: " + msg.Err().Error())
+			break
+		}
+		a.AppendMessage(fmt.Sprintf("removed %d items from %s", msg.removed, msg.playlist.Name))
+		delete(a.data, msg.playlist.Id)
+		push(GetPlaylistItemsCmd(a, msg.playlist.Id, msg.playlist.Name))
+	case DiffPlaylistsResult:
+		if a.checkError(msg) {
+			a.AppendMessage("diff failed: " + msg.Err().Error())
+			break
+		}
+		a.diffView = Optional[diffView]{ Value: diffView{ diff: msg.diff }, Valid: true }
+	case SyncLibraryTick:
+		push(SyncLibraryCmd(a))
+		push(SyncLibraryTickCmd(a))
+	case WatchPlaylistsTick:
+		push(CheckWatchedCmd(a))
+	case CheckWatchedResult:
+		for _, change := range msg.changes {
+			a.AppendMessage("playlist changed: " + change.String())
+			if !msg.fromDaemon {
+				a.runHooks(playlistHookEvent(change))
+			}
+		}
+		if msg.lastChangeId > 0 || !a.lastChangeId.Valid {
+			a.lastChangeId = Optional[int64]{ Value: max(msg.lastChangeId, a.lastChangeId.Value), Valid: true }
+		}
+		if a.checkError(msg) {
+			a.AppendMessage("checking watched playlists failed: " + msg.Err().Error())
+		}
+		push(WatchPlaylistsTickCmd(a))
+	case ConfigReloadResult:
+		if msg.changed {
+			if a.checkError(msg) {
+				a.AppendMessage("config not reloaded: " + msg.Err().Error())
+			} else {
+				syncInterval := a.config.Intervals.Sync
+				watchInterval := a.config.Intervals.Watch
+				a.SetConfig(msg.config)
+				a.AppendMessage("config reloaded")
+				if syncInterval.Duration == 0 && msg.config.Intervals.Sync.Duration != 0 {
+					push(SyncLibraryTickCmd(a))
+				}
+				if watchInterval.Duration == 0 && msg.config.Intervals.Watch.Duration != 0 {
+					push(WatchPlaylistsTickCmd(a))
+				}
+			}
+		}
+		a.configModTime = msg.modTime
+		push(WatchConfigCmd(a))
 	case GetUsersQueueResult:
 		m, _:= GetModel[List](a, "queue")
 		push(SetItems(&m, msg.result.Queue))
@@ -100,6 +284,7 @@ func (a *App) updateResults(msg tea.Msg, b *Batch) {
 	case GetPlaylistResult:
 		a.AddPlaylistToCache(msg.result)
 		a.SetDefaultPlaylist(msg.result)
+		push(SaveDefaultPlaylistCmd(a, msg.result))
 	case GetPlaylistItemsResult:
 		id := msg.id
 		a.data[id] = msg.result.Items
@@ -108,24 +293,72 @@ func (a *App) updateResults(msg tea.Msg, b *Batch) {
 	case GetUsersRecentlyPlayedResult:
 		a.data["recently_played"] = msg.result.Items
 		SetTable(a, msg.result.Items, "Recently Played")
+		push(RecordPlayHistoryCmd(a, msg.result.Items))
 	case GetCurrentSessionPlayedResult:
 		a.AppendMessage("received current session result" + fmt.Sprintf(" %d", len(msg.result.Items)))
 		SetTable(a, msg.result.Items, "Current Session")
+		push(RecordPlayHistoryCmd(a, msg.result.Items))
+	case RecordPlayHistoryResult:
+		if a.checkError(msg) {
+			logger.Error("unable to record play history", "error", msg.Err())
+			break
+		}
+		logger.Debug("recorded play history", "recorded", msg.recorded)
 	case GetCurrentlyPlayingTrackResult:
 		if msg.result != nil {
 			a.foundCurrentlyPlaying = true
 			a.AppendMessage("got valid result")
 		}
-	case GetCurrentlyPlayingResult:
-		if msg.retry {
+	case PlaybackResult:
+		push(waitForPlaybackCmd(a.playbackResults))
+
+		if err := msg.result.Err; err != nil {
+			logger.Error("unable to get the playback state", "error", err)
 			break
 		}
-		a.retrying = false
-		a.SetCurrentlyPlaying(msg.result)
+
+		if msg.result.Raw.Item.Valid {
+			a.SetCurrentlyPlaying(msg.result.Raw)
+		} else {
+			a.UnsetCurrentlyPlaying()
+		}
+
 		updateMediaInfo(a)
-		push(tea.Tick(1*time.Second, func (_ time.Time) tea.Msg {
-			return GetCurrentlyPlaying(a)
-		}))
+
+		for _, event := range msg.result.Events {
+			if !a.playbackViaDaemon.Load() {
+				a.runHooks(playbackHookEvent(event))
+			}
+
+			switch event.Kind {
+			case poller.TrackChanged:
+				if !a.playbackViaDaemon.Load() {
+					a.notifyTrackChanged(event.State)
+				}
+				push(GetUsersQueueCmd(a))
+			case poller.DeviceChanged, poller.VolumeChanged:
+				push(GetAvailableDevices(a))
+			}
+		}
+	case NotificationActionResult:
+		push(WatchNotificationActionsCmd(a))
+		switch msg.action {
+		case notify.Skip:
+			push(SkipSongCmd(a, "next"))
+		case notify.Like:
+			push(LikeTrackCmd(a, msg.playing))
+		}
+	case LikeTrackResult:
+		if a.checkError(msg) {
+			a.AppendMessage("could not like " + msg.title + ": " + msg.Err().Error())
+		} else {
+			a.AppendMessage("added " + msg.title + " to liked songs")
+		}
+	case HookFailedResult:
+		push(WatchHookErrorsCmd(a))
+		a.AppendMessage(msg.err.Error())
+	case PlaybackChangedResult:
+		a.refreshPlayback()
 	case GetAvailableDevicesResult:
 		pos := a.posMap["devices"]
 		m := a.grid.At(pos).(List)
@@ -164,7 +397,7 @@ func (a *App) updateResults(msg tea.Msg, b *Batch) {
 		a.AppendMessage("received add item to queue result")
 		push(GetUsersQueueCmd(a))
 	case SkipItemResult:
-		push(GetUsersQueueCmd(a))
+		a.refreshPlayback()
 	case AppErr:
 		a.AppendMessage(msg.Error())
 	}
@@ -241,9 +474,9 @@ func updateMediaInfo(a *App) {
 		for _, artist := range playing.Track.Artists {
 			artistNames = append(artistNames, artist.Name)
 		}
-	} else {
+	} else if playing.Episode != nil {
 		name = playing.Episode.Name
-		duration = float64(playing.Track.DurationMs)
+		duration = float64(playing.Episode.DurationMs)
 	}
 
 	percent := progress/duration
@@ -519,33 +752,39 @@ func (a *App) updateInitialization(msg tea.Msg, b *Batch) bool {
 	return false
 }
 
+func (a *App) deactivateTextInput() {
+	inputPos := a.posMap["textinput"]
+	m := a.grid.At(inputPos).(textinput.Model)
+
+	if !m.Input.Focused() {
+		return
+	}
+
+	m.Input.Blur()
+	a.textInputFocus = false
+	m.Input.Reset()
+	a.setTextInputState(textInputDeactivated)
+	SetModel(a, m, "textinput")
+	if a.prevPos.Valid {
+		prevPos := a.prevPos.Value
+		a.grid.SetCursor(prevPos)
+	}
+
+	if a.inputValue.Valid {
+		a.inputValue = Optional[string]{}
+	}
+}
+
 func (a *App) updateTextInput(msg tea.Msg, b *Batch) bool {
 	var inputActivated bool
 
 	push := b.Append
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch s := msg.String(); s {
+		switch s := a.keymap.Lookup(msg.String()); s {
 		case "esc":
-			inputPos := a.posMap["textinput"]
-			m := a.grid.At(inputPos).(textinput.Model)
-
-			if m.Input.Focused() {
-				m.Input.Blur()
-				a.textInputFocus = false
-				m.Input.Reset()
-				a.setTextInputState(textInputDeactivated)
-				SetModel(a, m, "textinput")
-				if a.prevPos.Valid {
-					prevPos := a.prevPos.Value
-					a.grid.SetCursor(prevPos)
-				}
-
-				if a.inputValue.Valid {
-					a.inputValue = Optional[string]{}
-				}
-			}
-		case "/", ":":
+			a.deactivateTextInput()
+		case config.ActionSearch, config.ActionCommand:
 			inputPos := a.posMap["textinput"]
 			m := a.grid.At(inputPos).(textinput.Model)
 
@@ -558,9 +797,9 @@ func (a *App) updateTextInput(msg tea.Msg, b *Batch) bool {
 				Valid: true,
 			}
 
-			if s == ":" {
+			if s == config.ActionCommand {
 				a.setTextInputState(textInputCommand)
-			} else if s == "/" {
+			} else if s == config.ActionSearch {
 				a.setTextInputState(textInputSearch)
 			}
 
@@ -574,6 +813,10 @@ func (a *App) updateTextInput(msg tea.Msg, b *Batch) bool {
 		case "enter":
 			switch a.inputState {
 			case textInputCommand:
+				m := a.grid.At(a.grid.Cursor()).(textinput.Model)
+				value := m.Input.Value()
+				a.deactivateTextInput()
+				push(runCommand(a, value))
 			case textInputSearch:
 				m := a.grid.At(a.grid.Cursor()).(textinput.Model)
 				value := m.Input.Value()
@@ -581,10 +824,15 @@ func (a *App) updateTextInput(msg tea.Msg, b *Batch) bool {
 					Value: value,
 					Valid: true,
 				}
+				if query, ok := strings.CutPrefix(value, localSearchPrefix); ok {
+					push(LocalSearchCmd(a, query))
+					break
+				}
 				params := client.GetSearchResultsParams{
 					Q: value,
 					Type: []string{ "artist", "album", "playlist", "track" },
-					Market: "US",
+					Market: a.config.Market,
+					Limit: a.config.PageSizes.Search,
 				}
 				push(GetSearchResultCmd(a, params))
 			}
@@ -598,7 +846,7 @@ func (a *App) update(msg tea.Msg, b *Batch) {
 	push := b.Append
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch key := msg.String(); key {
+		switch key := a.keymap.Lookup(msg.String()); key {
 		case "esc":
 			if a.grid.Focus() {
 				a.grid.SetFocus(false)
@@ -610,22 +858,30 @@ func (a *App) update(msg tea.Msg, b *Batch) {
 					a.grid.SetFocus(true)
 				}
 			}
-		case "ctrl+r":
-			push(GetCurrentlyPlayingCmd(a))
+		case config.ActionRefresh:
+			a.refreshPlayback()
 			push(GetUsersQueueCmd(a))
 			push(GetAvailableDevices(a))
-			a.AppendMessage("retrying getCurrentlyPlaying")
+			a.AppendMessage("refreshing playback")
 		case "up", "down":
 			//push(updatePlaybackVolume(a, key))
-		case "p":
+		case config.ActionPlayPause:
 			push(updatePlaybackStatus(a))
-		case "n":
+		case config.ActionNext:
 			push(updateSkipNext(a))
-		case "b":
+		case config.ActionPrevious:
 			push(updateSkipPrev(a))
-		case "a":
+		case config.ActionQueue:
 			push(handleAddItem(a))
-		case "A":
+		case config.ActionExport:
+			push(handleExport(a))
+		case config.ActionEditPlaylist:
+			push(handleEdit(a))
+		case config.ActionDedupe:
+			push(handleDedupe(a))
+		case config.ActionSaveQueue:
+			push(handleSave(a))
+		case config.ActionAddToPlaylist:
 			pos := a.grid.Cursor()
 			switch m := a.grid.At(pos).(type) {
 			case nested.NestedList:
@@ -651,12 +907,13 @@ func (a *App) update(msg tea.Msg, b *Batch) {
 							break
 						}
 						a.SetDefaultPlaylist(p)
+						push(SaveDefaultPlaylistCmd(a, p))
 						break
 					}
 
 					params := client.GetPlaylistParams{
 						Id: id,
-						Market: "US",
+						Market: a.config.Market,
 					}
 					push(GetPlaylistCmd(a, params))
 				}
@@ -700,7 +957,7 @@ func (a *App) update(msg tea.Msg, b *Batch) {
 				push(AddItemsToPlaylistCmd(a, params))
 				
 			}
-		case "C":
+		case config.ActionAddPlaying:
 			if !a.CurrentlyPlayingIsValid() {
 				a.AppendMessage("currently playing has not been set")
 				break
@@ -738,7 +995,7 @@ func (a *App) update(msg tea.Msg, b *Batch) {
 			}
 
 			push(AddItemsToPlaylistCmd(a, params))
-		case "c":
+		case config.ActionCollapse:
 			pos := a.grid.Cursor()
 			switch m := a.grid.At(pos).(type) {
 			case nested.NestedList:
@@ -763,6 +1020,10 @@ func (a *App) update(msg tea.Msg, b *Batch) {
 					push(handleSelection(a))
 				case media.Model:
 					push(updateMediaControlSelection(a, m, pos))
+				case Table[Rower]:
+					if m.Title() == duplicatesTitle {
+						toggleDuplicate(a, m)
+					}
 				case nested.NestedList:
 					item := m.SelectedItem()
 					idx := m.Index()
@@ -790,15 +1051,22 @@ func (a *App) update(msg tea.Msg, b *Batch) {
 							items, ok := a.data["recently_played"].([]types.PlayHistory)
 							if !ok {
 								params := client.RecentlyPlayedTracksParams{
-									Limit: 30,
+									Limit: a.config.PageSizes.RecentlyPlayed,
 								}
 								push(GetUsersRecentlyPlayedCmd(a, params))
 								break
 							}
 							SetTable(a, items, "Recently Played")
+						case "Liked Songs":
+							items, ok := a.data["saved_tracks"].([]types.SavedTrack)
+							if !ok {
+								a.AppendMessage("liked songs have not been synced yet")
+								break
+							}
+							SetTable(a, items, "Liked Songs")
 						case "Current Session":
 							params := client.RecentlyPlayedTracksParams{
-								Limit: 10,
+								Limit: a.config.PageSizes.CurrentSession,
 								After: int(a.sessionStart.UnixMilli()),
 							}
 							push(GetCurrentSessionPlayedCmd(a, params))
@@ -823,9 +1091,20 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		updateModelDims(a)
 	case Shutdown:
 		logger.Debug("received shutdown message")
+		if err := a.saveUiState(); err != nil {
+			logger.Error("unable to save ui state", "error", err)
+		}
 		a.db.Close()
 		return a, tea.Quit
 	case tea.KeyMsg:
+		if a.updateDiffView(msg) {
+			return a, nil
+		}
+
+		if a.keymap.Lookup(msg.String()) == config.ActionQuit && !a.textInputFocus && !a.isState(NewLogin) {
+			return a, ShutDownApp(a)
+		}
+
 		switch msg.String() {
 		case "ctrl+c":
 			return a, ShutDownApp(a)
@@ -842,7 +1121,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			if !a.grid.Focus() {
-				return a, tea.Quit
+				return a, ShutDownApp(a)
 			}
 			
 		}
@@ -1021,6 +1300,10 @@ func SetTableColumns[T Rower](t *Table[T], title string) {
 	switch title {
 	case "Recently Played", "Current Session":
 		t.t.SetColumns(playHistoryColumns())
+	case "Local Search":
+		t.t.SetColumns(localSearchColumns())
+	case duplicatesTitle:
+		t.t.SetColumns(duplicatesColumns())
 	default:
 		t.t.SetColumns(defaultColumns())
 	}
@@ -1100,6 +1383,12 @@ func handleAddItem(a *App) tea.Cmd {
 		}
 	case types.PlayHistory:
 		uri = item.Track.Uri
+	case types.SavedTrack:
+		uri = item.Track.Uri
+	case localSearchItem:
+		if item.Kind != "playlist" {
+			uri = item.Uri
+		}
 	}
 
 	if msg != "" {
@@ -1163,7 +1452,7 @@ func (a *App) View() string {
 		s := "Authorizing client credentials\n"
 
 		if authUrl != "" {
-			urlView := lipgloss.NewStyle().Width(a.width).Foreground(lipgloss.Color("green"))
+			urlView := a.styles.link.Width(a.width)
 			s += "go to the following url:\n"
 			s += urlView.Render(fmt.Sprintf("%s\n", authUrl))
 		}
@@ -1208,6 +1497,9 @@ func (a *App) View() string {
 	titleView :=  a.styles.title.Width(a.width).Align(lipgloss.Center).Render(a.title)
 	infoView := a.styles.infoStyle.Width(a.width).Render(a.currentlyPlayingArtistView())
 	gridView := a.styles.gridStyle.Render(a.grid.View())
+	if a.diffView.Valid {
+		gridView = a.diffView.Value.View(a.styles, a.width, a.height-lipgloss.Height(titleView)-lipgloss.Height(infoView))
+	}
 	s := lipgloss.JoinVertical(lipgloss.Center, titleView, infoView)
 	s = lipgloss.JoinVertical(lipgloss.Left, s, gridView)
 	return s
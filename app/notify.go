@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/notify"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/utils"
+)
+
+type NotificationActionResult struct {
+	action notify.Action
+	playing output.NowPlaying
+}
+
+type LikeTrackResult struct {
+	title string
+	err error
+}
+
+func (r LikeTrackResult) Err() error {
+	return r.err
+}
+
+// newNotifier connects to the notification server, which is never done
+// over ssh.
+func newNotifier(a *App, onAction func(notify.Action, output.NowPlaying)) (*notify.Notifier, error) {
+	if notify.OverSSH() {
+		return nil, fmt.Errorf("not showing notifications over ssh")
+	}
+
+	cfg := a.config.Notifications
+
+	return notify.New(notify.Options{
+		MinInterval: cfg.MinInterval.Duration,
+		Timeout: cfg.Timeout.Duration,
+		Actions: cfg.Actions,
+		CoverDir: filepath.Join(utils.ConfigDir(), "covers"),
+		OnAction: onAction,
+	}, logger)
+}
+
+// notifyTrackChanged shows the notification of the tui, connecting on the
+// first track so enabling notifications takes effect on config reload.
+func (a *App) notifyTrackChanged(n output.NowPlaying) {
+	if !a.config.Notifications.Enabled {
+		return
+	}
+
+	if a.notifier == nil {
+		if a.notifyActions == nil {
+			a.notifyActions = make(chan NotificationActionResult, 4)
+		}
+
+		notifier, err := newNotifier(a, func(action notify.Action, n output.NowPlaying) {
+			select {
+			case a.notifyActions <- NotificationActionResult{ action: action, playing: n }:
+			default:
+			}
+		})
+
+		if err != nil {
+			// not retried for every track, only after the config changed
+			logger.Error("notifications are off", "err", err)
+			a.config.Notifications.Enabled = false
+			return
+		}
+
+		a.notifier = notifier
+	}
+
+	a.notifier.TrackChanged(n)
+}
+
+// WatchNotificationActionsCmd hands the buttons pressed on notifications
+// to the tui.
+func WatchNotificationActionsCmd(a *App) tea.Cmd {
+	if a.notifyActions == nil {
+		a.notifyActions = make(chan NotificationActionResult, 4)
+	}
+
+	return func() tea.Msg {
+		return <-a.notifyActions
+	}
+}
+
+func LikeTrackCmd(a *App, n output.NowPlaying) tea.Cmd {
+	return func() tea.Msg {
+		return LikeTrackResult{
+			title: n.Title,
+			err: likeTrack(defaultAccessTokenCtx(a), a, n.Uri),
+		}
+	}
+}
+
+// likeTrack adds the track with uri to the liked songs.
+func likeTrack(ctx context.Context, a *App, uri string) error {
+	id, ok := strings.CutPrefix(uri, "spotify:track:")
+
+	if !ok {
+		return fmt.Errorf("%s is not a track", uri)
+	}
+
+	return a.client.SaveTracks(ctx, []string{ id })
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/poller"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// fetchPlayback asks the daemon for the playback state when one runs and
+// spotify otherwise, renewing the token when it expired.
+func fetchPlayback(a *App) poller.FetchFunc {
+	return func(ctx context.Context) (types.CurrentlyPlaying, error) {
+		var playing types.CurrentlyPlaying
+
+		if ok, err := a.callDaemon(ctx, "currently_playing", nil, &playing); ok {
+			return playing, err
+		}
+
+		if a.IsTokenExpired() {
+			if err := a.renewAccessToken(); err != nil {
+				return playing, err
+			}
+		}
+
+		return getCurrentlyPlaying(client.WithAccessToken(ctx, a.AccessToken()), a)
+	}
+}
+
+// PlaybackWatchHandler is gsp watch, which writes every change of the
+// playback state as a json object per line.
+func PlaybackWatchHandler(a *App) *CliCommand {
+	var interval time.Duration
+	var only string
+	cmd := NewCliCommand("watch", "", "write playback changes as they happen, one json object per line")
+	cmd.Structured = true
+	cmd.Flags.DurationVar(&interval, "interval", a.config.Intervals.Playback.Duration, "how often to poll while playing")
+	cmd.Flags.StringVar(&only, "events", "", "comma separated events to write, e.g. track_changed,paused (default all)")
+	cmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		if interval < 500*time.Millisecond {
+			return usageError("-interval must be at least 500ms")
+		}
+
+		var kinds []poller.Kind
+
+		if only != "" {
+			var err error
+
+			if kinds, err = poller.ParseKinds(only); err != nil {
+				return usageError("-events: %v", err)
+			}
+		}
+
+		// events are written as they come, text output would be ndjson anyway
+		format := a.cliOptions.Output
+
+		if format == output.FormatText || format == output.FormatJSON {
+			format = output.FormatNDJSON
+		}
+
+		p := output.New(os.Stdout, format, a.cliOptions.template)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		w := poller.New(fetchPlayback(a), poller.Options{
+			Interval: interval,
+			Paused: max(interval, a.config.Intervals.PlaybackPaused.Duration),
+		})
+
+		results := make(chan poller.Result)
+
+		go w.Run(ctx, results)
+
+		for r := range results {
+			if r.Err != nil {
+				fmt.Fprintf(os.Stderr, "gsp: %v\n", r.Err)
+				continue
+			}
+
+			for _, event := range r.Events {
+				if kinds != nil && !slices.Contains(kinds, event.Kind) {
+					continue
+				}
+
+				if err := output.Print(p, event); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return cmd
+}
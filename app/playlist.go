@@ -0,0 +1,576 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+	tea "github.com/charmbracelet/bubbletea"
+	nested "github.com/arjunmoola/go-spotify/models/list"
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+type ExportPlaylistResult struct {
+	name string
+	path string
+	items int
+	err error
+}
+
+func (r ExportPlaylistResult) Err() error {
+	return r.err
+}
+
+// selectedPlaylist returns the playlist under the cursor, either in the
+// sidebar or the one whose items are shown in the table.
+func (a *App) selectedPlaylist() (types.SimplifiedPlaylistObject, bool) {
+	switch m := a.grid.At(a.grid.Cursor()).(type) {
+	case nested.NestedList:
+		item := m.SelectedItem()
+
+		if item.Title() != "Playlists" || !item.Expanded() || item.IsEmpty() {
+			break
+		}
+
+		p, ok := item.SelectedItem().(types.SimplifiedPlaylistObject)
+
+		return p, ok
+	case Table[Rower]:
+		playlists, _ := a.data["playlists"].([]types.SimplifiedPlaylistObject)
+
+		for _, p := range playlists {
+			if p.Name == m.Title() {
+				return p, true
+			}
+		}
+	}
+
+	return types.SimplifiedPlaylistObject{}, false
+}
+
+func exportFileName(name string, format playlist.Format) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+
+	if name == "" {
+		name = "playlist"
+	}
+
+	return name + "." + string(format)
+}
+
+func exportPlaylist(ctx context.Context, a *App, w io.Writer, format playlist.Format, p types.SimplifiedPlaylistObject) (int, error) {
+	items, err := a.client.GetAllPlaylistItems(ctx, p.Id, a.config.Market)
+
+	if err != nil {
+		return 0, err
+	}
+
+	entries := playlist.Entries(items)
+
+	return len(entries), playlist.Export(w, format, p, entries)
+}
+
+// ExportPlaylistCmd writes the playlist to the export directory from the
+// config in the configured format.
+func ExportPlaylistCmd(a *App, p types.SimplifiedPlaylistObject) tea.Cmd {
+	format := playlist.Format(a.config.Export.Format)
+	dir := a.config.ExportDir()
+
+	return func() tea.Msg {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return ExportPlaylistResult{ err: err }
+		}
+
+		path := filepath.Join(dir, exportFileName(p.Name, format))
+		file, err := os.Create(path)
+
+		if err != nil {
+			return ExportPlaylistResult{ err: err }
+		}
+
+		defer file.Close()
+
+		n, err := exportPlaylist(defaultAccessTokenCtx(a), a, file, format, p)
+
+		return ExportPlaylistResult{
+			name: p.Name,
+			path: path,
+			items: n,
+			err: err,
+		}
+	}
+}
+
+func handleExport(a *App) tea.Cmd {
+	p, ok := a.selectedPlaylist()
+
+	if !ok {
+		a.AppendMessage("select a playlist to export")
+		return nil
+	}
+
+	a.AppendMessage("exporting " + p.Name)
+
+	return ExportPlaylistCmd(a, p)
+}
+
+// printReview prints every match with its confidence so a questionable
+// search result can be spotted before anything is written.
+func printReview(w io.Writer, matches []playlist.Match) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "LINE\tMETHOD\tCONFIDENCE\tSOURCE\tMATCH")
+
+	for _, m := range matches {
+		source := m.Row.Name
+
+		if artists := m.Row.ArtistNames(); artists != "" {
+			source = artists + " - " + source
+		}
+
+		if source == "" {
+			source = m.Row.Uri
+		}
+
+		match := m.TrackName()
+
+		switch {
+		case match == "":
+			match = "(not found)"
+		case !m.Accepted:
+			match = "(rejected) " + match
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%.2f\t%s\t%s\n", m.Row.Line, m.Method, m.Confidence, source, match)
+	}
+
+	return tw.Flush()
+}
+
+func readLine() string {
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+
+	line := strings.ToLower(readLine())
+
+	return line == "y" || line == "yes"
+}
+
+func writeReport(path string, matches []playlist.Match) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return playlist.WriteReport(file, matches)
+}
+
+type importOptions struct {
+	to string
+	name string
+	report string
+	minConfidence float64
+	yes bool
+}
+
+// importPlaylist matches the tracks in the file and adds them to an existing
+// playlist or a new one. Rows that were not matched are written to a report.
+func importPlaylist(ctx context.Context, a *App, path string, opts importOptions) error {
+	rows, err := playlist.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return fmt.Errorf("%s has no tracks", path)
+	}
+
+	var target types.SimplifiedPlaylistObject
+
+	if opts.to != "" {
+		target, err = playlist.Resolve(ctx, a.db, a.client, opts.to)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	matcher := playlist.NewMatcher(a.client, a.config.Market, opts.minConfidence)
+	matcher.OnProgress(func(done, total int) {
+		fmt.Fprintf(os.Stderr, "\rmatching %d/%d", done, total)
+	})
+
+	matches, err := matcher.Match(ctx, rows)
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return err
+	}
+
+	var uris []string
+
+	for _, m := range matches {
+		if uri := m.Uri(); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+
+	if err := printReview(os.Stdout, matches); err != nil {
+		return err
+	}
+
+	unmatched := len(matches) - len(uris)
+
+	fmt.Printf("\nmatched %d of %d rows\n", len(uris), len(matches))
+
+	if unmatched > 0 {
+		if err := writeReport(opts.report, matches); err != nil {
+			return err
+		}
+
+		fmt.Printf("wrote %d unmatched rows to %s\n", unmatched, opts.report)
+	}
+
+	if len(uris) == 0 {
+		return fmt.Errorf("no tracks to import")
+	}
+
+	destination := opts.name
+
+	if target.Id != "" {
+		destination = target.Name
+	}
+
+	if !opts.yes && !confirm(fmt.Sprintf("add %d tracks to %s?", len(uris), destination)) {
+		fmt.Println("nothing imported")
+		return nil
+	}
+
+	if target.Id == "" {
+		user, err := a.client.GetCurrentUserProfile(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		p, err := a.client.CreatePlaylist(ctx, client.CreatePlaylistParams{
+			UserId: user.Id,
+			Name: opts.name,
+			Description: "imported from " + filepath.Base(path),
+		})
+
+		if err != nil {
+			return fmt.Errorf("creating playlist %s: %w", opts.name, err)
+		}
+
+		target.Id = p.Id
+		target.Name = p.Name
+	}
+
+	if _, err := playlist.AddUris(ctx, a.client, target.Id, uris); err != nil {
+		return err
+	}
+
+	fmt.Printf("added %d tracks to %s\n", len(uris), target.Name)
+
+	return nil
+}
+
+func PlaylistHandler(a *App) *CliCommand {
+	listCmd := NewCliCommand("list", "", "list your playlists")
+	listCmd.Structured = true
+	listCmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		all, err := a.client.GetAllCurrentUsersPlaylists(defaultAccessTokenCtx(a))
+
+		if err != nil {
+			return err
+		}
+
+		playlists := make([]output.Playlist, 0, len(all))
+
+		for _, p := range all {
+			playlists = append(playlists, output.NewPlaylist(p))
+		}
+
+		if p := a.printer(); !p.Text() {
+			return output.List(p, playlists)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(tw, "NAME\tOWNER\tTRACKS\tURI")
+
+		for _, p := range playlists {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", p.Name, p.Owner, p.Tracks, p.Uri)
+		}
+
+		return tw.Flush()
+	}
+
+	var exportFormat, exportOutput string
+	exportCmd := NewCliCommand("export", "<playlist>", "write a playlist as csv, json, m3u or xspf")
+	exportCmd.Flags.StringVar(&exportFormat, "format", "", "csv, json, m3u or xspf, defaults to the extension of -o or csv")
+	exportCmd.Flags.StringVar(&exportOutput, "o", "", "file to write to instead of stdout")
+	exportCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		format := playlist.FormatCSV
+
+		if exportFormat != "" {
+			f, err := playlist.ParseFormat(exportFormat)
+
+			if err != nil {
+				return UsageError{ Msg: err.Error() }
+			}
+
+			format = f
+		} else if f, ok := playlist.FormatFromPath(exportOutput); ok {
+			format = f
+		}
+
+		ctx := defaultAccessTokenCtx(a)
+
+		p, err := playlist.Resolve(ctx, a.db, a.client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+
+		if exportOutput != "" {
+			file, err := os.Create(exportOutput)
+
+			if err != nil {
+				return err
+			}
+
+			defer file.Close()
+
+			w = file
+		}
+
+		n, err := exportPlaylist(ctx, a, w, format, p)
+
+		if err != nil {
+			return err
+		}
+
+		if exportOutput != "" {
+			fmt.Printf("exported %d items of %s to %s\n", n, p.Name, exportOutput)
+		}
+
+		return nil
+	}
+
+	var importOpts importOptions
+	importCmd := NewCliCommand("import", "<file>", "add the tracks of a csv, m3u, json or xspf file to a playlist")
+	importCmd.Flags.StringVar(&importOpts.to, "to", "", "append to this playlist instead of creating one")
+	importCmd.Flags.StringVar(&importOpts.name, "name", "", "name of the new playlist, defaults to the file name")
+	importCmd.Flags.StringVar(&importOpts.report, "report", "", "where to write unmatched rows, defaults to <file>.unmatched.csv")
+	importCmd.Flags.Float64Var(&importOpts.minConfidence, "min-confidence", playlist.DefaultMinConfidence, "lowest search score between 0 and 1 accepted as a match")
+	importCmd.Flags.BoolVar(&importOpts.yes, "yes", false, "add the matches without asking")
+	importCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		if importOpts.to != "" && importOpts.name != "" {
+			return usageError("-to and -name cannot be used together")
+		}
+
+		if importOpts.minConfidence < 0 || importOpts.minConfidence > 1 {
+			return usageError("-min-confidence must be between 0 and 1")
+		}
+
+		path := args[0]
+
+		if importOpts.name == "" {
+			importOpts.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		if importOpts.report == "" {
+			importOpts.report = strings.TrimSuffix(path, filepath.Ext(path)) + ".unmatched.csv"
+		}
+
+		return importPlaylist(defaultAccessTokenCtx(a), a, path, importOpts)
+	}
+
+	editCmd := NewCliCommand("edit", "<playlist>", "edit a playlist as text in $EDITOR")
+	editCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		ctx := defaultAccessTokenCtx(a)
+
+		p, err := playlist.Resolve(ctx, a.db, a.client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		return editPlaylist(ctx, a, p)
+	}
+
+	var dedupeOpts dedupeOptions
+	dedupeCmd := NewCliCommand("dedupe", "<playlist>", "find and remove duplicate and unavailable items")
+	dedupeCmd.Flags.StringVar(&dedupeOpts.by, "by", "uri,isrc,title,unavailable", "what to look for, a comma separated list of uri, isrc, title, unplayable and unavailable")
+	dedupeCmd.Flags.BoolVar(&dedupeOpts.yes, "yes", false, "remove everything found without asking")
+	dedupeCmd.Flags.BoolVar(&dedupeOpts.dryRun, "n", false, "only list what was found")
+	dedupeCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		ctx := defaultAccessTokenCtx(a)
+
+		p, err := playlist.Resolve(ctx, a.db, a.client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		return dedupePlaylist(ctx, a, p, dedupeOpts)
+	}
+
+	diffCmd := NewCliCommand("diff", "<playlist> <playlist>", "show the tracks only in one of two playlists")
+	diffCmd.Structured = true
+	diffCmd.Run = func(args ...string) error {
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		d, err := diffPlaylists(defaultAccessTokenCtx(a), a, args[0], args[1])
+
+		if err != nil {
+			return err
+		}
+
+		if p := a.printer(); !p.Text() {
+			return output.List(p, d.records())
+		}
+
+		printDiff(d)
+
+		return nil
+	}
+
+	var mergeOpts mergeOptions
+	mergeCmd := NewCliCommand("merge", "<playlist> <playlist> -into <playlist>", "combine two playlists into a third")
+	mergeCmd.Flags.StringVar(&mergeOpts.into, "into", "", "playlist to add the result to, created when it does not exist")
+	mergeCmd.Flags.StringVar(&mergeOpts.mode, "mode", string(playlist.MergeUnion), "union, intersection or difference")
+	mergeCmd.Flags.BoolVar(&mergeOpts.dedupe, "dedupe", false, "skip duplicates and tracks already in the target playlist")
+	mergeCmd.Run = func(args ...string) error {
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		if mergeOpts.into == "" {
+			return usageError("-into is required")
+		}
+
+		return mergePlaylists(defaultAccessTokenCtx(a), a, args[0], args[1], mergeOpts)
+	}
+
+	var sortOpts sortOptions
+	sortCmd := NewCliCommand("sort", "<playlist>", "reorder a playlist on spotify")
+	sortCmd.Flags.StringVar(&sortOpts.by, "by", string(playlist.SortArtist), "artist, album, release, added, popularity, duration or random")
+	sortCmd.Flags.BoolVar(&sortOpts.reverse, "reverse", false, "sort in the opposite order")
+	sortCmd.Flags.Uint64Var(&sortOpts.seed, "seed", 0, "seed for -by random, the same seed gives the same order")
+	sortCmd.Flags.BoolVar(&sortOpts.replace, "replace", false, "rewrite the playlist instead of moving items, faster but resets the added dates")
+	sortCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		ctx := defaultAccessTokenCtx(a)
+
+		p, err := playlist.Resolve(ctx, a.db, a.client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		if sortOpts.by == string(playlist.SortRandom) && sortOpts.seed == 0 {
+			sortOpts.seed = uint64(time.Now().UnixNano())
+			fmt.Printf("shuffling with seed %d\n", sortOpts.seed)
+		}
+
+		ops, err := sortPlaylist(ctx, a, p, sortOpts)
+
+		if err != nil {
+			return err
+		}
+
+		if sortOpts.replace {
+			fmt.Printf("%s: rewritten in sorted order\n", p.Name)
+			return nil
+		}
+
+		fmt.Printf("%s: %s\n", p.Name, describeOps(ops))
+
+		return nil
+	}
+
+	shuffleOpts := shuffleOptions{ count: defaultQueueCount }
+	shuffleCmd := NewCliCommand("shuffle", "<playlist>", "shuffle a playlist into a copy or the queue, spreading out artists")
+	shuffleCmd.Flags.Uint64Var(&shuffleOpts.seed, "seed", 0, "seed for the shuffle, the same seed gives the same order")
+	shuffleCmd.Flags.StringVar(&shuffleOpts.copy, "copy", "", "playlist to write the shuffle to, defaults to \"<playlist> (shuffled)\"")
+	shuffleCmd.Flags.BoolVar(&shuffleOpts.queue, "queue", false, "add the shuffle to the queue instead of a playlist")
+	shuffleCmd.Flags.IntVar(&shuffleOpts.count, "n", shuffleOpts.count, "how many tracks to queue")
+	shuffleCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		if shuffleOpts.queue && shuffleOpts.copy != "" {
+			return usageError("-queue and -copy cannot be used together")
+		}
+
+		if shuffleOpts.count < 1 {
+			return usageError("-n must be at least 1")
+		}
+
+		ctx := defaultAccessTokenCtx(a)
+
+		p, err := playlist.Resolve(ctx, a.db, a.client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		return shufflePlaylist(ctx, a, p, shuffleOpts)
+	}
+
+	cmd := NewCliCommand("playlist", "", "list, export, import, edit, clean up, compare and reorder playlists")
+
+	return cmd.Add(listCmd, exportCmd, importCmd, editCmd, dedupeCmd, diffCmd, mergeCmd, sortCmd, shuffleCmd, watchHandler(a))
+}
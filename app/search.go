@@ -0,0 +1,205 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/library"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// localSearchPrefix switches the search input from the api to the local
+// mirror, e.g. "/@daft punk".
+const localSearchPrefix = "@"
+
+type localSearchItem library.SearchResult
+
+func (i localSearchItem) Row() table.Row {
+	if i.Kind == "playlist" {
+		return table.Row{ i.Name, i.Artists, "playlist", "" }
+	}
+
+	return table.Row{ i.Name, i.Artists, i.Album, strings.Join(i.Playlists, ", ") }
+}
+
+type LocalSearchResult struct {
+	query string
+	results []localSearchItem
+	err error
+}
+
+func (r LocalSearchResult) Err() error {
+	return r.err
+}
+
+func LocalSearchCmd(a *App, query string) tea.Cmd {
+	return func() tea.Msg {
+		results, err := library.Search(context.Background(), database.New(a.db), query, 0)
+
+		if err != nil {
+			return LocalSearchResult{ query: query, err: err }
+		}
+
+		items := make([]localSearchItem, 0, len(results))
+
+		for _, result := range results {
+			items = append(items, localSearchItem(result))
+		}
+
+		return LocalSearchResult{
+			query: query,
+			results: items,
+		}
+	}
+}
+
+func SearchHandler(a *App) *CliCommand {
+	var local bool
+	var limit int
+	cmd := NewCliCommand("search", "<query>", "search spotify or the local library for tracks, artists and playlists")
+	cmd.Structured = true
+	cmd.Flags.BoolVar(&local, "local", false, "search the local mirror of the library instead of spotify")
+	cmd.Flags.IntVar(&limit, "limit", a.config.PageSizes.Search, "maximum number of results")
+	cmd.Run = func(args ...string) error {
+		query := strings.Join(args, " ")
+
+		if query == "" {
+			return errUsage
+		}
+
+		if local {
+			return searchLocal(a, query, limit)
+		}
+
+		results, err := searchSpotify(defaultAccessTokenCtx(a), a, query, limit)
+
+		if err != nil {
+			return err
+		}
+
+		return printSearchResults(a, results)
+	}
+
+	return cmd
+}
+
+// searchSpotify searches spotify for tracks, artists and playlists.
+func searchSpotify(ctx context.Context, a *App, query string, limit int) ([]output.SearchResult, error) {
+	result, err := a.client.GetSearchResults(ctx, client.GetSearchResultsParams{
+		Q: query,
+		Type: []string{ "artist", "playlist", "track" },
+		Limit: limit,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var results []output.SearchResult
+
+	for _, track := range result.Tracks.Items {
+		t := output.NewTrack(types.ItemUnion{ Type: "track", Track: &track })
+		results = append(results, output.SearchResult{
+			Kind: "track",
+			Uri: t.Uri,
+			Name: t.Title,
+			Artists: t.Artists,
+			Album: t.Album,
+		})
+	}
+
+	for _, artist := range result.Artists.Items {
+		results = append(results, output.SearchResult{
+			Kind: "artist",
+			Uri: artist.Uri,
+			Name: artist.Name,
+			Artists: []string{},
+		})
+	}
+
+	for _, playlist := range result.Playlists.Items {
+		if playlist.Uri == "" {
+			continue
+		}
+		results = append(results, output.SearchResult{
+			Kind: "playlist",
+			Uri: playlist.Uri,
+			Name: playlist.Name,
+			Artists: []string{},
+			Owner: playlist.Owner.DisplayName.Value,
+		})
+	}
+
+	return results, nil
+}
+
+func searchLocal(a *App, query string, limit int) error {
+	found, err := library.Search(context.Background(), database.New(a.db), query, limit)
+
+	if err != nil {
+		return err
+	}
+
+	p := a.printer()
+
+	if len(found) == 0 && p.Text() {
+		fmt.Println("no matches in the local library, run gsp sync first if it is empty")
+		return nil
+	}
+
+	results := make([]output.SearchResult, 0, len(found))
+
+	for _, r := range found {
+		result := output.SearchResult{
+			Kind: r.Kind,
+			Uri: r.Uri,
+			Name: r.Name,
+			Artists: []string{},
+			Album: r.Album,
+			Playlists: r.Playlists,
+		}
+
+		// the artists column of a playlist holds its owner
+		if r.Kind == "playlist" {
+			result.Owner = r.Artists
+		} else if r.Artists != "" {
+			result.Artists = strings.Split(r.Artists, ", ")
+		}
+
+		results = append(results, result)
+	}
+
+	return printSearchResults(a, results)
+}
+
+// printSearchResults writes one result per line, tab separated for text so
+// the output of remote and local searches lines up.
+func printSearchResults(a *App, results []output.SearchResult) error {
+	if p := a.printer(); !p.Text() {
+		return output.List(p, results)
+	}
+
+	for _, r := range results {
+		switch r.Kind {
+		case "artist":
+			fmt.Printf("artist\t%s\t%s\n", r.Name, r.Uri)
+		case "playlist":
+			fmt.Printf("playlist\t%s\t%s\t%s\n", r.Name, r.Owner, r.Uri)
+		default:
+			fmt.Printf("%s\t%s\t%s\t%s", r.Kind, r.Name, strings.Join(r.Artists, ", "), r.Uri)
+
+			if len(r.Playlists) > 0 {
+				fmt.Printf("\t%s", strings.Join(r.Playlists, ", "))
+			}
+
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
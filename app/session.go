@@ -0,0 +1,324 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/history"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+const (
+	sourceQueue = "queue"
+	sourceSession = "session"
+)
+
+func itemUri(item types.ItemUnion) string {
+	switch {
+	case item.Type == "track" && item.Track != nil:
+		return item.Track.Uri
+	case item.Type == "episode" && item.Episode != nil:
+		return item.Episode.Uri
+	}
+
+	return ""
+}
+
+// queueUris returns the playing track followed by the queue. The api repeats
+// the upcoming tracks of the context to fill the queue, so each track is
+// only taken once.
+func queueUris(ctx context.Context, a *App) ([]string, error) {
+	queue, err := a.client.GetQueue(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := queue.Queue
+
+	if queue.CurrentlyPlaying.Valid {
+		items = append([]types.ItemUnion{ queue.CurrentlyPlaying.Value }, items...)
+	}
+
+	seen := make(map[string]bool)
+	var uris []string
+
+	for _, item := range items {
+		uri := itemUri(item)
+
+		if uri == "" || seen[uri] {
+			continue
+		}
+
+		seen[uri] = true
+		uris = append(uris, uri)
+	}
+
+	return uris, nil
+}
+
+// sessionUris returns the tracks played since since. The recently played
+// tracks are recorded first so plays the tui has not fetched yet count too.
+func sessionUris(ctx context.Context, a *App, since time.Time) ([]string, error) {
+	page, err := a.client.GetRecentlyPlayedTracks(ctx, client.RecentlyPlayedTracksParams{
+		Limit: 50,
+		After: int(since.UnixMilli()),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	plays := make([]history.Play, 0, len(page.Items))
+
+	for _, item := range page.Items {
+		play, err := history.FromPlayHistory(item)
+
+		if err != nil {
+			return nil, err
+		}
+
+		plays = append(plays, play)
+	}
+
+	q := database.New(a.db)
+
+	if _, err := history.Record(ctx, q, plays); err != nil {
+		return nil, err
+	}
+
+	return history.PlayedSince(ctx, q, since)
+}
+
+// defaultPlaylistId is the default playlist picked in the tui, read from the
+// stored ui state when running from the command line.
+func defaultPlaylistId(ctx context.Context, a *App) (string, error) {
+	if a.DefaultPlaylistIsValid() {
+		return a.DefaultPlaylistId(), nil
+	}
+
+	user, err := a.client.GetCurrentUserProfile(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	state, err := database.New(a.db).GetUiState(ctx, user.Id)
+
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && state.DefaultPlaylistID == "") {
+		return "", fmt.Errorf("no default playlist, pick one in the tui first")
+	}
+
+	return state.DefaultPlaylistID, err
+}
+
+type saveOptions struct {
+	source string
+	since time.Time
+	name string
+	toDefault bool
+}
+
+type saveResult struct {
+	playlist string
+	source string
+	saved int
+	skipped int
+}
+
+func (r saveResult) String() string {
+	s := fmt.Sprintf("saved %d tracks from the %s to %s", r.saved, r.source, r.playlist)
+
+	if r.skipped > 0 {
+		s += fmt.Sprintf(", %d were already in it", r.skipped)
+	}
+
+	return s
+}
+
+// saveTracks saves the queue or the session as a new playlist or appends it
+// to the default playlist, leaving out tracks that are already there.
+func saveTracks(ctx context.Context, a *App, opts saveOptions) (saveResult, error) {
+	result := saveResult{ source: opts.source }
+
+	var uris []string
+	var err error
+
+	if opts.source == sourceSession {
+		uris, err = sessionUris(ctx, a, opts.since)
+	} else {
+		uris, err = queueUris(ctx, a)
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	if len(uris) == 0 {
+		return result, fmt.Errorf("the %s is empty", opts.source)
+	}
+
+	var id string
+
+	if opts.toDefault {
+		id, err = defaultPlaylistId(ctx, a)
+
+		if err != nil {
+			return result, err
+		}
+
+		p, items, err := loadPlaylistItems(ctx, a, types.SimplifiedPlaylistObject{ Id: id })
+
+		if err != nil {
+			return result, err
+		}
+
+		existing := make(map[string]bool)
+
+		for _, uri := range playlist.EditUris(items) {
+			existing[uri] = true
+		}
+
+		var missing []string
+
+		for _, uri := range uris {
+			if !existing[uri] {
+				missing = append(missing, uri)
+			}
+		}
+
+		result.skipped = len(uris) - len(missing)
+		result.playlist = a.DefaultPlaylistName()
+
+		if result.playlist == "" {
+			result.playlist = p.Id
+		}
+
+		uris = missing
+	} else {
+		name := opts.name
+
+		if name == "" {
+			title := "Queue"
+
+			if opts.source == sourceSession {
+				title = "Session"
+			}
+
+			name = title + " " + time.Now().Format("2006-01-02 15:04")
+		}
+
+		user, err := a.client.GetCurrentUserProfile(ctx)
+
+		if err != nil {
+			return result, err
+		}
+
+		created, err := a.client.CreatePlaylist(ctx, client.CreatePlaylistParams{
+			UserId: user.Id,
+			Name: name,
+			Description: "saved from the " + opts.source + " by gsp",
+		})
+
+		if err != nil {
+			return result, fmt.Errorf("creating playlist %s: %w", name, err)
+		}
+
+		id = created.Id
+		result.playlist = created.Name
+	}
+
+	if len(uris) > 0 {
+		if _, err := playlist.AddUris(ctx, a.client, id, uris); err != nil {
+			return result, err
+		}
+	}
+
+	result.saved = len(uris)
+
+	return result, nil
+}
+
+type SaveTracksResult struct {
+	result saveResult
+	err error
+}
+
+func (r SaveTracksResult) Err() error {
+	return r.err
+}
+
+func SaveTracksCmd(a *App, opts saveOptions) tea.Cmd {
+	return func() tea.Msg {
+		result, err := saveTracks(defaultAccessTokenCtx(a), a, opts)
+
+		return SaveTracksResult{
+			result: result,
+			err: err,
+		}
+	}
+}
+
+// handleSave saves the current session when its table is selected and the
+// queue otherwise.
+func handleSave(a *App) tea.Cmd {
+	opts := saveOptions{ source: sourceQueue }
+
+	if t, ok := a.grid.At(a.grid.Cursor()).(Table[Rower]); ok && t.Title() == "Current Session" {
+		opts = saveOptions{ source: sourceSession, since: a.sessionStart }
+	}
+
+	a.AppendMessage("saving the " + opts.source + " as a playlist")
+
+	return SaveTracksCmd(a, opts)
+}
+
+func SaveHandler(a *App) *CliCommand {
+	var opts saveOptions
+	var since time.Duration
+	cmd := NewCliCommand("save", "<queue|session>", "save the queue or the tracks played this session as a playlist")
+	cmd.Flags.DurationVar(&since, "since", 3*time.Hour, "how far back the session goes")
+	cmd.Flags.StringVar(&opts.name, "name", "", "name of the new playlist, defaults to the source and the time")
+	cmd.Flags.BoolVar(&opts.toDefault, "default", false, "append to the default playlist instead of creating one")
+	cmd.Structured = true
+	cmd.Run = func(args ...string) error {
+		if len(args) != 1 || (args[0] != sourceQueue && args[0] != sourceSession) {
+			return errUsage
+		}
+
+		if opts.toDefault && opts.name != "" {
+			return usageError("-name and -default cannot be used together")
+		}
+
+		opts.source = args[0]
+		opts.since = time.Now().Add(-since)
+
+		result, err := saveTracks(defaultAccessTokenCtx(a), a, opts)
+
+		if err != nil {
+			return err
+		}
+
+		if p := a.printer(); !p.Text() {
+			return output.Print(p, output.SaveResult{
+				Source: result.source,
+				Playlist: result.playlist,
+				Saved: result.saved,
+				Skipped: result.skipped,
+			})
+		}
+
+		fmt.Println(result)
+
+		return nil
+	}
+
+	return cmd
+}
@@ -0,0 +1,1028 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/library"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/types"
+	"github.com/arjunmoola/go-spotify/utils"
+	"golang.org/x/term"
+)
+
+// shellHistorySize is how many lines gsp shell remembers across sessions.
+const shellHistorySize = 500
+
+// shellCommand is a command of gsp shell. Everything after the name is one
+// argument, so names with spaces need no quotes.
+type shellCommand struct {
+	names []string
+	args string
+	summary string
+	run func(ctx context.Context, sh *shell, arg string) error
+	// complete lists what the argument can be for tab completion
+	complete func(sh *shell) []string
+}
+
+type shell struct {
+	a *App
+	term *term.Terminal
+	commands []shellCommand
+
+	// results are the results of the last search, commands take them by
+	// number or name
+	results []output.SearchResult
+	devices []output.Device
+	playlists []string
+}
+
+func ShellHandler(a *App) *CliCommand {
+	cmd := NewCliCommand("shell", "", "an interactive prompt that keeps the login for many commands")
+	cmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		sh := &shell{ a: a }
+		sh.commands = shellCommands()
+
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return sh.runScript(os.Stdin)
+		}
+
+		return sh.runInteractive()
+	}
+
+	return cmd
+}
+
+// runInteractive reads lines with editing, history and completion. The
+// terminal is only raw while a line is read, commands print as usual and
+// ctrl+c cancels the running one.
+func (sh *shell) runInteractive() error {
+	fd := int(os.Stdin.Fd())
+
+	sh.term = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{ os.Stdin, os.Stdout }, sh.prompt())
+	sh.term.History = loadShellHistory(filepath.Join(utils.ConfigDir(), "shell_history"))
+	sh.term.AutoCompleteCallback = sh.autoComplete
+
+	fmt.Println("gsp shell, help lists the commands, ctrl+d leaves")
+
+	for {
+		if width, height, err := term.GetSize(fd); err == nil {
+			sh.term.SetSize(width, height)
+		}
+
+		state, err := term.MakeRaw(fd)
+
+		if err != nil {
+			return err
+		}
+
+		line, err := sh.term.ReadLine()
+		term.Restore(fd, state)
+
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if done, _ := sh.exec(line); done {
+			return nil
+		}
+	}
+}
+
+// runScript runs commands piped in, one per line, e.g. from a file.
+func (sh *shell) runScript(r io.Reader) error {
+	var failed bool
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		done, ok := sh.exec(line)
+		failed = failed || !ok
+
+		if done {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if failed {
+		return ExitError{ Code: ExitFailure, Err: errors.New("some commands failed") }
+	}
+
+	return nil
+}
+
+func (sh *shell) prompt() string {
+	if profile := sh.a.cliOptions.Profile; profile != "" {
+		return "gsp:" + profile + "> "
+	}
+
+	return "gsp> "
+}
+
+// exec runs a line and reports whether the shell should end and whether
+// the command succeeded. Errors are printed, the shell goes on.
+func (sh *shell) exec(line string) (done bool, ok bool) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+
+	if name == "" {
+		return false, true
+	}
+
+	if name == "exit" || name == "quit" {
+		return true, true
+	}
+
+	cmd, found := sh.lookup(name)
+
+	if !found {
+		fmt.Fprintf(os.Stderr, "unknown command %s, help lists the commands\n", name)
+		return false, false
+	}
+
+	// the session can outlast the access token
+	if time.Until(sh.a.ExpiresAt()) < tokenMargin {
+		if err := sh.a.renewAccessToken(); err != nil {
+			fmt.Fprintf(os.Stderr, "gsp: could not renew the login: %v\n", err)
+			return false, false
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(defaultAccessTokenCtx(sh.a), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, sh, arg); err != nil {
+		if ctx.Err() != nil {
+			err = errors.New("canceled")
+		}
+
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+
+		return false, false
+	}
+
+	return false, true
+}
+
+func (sh *shell) lookup(name string) (shellCommand, bool) {
+	for _, cmd := range sh.commands {
+		if slices.Contains(cmd.names, name) {
+			return cmd, true
+		}
+	}
+
+	return shellCommand{}, false
+}
+
+// autoComplete completes the command name or its argument on tab. An
+// ambiguous completion is extended as far as it goes, pressing tab again
+// lists the candidates.
+func (sh *shell) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	prefix := strings.TrimLeft(line[:pos], " ")
+	name, arg, hasArg := strings.Cut(prefix, " ")
+
+	var candidates []string
+	word := name
+
+	if hasArg {
+		cmd, ok := sh.lookup(name)
+
+		if !ok || cmd.complete == nil {
+			return "", 0, false
+		}
+
+		candidates = cmd.complete(sh)
+		word = strings.TrimLeft(arg, " ")
+	} else {
+		for _, cmd := range sh.commands {
+			candidates = append(candidates, cmd.names...)
+		}
+
+		candidates = append(candidates, "exit")
+	}
+
+	var matches []string
+
+	for _, c := range candidates {
+		if hasPrefixFold(c, word) && !slices.Contains(matches, c) {
+			matches = append(matches, c)
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := commonPrefixFold(matches)
+
+	if len(matches) == 1 && !hasArg {
+		completion += " "
+	}
+
+	if len(matches) > 1 && utf8.RuneCountInString(completion) <= utf8.RuneCountInString(word) {
+		fmt.Fprintln(sh.term, strings.Join(matches, "   "))
+		return "", 0, false
+	}
+
+	head := line[:pos-len(word)] + completion
+
+	return head + line[pos:], len(head), true
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// commonPrefixFold is the longest prefix all of s share ignoring case, in
+// the case of the first one.
+func commonPrefixFold(s []string) string {
+	first := []rune(s[0])
+	n := len(first)
+
+	for _, other := range s[1:] {
+		runes := []rune(other)
+		i := 0
+
+		for i < n && i < len(runes) && strings.EqualFold(string(first[i]), string(runes[i])) {
+			i++
+		}
+
+		n = i
+	}
+
+	return string(first[:n])
+}
+
+// result finds a result of the last search by its number or name.
+func (sh *shell) result(arg string) (output.SearchResult, error) {
+	if len(sh.results) == 0 {
+		return output.SearchResult{}, fmt.Errorf("search for something first")
+	}
+
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(sh.results) {
+			return output.SearchResult{}, fmt.Errorf("pick a result between 1 and %d", len(sh.results))
+		}
+
+		return sh.results[n-1], nil
+	}
+
+	for _, r := range sh.results {
+		if strings.EqualFold(r.Name, arg) {
+			return r, nil
+		}
+	}
+
+	return output.SearchResult{}, fmt.Errorf("no result named %q", arg)
+}
+
+// track is the uri of the track arg names, a result, a uri or the playing
+// track without arg.
+func (sh *shell) track(ctx context.Context, arg string) (string, string, error) {
+	if arg == "" {
+		playing, err := getCurrentlyPlaying(ctx, sh.a)
+
+		if err != nil {
+			return "", "", err
+		}
+
+		n := output.NewNowPlaying(playing)
+
+		if n.Type != "track" {
+			return "", "", fmt.Errorf("nothing is playing")
+		}
+
+		return n.Uri, n.Title, nil
+	}
+
+	if strings.HasPrefix(arg, "spotify:track:") {
+		return arg, arg, nil
+	}
+
+	r, err := sh.result(arg)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	if r.Kind != "track" {
+		return "", "", fmt.Errorf("%s is %s, not a track", r.Name, withArticle(r.Kind))
+	}
+
+	return r.Uri, r.Name, nil
+}
+
+func withArticle(kind string) string {
+	if strings.HasPrefix(kind, "a") {
+		return "an " + kind
+	}
+
+	return "a " + kind
+}
+
+func (sh *shell) resultNames(kinds ...string) []string {
+	var names []string
+
+	for _, r := range sh.results {
+		if len(kinds) == 0 || slices.Contains(kinds, r.Kind) {
+			names = append(names, r.Name)
+		}
+	}
+
+	return names
+}
+
+// activeDevice is the device spotify plays on, every action needs one.
+func (sh *shell) activeDevice(ctx context.Context) (output.Device, error) {
+	devices, err := sh.listDevices(ctx)
+
+	if err != nil {
+		return output.Device{}, err
+	}
+
+	for _, device := range devices {
+		if device.Active {
+			return device, nil
+		}
+	}
+
+	return output.Device{}, fmt.Errorf("no active device, pick one with dev")
+}
+
+func (sh *shell) listDevices(ctx context.Context) ([]output.Device, error) {
+	devices, err := listDevices(ctx, sh.a)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sh.devices = devices
+
+	return devices, nil
+}
+
+func (sh *shell) deviceNames() []string {
+	if sh.devices == nil {
+		ctx, cancel := context.WithTimeout(defaultAccessTokenCtx(sh.a), 5*time.Second)
+		defer cancel()
+
+		sh.listDevices(ctx)
+	}
+
+	names := make([]string, 0, len(sh.devices))
+
+	for _, device := range sh.devices {
+		names = append(names, device.Name)
+	}
+
+	return names
+}
+
+// playlistNames are the names of the user's playlists, from the local
+// mirror when it was synced.
+func (sh *shell) playlistNames() []string {
+	if sh.playlists != nil {
+		return sh.playlists
+	}
+
+	ctx, cancel := context.WithTimeout(defaultAccessTokenCtx(sh.a), 10*time.Second)
+	defer cancel()
+
+	playlists, err := library.Playlists(ctx, database.New(sh.a.db))
+
+	if err == nil && len(playlists) == 0 {
+		playlists, err = sh.a.client.GetAllCurrentUsersPlaylists(ctx)
+	}
+
+	if err != nil {
+		logger.Error("could not list the playlists for completion", "err", err)
+		return nil
+	}
+
+	sh.playlists = make([]string, 0, len(playlists))
+
+	for _, p := range playlists {
+		sh.playlists = append(sh.playlists, p.Name)
+	}
+
+	return sh.playlists
+}
+
+// changed drops what shows the playback state before an action.
+func (sh *shell) changed() {
+	if err := statusCache().Clear(); err != nil {
+		logger.Error("could not clear the status cache", "err", err)
+	}
+
+	if _, err := sh.a.callDaemon(context.Background(), "poll", nil, nil); err != nil {
+		logger.Error("could not ask the daemon to poll", "err", err)
+	}
+}
+
+// playback runs an action on the active device.
+func (sh *shell) playback(ctx context.Context, f func(deviceId string) error) error {
+	device, err := sh.activeDevice(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if err := f(device.Id); err != nil {
+		return err
+	}
+
+	sh.changed()
+
+	return nil
+}
+
+func (sh *shell) play(ctx context.Context, other types.Optional[client.OtherParams]) error {
+	return sh.playback(ctx, func(deviceId string) error {
+		return sh.a.client.PlaybackAction(ctx, client.PlaybackActionParams{
+			DeviceId: deviceId,
+			Action: "play",
+			Other: other,
+		})
+	})
+}
+
+func playUri(uri string) types.Optional[client.OtherParams] {
+	other := client.OtherParams{}
+
+	if strings.HasPrefix(uri, "spotify:track:") || strings.HasPrefix(uri, "spotify:episode:") {
+		other.Uris = types.Optional[[]string]{ Value: []string{ uri }, Valid: true }
+	} else {
+		other.ContextUri = types.Optional[string]{ Value: uri, Valid: true }
+	}
+
+	return types.Optional[client.OtherParams]{ Value: other, Valid: true }
+}
+
+func shellCommands() []shellCommand {
+	return []shellCommand{
+		{
+			names: []string{ "search", "s" },
+			args: "<query>",
+			summary: "search spotify, the results are numbered for the other commands",
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				if arg == "" {
+					return fmt.Errorf("search for what?")
+				}
+
+				results, err := searchSpotify(ctx, sh.a, arg, sh.a.config.PageSizes.Search)
+
+				if err != nil {
+					return err
+				}
+
+				sh.results = results
+
+				return sh.printResults()
+			},
+		},
+		{
+			names: []string{ "results", "r" },
+			summary: "show the results of the last search again",
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				return sh.printResults()
+			},
+		},
+		{
+			names: []string{ "play" },
+			args: "[result|playlist|uri]",
+			summary: "resume, or play a search result, playlist or uri",
+			complete: func(sh *shell) []string {
+				return append(sh.resultNames(), sh.playlistNames()...)
+			},
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				if arg == "" {
+					return sh.play(ctx, types.Optional[client.OtherParams]{})
+				}
+
+				if strings.HasPrefix(arg, "spotify:") {
+					return sh.play(ctx, playUri(arg))
+				}
+
+				if r, err := sh.result(arg); err == nil {
+					fmt.Printf("playing %s\n", r.Name)
+					return sh.play(ctx, playUri(r.Uri))
+				} else if _, numeric := strconv.Atoi(arg); numeric == nil {
+					return err
+				}
+
+				p, err := playlist.Resolve(ctx, sh.a.db, sh.a.client, arg)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("playing %s\n", p.Name)
+
+				return sh.play(ctx, playUri("spotify:playlist:" + p.Id))
+			},
+		},
+		{
+			names: []string{ "pause" },
+			summary: "pause playback",
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				return sh.playback(ctx, func(deviceId string) error {
+					return sh.a.client.PlaybackAction(ctx, client.PlaybackActionParams{ DeviceId: deviceId, Action: "pause" })
+				})
+			},
+		},
+		{
+			names: []string{ "next", "n" },
+			summary: "skip to the next track",
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				return sh.playback(ctx, func(deviceId string) error {
+					return sh.a.client.SkipSong(ctx, client.SkipSongParams{ DeviceId: deviceId, Direction: "next" })
+				})
+			},
+		},
+		{
+			names: []string{ "prev", "b" },
+			summary: "go back to the previous track",
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				return sh.playback(ctx, func(deviceId string) error {
+					return sh.a.client.SkipSong(ctx, client.SkipSongParams{ DeviceId: deviceId, Direction: "previous" })
+				})
+			},
+		},
+		{
+			names: []string{ "now" },
+			summary: "show what is playing",
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				playing, err := getCurrentlyPlaying(ctx, sh.a)
+
+				if err != nil {
+					return err
+				}
+
+				return printNowPlaying(output.NewNowPlaying(playing))
+			},
+		},
+		{
+			names: []string{ "q" },
+			args: "[result|uri]",
+			summary: "queue a track from the search results, or show the queue",
+			complete: func(sh *shell) []string {
+				return sh.resultNames("track")
+			},
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				if arg == "" {
+					return sh.printQueue(ctx)
+				}
+
+				uri, name, err := sh.track(ctx, arg)
+
+				if err != nil {
+					return err
+				}
+
+				err = sh.playback(ctx, func(deviceId string) error {
+					return sh.a.client.AddItemToQueue(ctx, client.AddItemToQueueParams{ DeviceId: deviceId, Uri: uri })
+				})
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("queued %s\n", name)
+
+				return nil
+			},
+		},
+		{
+			names: []string{ "vol" },
+			args: "[percent|+n|-n]",
+			summary: "show or set the volume of the active device",
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				device, err := sh.activeDevice(ctx)
+
+				if err != nil {
+					return err
+				}
+
+				if device.Volume == nil {
+					return fmt.Errorf("%s has no volume control", device.Name)
+				}
+
+				if arg == "" {
+					fmt.Printf("%d%%\n", *device.Volume)
+					return nil
+				}
+
+				percent, err := strconv.Atoi(strings.TrimSuffix(arg, "%"))
+
+				if err != nil {
+					return fmt.Errorf("%q is not a volume, use e.g. 40, +10 or -10", arg)
+				}
+
+				if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+					percent += *device.Volume
+				}
+
+				percent = min(max(percent, 0), 100)
+
+				err = sh.a.client.SetPlaybackVolume(ctx, client.SetPlaybackVolumeParams{ DeviceId: device.Id, Percent: percent })
+
+				if err != nil {
+					return err
+				}
+
+				sh.changed()
+				fmt.Printf("%d%%\n", percent)
+
+				return nil
+			},
+		},
+		{
+			names: []string{ "dev" },
+			args: "[device]",
+			summary: "list the devices, or move playback to one by number or name",
+			complete: (*shell).deviceNames,
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				devices, err := sh.listDevices(ctx)
+
+				if err != nil {
+					return err
+				}
+
+				if arg == "" {
+					return printShellDevices(devices)
+				}
+
+				device, err := findDevice(devices, arg)
+
+				if err != nil {
+					return err
+				}
+
+				if err := sh.a.client.TransferPlayback(ctx, device.Id, false); err != nil {
+					return err
+				}
+
+				sh.devices = nil
+				sh.changed()
+				fmt.Printf("playing on %s\n", device.Name)
+
+				return nil
+			},
+		},
+		{
+			names: []string{ "like" },
+			args: "[result]",
+			summary: "add a track from the search results, or the playing one, to the liked songs",
+			complete: func(sh *shell) []string {
+				return sh.resultNames("track")
+			},
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				uri, name, err := sh.track(ctx, arg)
+
+				if err != nil {
+					return err
+				}
+
+				if err := likeTrack(ctx, sh.a, uri); err != nil {
+					return err
+				}
+
+				fmt.Printf("added %s to liked songs\n", name)
+
+				return nil
+			},
+		},
+		{
+			names: []string{ "add" },
+			args: "<playlist>",
+			summary: "add the playing track to a playlist",
+			complete: (*shell).playlistNames,
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				if arg == "" {
+					return fmt.Errorf("add to which playlist?")
+				}
+
+				uri, name, err := sh.track(ctx, "")
+
+				if err != nil {
+					return err
+				}
+
+				p, err := playlist.Resolve(ctx, sh.a.db, sh.a.client, arg)
+
+				if err != nil {
+					return err
+				}
+
+				_, err = sh.a.client.AddItemsToPlaylist(ctx, client.AddItemsToPlaylistParams{ Id: p.Id, Uris: []string{ uri } })
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("added %s to %s\n", name, p.Name)
+
+				return nil
+			},
+		},
+		{
+			names: []string{ "playlists" },
+			summary: "list your playlists",
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				sh.playlists = nil
+
+				for _, name := range sh.playlistNames() {
+					fmt.Println(name)
+				}
+
+				return nil
+			},
+		},
+		{
+			names: []string{ "shuffle" },
+			args: "on|off",
+			summary: "turn shuffle on or off",
+			complete: func(sh *shell) []string {
+				return []string{ "on", "off" }
+			},
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				if arg != "on" && arg != "off" {
+					return fmt.Errorf("use shuffle on or shuffle off")
+				}
+
+				return sh.playback(ctx, func(deviceId string) error {
+					return sh.a.client.TogglePlaybackShuffle(ctx, client.TogglePlaybackShuffleParams{ DeviceId: deviceId, State: arg == "on" })
+				})
+			},
+		},
+		{
+			names: []string{ "repeat" },
+			args: "off|track|context",
+			summary: "set the repeat mode",
+			complete: func(sh *shell) []string {
+				return []string{ "off", "track", "context" }
+			},
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				if !slices.Contains([]string{ "off", "track", "context" }, arg) {
+					return fmt.Errorf("use repeat off, repeat track or repeat context")
+				}
+
+				return sh.playback(ctx, func(deviceId string) error {
+					return sh.a.client.SetRepeatMode(ctx, client.SetRepeatModeParams{ DeviceId: deviceId, State: arg })
+				})
+			},
+		},
+		{
+			names: []string{ "help", "?" },
+			summary: "list the commands",
+			run: func(ctx context.Context, sh *shell, arg string) error {
+				tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+				for _, cmd := range sh.commands {
+					fmt.Fprintf(tw, "  %s %s\t%s\n", strings.Join(cmd.names, ", "), cmd.args, cmd.summary)
+				}
+
+				fmt.Fprintf(tw, "  exit\tleave the shell, ctrl+d does too\n")
+
+				return tw.Flush()
+			},
+		},
+	}
+}
+
+func (sh *shell) printResults() error {
+	if len(sh.results) == 0 {
+		fmt.Println("nothing found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for i, r := range sh.results {
+		detail := strings.Join(r.Artists, ", ")
+
+		if r.Kind == "playlist" {
+			detail = r.Owner
+		}
+
+		fmt.Fprintf(tw, "%3d\t%s\t%s\t%s\n", i+1, r.Kind, r.Name, detail)
+	}
+
+	return tw.Flush()
+}
+
+func (sh *shell) printQueue(ctx context.Context) error {
+	items, err := listQueue(ctx, sh.a)
+
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Println("the queue is empty")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for _, item := range items {
+		position := strconv.Itoa(item.Position)
+
+		if item.Position == 0 {
+			position = "now"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", position, item.Title, strings.Join(item.Artists, ", "))
+	}
+
+	return tw.Flush()
+}
+
+func printNowPlaying(n output.NowPlaying) error {
+	if n.Uri == "" {
+		fmt.Println("nothing is playing")
+		return nil
+	}
+
+	state := "playing"
+
+	if !n.Playing {
+		state = "paused"
+	}
+
+	fmt.Printf("%s %s", state, n.Title)
+
+	if len(n.Artists) > 0 {
+		fmt.Printf(" by %s", strings.Join(n.Artists, ", "))
+	}
+
+	fmt.Printf(" (%s/%s)", output.FormatDuration(n.ProgressMs), output.FormatDuration(n.DurationMs))
+
+	if n.Device.Name != "" {
+		fmt.Printf(" on %s", n.Device.Name)
+	}
+
+	fmt.Println()
+
+	return nil
+}
+
+func printShellDevices(devices []output.Device) error {
+	if len(devices) == 0 {
+		fmt.Println("no devices, open spotify on one")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for i, device := range devices {
+		active, volume := "", "-"
+
+		if device.Active {
+			active = "*"
+		}
+
+		if device.Volume != nil {
+			volume = fmt.Sprintf("%d%%", *device.Volume)
+		}
+
+		fmt.Fprintf(tw, "%3d\t%s\t%s\t%s\t%s\n", i+1, active, device.Name, device.Type, volume)
+	}
+
+	return tw.Flush()
+}
+
+// findDevice finds a device by number, name or the start of its name.
+func findDevice(devices []output.Device, arg string) (output.Device, error) {
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(devices) {
+			return output.Device{}, fmt.Errorf("pick a device between 1 and %d", len(devices))
+		}
+
+		return devices[n-1], nil
+	}
+
+	var found []output.Device
+
+	for _, device := range devices {
+		if strings.EqualFold(device.Name, arg) {
+			return device, nil
+		}
+
+		if hasPrefixFold(device.Name, arg) {
+			found = append(found, device)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return output.Device{}, fmt.Errorf("no device named %q", arg)
+	case 1:
+		return found[0], nil
+	}
+
+	return output.Device{}, fmt.Errorf("%q could be any of %d devices, type more of the name", arg, len(found))
+}
+
+// shellHistory keeps the lines of gsp shell in a file so they are there in
+// the next session.
+type shellHistory struct {
+	path string
+	// entries are the oldest first
+	entries []string
+}
+
+func loadShellHistory(path string) *shellHistory {
+	h := &shellHistory{ path: path }
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return h
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+
+	if len(h.entries) > shellHistorySize {
+		h.entries = h.entries[len(h.entries)-shellHistorySize:]
+		os.WriteFile(path, []byte(strings.Join(h.entries, "\n") + "\n"), 0600)
+	}
+
+	return h
+}
+
+func (h *shellHistory) Add(entry string) {
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+
+	if len(h.entries) > shellHistorySize {
+		h.entries = h.entries[1:]
+	}
+
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		logger.Error("could not save the shell history", "err", err)
+		return
+	}
+
+	defer f.Close()
+
+	fmt.Fprintln(f, entry)
+}
+
+func (h *shellHistory) Len() int {
+	return len(h.entries)
+}
+
+func (h *shellHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
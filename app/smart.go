@@ -0,0 +1,393 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/smart"
+)
+
+// stringList is a flag that can be given more than once.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// splitArtists undoes the ", " the local library joins artist names with.
+func splitArtists(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+
+	return strings.Split(s, ", ")
+}
+
+type smartOptions struct {
+	source string
+	rules smart.Rules
+	genres stringList
+	artists stringList
+	every time.Duration
+}
+
+func createSmartPlaylist(ctx context.Context, a *App, name string, opts smartOptions) error {
+	rules := opts.rules
+	rules.Genres = opts.genres
+	rules.Artists = opts.artists
+
+	switch opts.source {
+	case smart.SourceLiked, smart.SourceAll:
+		rules.Source = opts.source
+	default:
+		p, err := playlist.Resolve(ctx, a.db, a.client, opts.source)
+
+		if err != nil {
+			return err
+		}
+
+		rules.Source = p.Id
+		rules.SourceName = p.Name
+	}
+
+	if err := smart.Save(ctx, database.New(a.db), name, rules, opts.every); err != nil {
+		return err
+	}
+
+	fmt.Printf("saved %s: %s\n", name, rules)
+	fmt.Printf("run gsp smart refresh %q to create it on spotify\n", name)
+
+	return nil
+}
+
+func printSmartPlaylists(playlists []smart.Playlist) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "NAME\tEVERY\tREFRESHED\tRULES")
+
+	for _, p := range playlists {
+		every, refreshed := "-", "never"
+
+		if p.RefreshInterval > 0 {
+			every = p.RefreshInterval.String()
+		}
+
+		if !p.RefreshedAt.IsZero() {
+			refreshed = p.RefreshedAt.Local().Format(time.DateTime)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.Name, every, refreshed, p.Rules)
+	}
+
+	return tw.Flush()
+}
+
+func printSmartPlaylistRecords(p *output.Printer, playlists []smart.Playlist) error {
+	records := make([]output.SmartPlaylist, 0, len(playlists))
+
+	for _, sp := range playlists {
+		rules, err := sp.Rules.Marshal()
+
+		if err != nil {
+			return err
+		}
+
+		r := output.SmartPlaylist{
+			Name: sp.Name,
+			PlaylistId: sp.PlaylistId,
+			Description: sp.Rules.String(),
+			Rules: json.RawMessage(rules),
+		}
+
+		if sp.RefreshInterval > 0 {
+			r.RefreshInterval = sp.RefreshInterval.String()
+		}
+
+		if !sp.RefreshedAt.IsZero() {
+			r.RefreshedAt = sp.RefreshedAt.UTC().Format(time.RFC3339)
+		}
+
+		records = append(records, r)
+	}
+
+	return output.List(p, records)
+}
+
+// showSmartPlaylist lists the tracks a refresh would write without touching
+// the spotify playlist.
+func showSmartPlaylist(ctx context.Context, a *App, name string) error {
+	q := database.New(a.db)
+
+	p, err := smart.Get(ctx, q, name)
+
+	if err != nil {
+		return err
+	}
+
+	if len(p.Rules.Genres) > 0 {
+		if _, err := smart.FetchGenres(ctx, a.db, a.client); err != nil {
+			return fmt.Errorf("fetching genres: %w", err)
+		}
+	}
+
+	tracks, err := smart.Evaluate(ctx, q, p.Rules, p.PlaylistId, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	if printer := a.printer(); !printer.Text() {
+		records := make([]output.SmartTrack, 0, len(tracks))
+
+		for _, t := range tracks {
+			records = append(records, output.SmartTrack{
+				Uri: t.Uri,
+				Title: t.Name,
+				Artists: splitArtists(t.Artists),
+				Album: t.Album,
+				AddedAt: t.AddedAt,
+				LastPlayedAt: t.LastPlayedAt,
+				Plays: t.Plays,
+				Popularity: t.Popularity,
+			})
+		}
+
+		return output.List(printer, records)
+	}
+
+	fmt.Printf("%s: %s\n\n", p.Name, p.Rules)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "TRACK\tARTISTS\tADDED\tPLAYS\tLAST PLAYED")
+
+	for _, t := range tracks {
+		lastPlayed := t.LastPlayedAt
+
+		if lastPlayed == "" {
+			lastPlayed = "never"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", t.Name, t.Artists, t.AddedAt, t.Plays, lastPlayed)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d tracks\n", len(tracks))
+
+	return nil
+}
+
+func refreshSmartPlaylists(ctx context.Context, a *App, names []string, due bool) error {
+	results, err := refreshSmart(ctx, a, names, due)
+
+	if p := a.printer(); !p.Text() {
+		records := make([]output.SmartRefresh, 0, len(results))
+
+		for _, r := range results {
+			records = append(records, output.SmartRefresh{ Name: r.Name, PlaylistId: r.PlaylistId, Tracks: r.Tracks, Created: r.Created })
+		}
+
+		return errors.Join(output.List(p, records), err)
+	}
+
+	for _, r := range results {
+		fmt.Println(r)
+	}
+
+	if due && len(results) == 0 && err == nil {
+		fmt.Println("no smart playlists are due")
+	}
+
+	return err
+}
+
+// refreshSmart refreshes the named smart playlists, every one without names
+// or the due ones, stopping at the first that fails.
+func refreshSmart(ctx context.Context, a *App, names []string, due bool) ([]smart.RefreshResult, error) {
+	q := database.New(a.db)
+
+	if due {
+		return smart.RefreshDue(ctx, a.db, a.client, time.Now())
+	}
+
+	var playlists []smart.Playlist
+
+	if len(names) == 0 {
+		all, err := smart.List(ctx, q)
+
+		if err != nil {
+			return nil, err
+		}
+
+		playlists = all
+	}
+
+	for _, name := range names {
+		p, err := smart.Get(ctx, q, name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, p)
+	}
+
+	var results []smart.RefreshResult
+
+	for _, p := range playlists {
+		r, err := smart.Refresh(ctx, a.db, a.client, p)
+
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", p.Name, err)
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+type RefreshSmartPlaylistsResult struct {
+	results []smart.RefreshResult
+	err error
+}
+
+func (r RefreshSmartPlaylistsResult) Err() error {
+	return r.err
+}
+
+// RefreshDueSmartPlaylistsCmd refreshes the smart playlists whose interval
+// has passed, run after each library sync so they see the latest mirror. A
+// running daemon refreshes them instead.
+func RefreshDueSmartPlaylistsCmd(a *App) tea.Cmd {
+	return func() tea.Msg {
+		if a.daemonRunning() {
+			return RefreshSmartPlaylistsResult{}
+		}
+
+		results, err := smart.RefreshDue(defaultAccessTokenCtx(a), a.db, a.client, time.Now())
+
+		return RefreshSmartPlaylistsResult{
+			results: results,
+			err: err,
+		}
+	}
+}
+
+func SmartHandler(a *App) *CliCommand {
+	opts := smartOptions{ rules: smart.DefaultRules() }
+	createCmd := NewCliCommand("create", "<name>", "create a smart playlist from rules over the local library")
+	createCmd.Flags.StringVar(&opts.source, "source", smart.SourceLiked, "liked, all for liked songs and every playlist, or a playlist")
+	createCmd.Flags.Var(&opts.genres, "genre", "only artists with a genre containing this, can be repeated")
+	createCmd.Flags.Var(&opts.artists, "artist", "only tracks by this artist, can be repeated")
+	createCmd.Flags.IntVar(&opts.rules.AddedWithinDays, "added-within", 0, "only tracks added in the last this many days")
+	createCmd.Flags.IntVar(&opts.rules.NotPlayedWithinDays, "not-played-within", 0, "only tracks not played in the last this many days")
+	createCmd.Flags.IntVar(&opts.rules.MinPopularity, "min-popularity", 0, "lowest spotify popularity between 0 and 100")
+	createCmd.Flags.BoolVar(&opts.rules.ExcludeExplicit, "no-explicit", false, "leave out explicit tracks")
+	createCmd.Flags.StringVar(&opts.rules.Sort, "sort", smart.SortAdded, "added, random, popularity, plays or least_played")
+	createCmd.Flags.IntVar(&opts.rules.Limit, "limit", opts.rules.Limit, "most tracks in the playlist")
+	createCmd.Flags.DurationVar(&opts.every, "every", 0, "refresh in the background this often, e.g. 24h")
+	createCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		return createSmartPlaylist(defaultAccessTokenCtx(a), a, args[0], opts)
+	}
+
+	listCmd := NewCliCommand("list", "", "list the smart playlists")
+	listCmd.Structured = true
+	listCmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		playlists, err := smart.List(context.Background(), database.New(a.db))
+
+		if err != nil {
+			return err
+		}
+
+		if p := a.printer(); !p.Text() {
+			return printSmartPlaylistRecords(p, playlists)
+		}
+
+		if len(playlists) == 0 {
+			fmt.Println("no smart playlists, create one with gsp smart create")
+			return nil
+		}
+
+		return printSmartPlaylists(playlists)
+	}
+
+	showCmd := NewCliCommand("show", "<name>", "show the rules and tracks of a smart playlist")
+	showCmd.Structured = true
+	showCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		return showSmartPlaylist(defaultAccessTokenCtx(a), a, args[0])
+	}
+
+	var due bool
+	refreshCmd := NewCliCommand("refresh", "[name...]", "rewrite smart playlists on spotify, every one without names")
+	refreshCmd.Flags.BoolVar(&due, "due", false, "only refresh playlists whose interval has passed")
+	refreshCmd.Structured = true
+	refreshCmd.Run = func(args ...string) error {
+		if due && len(args) > 0 {
+			return usageError("-due cannot be used with names")
+		}
+
+		return refreshSmartPlaylists(defaultAccessTokenCtx(a), a, args, due)
+	}
+
+	deleteCmd := NewCliCommand("delete", "<name>", "delete a smart playlist, the spotify playlist is kept")
+	deleteCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		ctx := context.Background()
+		q := database.New(a.db)
+
+		p, err := smart.Get(ctx, q, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteSmartPlaylist(ctx, p.Name); err != nil {
+			return err
+		}
+
+		fmt.Printf("deleted %s\n", p.Name)
+
+		if p.PlaylistId != "" {
+			fmt.Println("the spotify playlist is kept and no longer refreshed")
+		}
+
+		return nil
+	}
+
+	cmd := NewCliCommand("smart", "", "manage rule-based smart playlists")
+
+	return cmd.Add(createCmd, listCmd, showCmd, refreshCmd, deleteCmd)
+}
@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// defaultQueueCount is how many tracks a shuffle adds to the queue, each one
+// is a separate request.
+const defaultQueueCount = 50
+
+type sortOptions struct {
+	by string
+	reverse bool
+	seed uint64
+	replace bool
+}
+
+// sortPlaylist writes the sorted order back to the playlist and returns the
+// moves it made. By default only the items that are out of order are moved,
+// which keeps their added dates, replace rewrites the whole playlist in a few
+// requests instead.
+func sortPlaylist(ctx context.Context, a *App, p types.SimplifiedPlaylistObject, opts sortOptions) ([]playlist.Op, error) {
+	key, err := playlist.ParseSortKey(opts.by)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p, items, err := loadPlaylistItems(ctx, a, p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	old := playlist.EditUris(items)
+	sorted := playlist.Sort(items, key, opts.reverse, opts.seed)
+
+	if opts.replace {
+		_, err := playlist.Rewrite(ctx, a.client, p.Id, p.SnapshotId, sorted)
+		return nil, err
+	}
+
+	ops, err := playlist.Plan(old, sorted)
+
+	if err != nil || len(ops) == 0 {
+		return ops, err
+	}
+
+	if _, err := playlist.ApplyEdit(ctx, a.client, p.Id, p.SnapshotId, ops); err != nil {
+		return nil, err
+	}
+
+	return ops, nil
+}
+
+// shuffledEntries spreads out the tracks of p, leaving out local files and
+// episodes which cannot be queued or copied reliably.
+func shuffledEntries(ctx context.Context, a *App, p types.SimplifiedPlaylistObject, seed uint64) ([]playlist.Entry, error) {
+	_, items, err := loadPlaylistItems(ctx, a, p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []playlist.Entry
+
+	for _, e := range playlist.Entries(items) {
+		if e.Type == "track" && !strings.HasPrefix(e.Uri, "spotify:local:") {
+			entries = append(entries, e)
+		}
+	}
+
+	return playlist.SpreadShuffle(entries, seed), nil
+}
+
+// queueEntries adds entries to the queue one at a time, the api has no batch
+// endpoint. It returns how many were queued before an error.
+func queueEntries(ctx context.Context, a *App, entries []playlist.Entry, deviceId string) (int, error) {
+	for i, e := range entries {
+		err := a.client.AddItemToQueue(ctx, client.AddItemToQueueParams{
+			Uri: e.Uri,
+			DeviceId: deviceId,
+		})
+
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(entries), nil
+}
+
+// copyShuffled writes the entries to the playlist named name, creating it when
+// it does not exist and replacing its items when it does.
+func copyShuffled(ctx context.Context, a *App, source types.SimplifiedPlaylistObject, name string, entries []playlist.Entry) (types.SimplifiedPlaylistObject, error) {
+	target, err := playlist.Resolve(ctx, a.db, a.client, name)
+
+	var notFound playlist.NotFoundError
+
+	switch {
+	case errors.As(err, &notFound):
+		user, err := a.client.GetCurrentUserProfile(ctx)
+
+		if err != nil {
+			return target, err
+		}
+
+		created, err := a.client.CreatePlaylist(ctx, client.CreatePlaylistParams{
+			UserId: user.Id,
+			Name: name,
+			Description: "shuffle of " + source.Name,
+		})
+
+		if err != nil {
+			return target, fmt.Errorf("creating playlist %s: %w", name, err)
+		}
+
+		target.Id = created.Id
+		target.Name = created.Name
+	case err != nil:
+		return target, err
+	case target.Id == source.Id:
+		return target, fmt.Errorf("the copy must be another playlist, use gsp playlist sort -by random to shuffle %s itself", source.Name)
+	case !a.canEdit(target):
+		return target, fmt.Errorf("%s belongs to %s and cannot be edited", target.Name, target.Owner.Id)
+	}
+
+	uris := make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		uris = append(uris, e.Uri)
+	}
+
+	first := uris[:min(len(uris), client.MaxAddItems)]
+
+	if _, err := a.client.ReplacePlaylistItems(ctx, client.ReplacePlaylistItemsParams{ Id: target.Id, Uris: first }); err != nil {
+		return target, err
+	}
+
+	if len(uris) > len(first) {
+		if _, err := playlist.AddUris(ctx, a.client, target.Id, uris[len(first):]); err != nil {
+			return target, err
+		}
+	}
+
+	return target, nil
+}
+
+type shuffleOptions struct {
+	seed uint64
+	copy string
+	queue bool
+	count int
+}
+
+func shufflePlaylist(ctx context.Context, a *App, p types.SimplifiedPlaylistObject, opts shuffleOptions) error {
+	if opts.seed == 0 {
+		opts.seed = uint64(time.Now().UnixNano())
+	}
+
+	entries, err := shuffledEntries(ctx, a, p, opts.seed)
+
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("%s has no tracks to shuffle\n", p.Name)
+		return nil
+	}
+
+	if opts.queue {
+		entries = entries[:min(len(entries), opts.count)]
+
+		n, err := queueEntries(ctx, a, entries, "")
+
+		fmt.Printf("queued %d tracks from %s (seed %d)\n", n, p.Name, opts.seed)
+
+		return err
+	}
+
+	if opts.copy == "" {
+		opts.copy = p.Name + " (shuffled)"
+	}
+
+	target, err := copyShuffled(ctx, a, p, opts.copy, entries)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %d shuffled tracks of %s to %s (seed %d)\n", len(entries), p.Name, target.Name, opts.seed)
+
+	return nil
+}
+
+type QueueShuffleResult struct {
+	playlist types.SimplifiedPlaylistObject
+	queued int
+	err error
+}
+
+func (r QueueShuffleResult) Err() error {
+	return r.err
+}
+
+func SortPlaylistCmd(a *App, p types.SimplifiedPlaylistObject, opts sortOptions) tea.Cmd {
+	return func() tea.Msg {
+		ops, err := sortPlaylist(defaultAccessTokenCtx(a), a, p, opts)
+
+		return EditPlaylistResult{
+			playlist: p,
+			ops: ops,
+			err: err,
+		}
+	}
+}
+
+func QueueShuffleCmd(a *App, p types.SimplifiedPlaylistObject, count int) tea.Cmd {
+	deviceId, _ := a.ActiveDeviceId()
+
+	return func() tea.Msg {
+		ctx := defaultAccessTokenCtx(a)
+
+		entries, err := shuffledEntries(ctx, a, p, uint64(time.Now().UnixNano()))
+
+		if err != nil {
+			return QueueShuffleResult{ playlist: p, err: err }
+		}
+
+		n, err := queueEntries(ctx, a, entries[:min(len(entries), count)], deviceId)
+
+		return QueueShuffleResult{
+			playlist: p,
+			queued: n,
+			err: err,
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/config"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/models/grid"
+	nested "github.com/arjunmoola/go-spotify/models/list"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// sectionSeparator joins the titles of the expanded sidebar sections.
+const sectionSeparator = "\n"
+
+type LoadUiStateResult struct {
+	state database.UiState
+	found bool
+	err error
+}
+
+func (r LoadUiStateResult) Err() error {
+	return r.err
+}
+
+type SaveDefaultPlaylistResult struct {
+	name string
+	err error
+}
+
+func (r SaveDefaultPlaylistResult) Err() error {
+	return r.err
+}
+
+// profile identifies whose ui state is stored. It is the spotify user id so
+// several accounts can share one database.
+func (a *App) profile() (string, bool) {
+	if !a.UserIsValid() || a.user.Value.Id == "" {
+		return "", false
+	}
+
+	return a.user.Value.Id, true
+}
+
+func LoadUiStateCmd(a *App, profile string) tea.Cmd {
+	return func() tea.Msg {
+		state, err := database.New(a.db).GetUiState(context.Background(), profile)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return LoadUiStateResult{}
+		}
+
+		if err != nil {
+			return LoadUiStateResult{ err: err }
+		}
+
+		return LoadUiStateResult{
+			state: state,
+			found: true,
+		}
+	}
+}
+
+// SaveDefaultPlaylistCmd stores the default playlist as soon as it is picked
+// so quick-add keeps working after a crash.
+func SaveDefaultPlaylistCmd(a *App, p types.Playlist) tea.Cmd {
+	profile, ok := a.profile()
+
+	if !ok {
+		return nil
+	}
+
+	return func() tea.Msg {
+		err := database.New(a.db).SetDefaultPlaylistID(context.Background(), database.SetDefaultPlaylistIDParams{
+			Profile: profile,
+			DefaultPlaylistID: p.Id,
+			UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		})
+
+		return SaveDefaultPlaylistResult{
+			name: p.Name,
+			err: err,
+		}
+	}
+}
+
+// saveUiState writes the current layout of the tui. It runs synchronously on
+// shutdown, right before the database is closed.
+func (a *App) saveUiState() error {
+	profile, ok := a.profile()
+
+	if !ok {
+		return nil
+	}
+
+	var expanded []string
+
+	if sidebar, ok := GetModel[nested.NestedList](a, "sidebar"); ok {
+		for i := 0; i < sidebar.Len(); i++ {
+			if item := sidebar.GetItem(i); item.Expanded() {
+				expanded = append(expanded, item.Title())
+			}
+		}
+	}
+
+	var tableTitle string
+
+	if t, ok := GetModel[Table[Rower]](a, "table"); ok {
+		tableTitle = t.Title()
+	}
+
+	var defaultPlaylistId string
+
+	if a.DefaultPlaylistIsValid() {
+		defaultPlaylistId = a.DefaultPlaylistId()
+	}
+
+	cursor := a.grid.Cursor()
+
+	return database.New(a.db).UpsertUiState(context.Background(), database.UpsertUiStateParams{
+		Profile: profile,
+		DefaultPlaylistID: defaultPlaylistId,
+		ExpandedSections: strings.Join(expanded, sectionSeparator),
+		TableTitle: tableTitle,
+		CursorRow: int64(cursor.Row),
+		CursorCol: int64(cursor.Col),
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (a *App) restoreUiState(state database.UiState, b *Batch) {
+	push := b.Append
+
+	if state.DefaultPlaylistID != "" && !a.DefaultPlaylistIsValid() {
+		push(GetPlaylistCmd(a, client.GetPlaylistParams{
+			Id: state.DefaultPlaylistID,
+			Market: a.config.Market,
+		}))
+	}
+
+	if sidebar, ok := GetModel[nested.NestedList](a, "sidebar"); ok && state.ExpandedSections != "" {
+		sections := strings.Split(state.ExpandedSections, sectionSeparator)
+
+		for i := 0; i < sidebar.Len(); i++ {
+			item := sidebar.GetItem(i)
+
+			for _, title := range sections {
+				if item.Title() == title && item.Expandable() {
+					item.Expand()
+					sidebar.SetItem(item, i)
+				}
+			}
+		}
+
+		SetModel(a, sidebar, "sidebar")
+	}
+
+	cursor := grid.Pos(int(state.CursorRow), int(state.CursorCol))
+
+	if a.grid.Selectable(cursor) {
+		a.savedCursor = Optional[grid.Position]{
+			Value: cursor,
+			Valid: true,
+		}
+		a.grid.SetCursor(cursor)
+	}
+
+	// an explicit startup view wins over whatever was open last time
+	if a.config.StartupView != config.StartupNone || state.TableTitle == "" {
+		return
+	}
+
+	a.restoredTable = state.TableTitle
+
+	if state.TableTitle == "Recently Played" {
+		push(GetUsersRecentlyPlayedCmd(a, client.RecentlyPlayedTracksParams{
+			Limit: a.config.PageSizes.RecentlyPlayed,
+		}))
+		return
+	}
+
+	a.restoreTable()
+}
+
+// restoreTable reopens the table that was shown when the tui was closed once
+// its items have been loaded.
+func (a *App) restoreTable() {
+	title := a.restoredTable
+
+	if title == "" || a.startupViewShown {
+		return
+	}
+
+	var shown bool
+
+	switch title {
+	case "Top Tracks":
+		if items, ok := a.data["top_tracks"].([]types.Track); ok {
+			a.showStartupView(func() { SetTable(a, items, title) })
+			shown = true
+		}
+	case "Liked Songs":
+		if items, ok := a.data["saved_tracks"].([]types.SavedTrack); ok {
+			a.showStartupView(func() { SetTable(a, items, title) })
+			shown = true
+		}
+	default:
+		playlists, _ := a.data["playlists"].([]types.SimplifiedPlaylistObject)
+
+		for _, playlist := range playlists {
+			if playlist.Name != title {
+				continue
+			}
+
+			if items, ok := a.data[playlist.Id].([]types.PlaylistItemUnion); ok {
+				a.registerNewKey(title, "default")
+				a.showStartupView(func() { SetTable(a, items, title) })
+				shown = true
+			}
+
+			break
+		}
+	}
+
+	if !shown {
+		return
+	}
+
+	// SetTable moves the cursor to the table, put it back where it was
+	if a.savedCursor.Valid {
+		a.grid.SetCursor(a.savedCursor.Value)
+		a.grid.SetFocus(false)
+	}
+}
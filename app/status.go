@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/statusline"
+	"github.com/arjunmoola/go-spotify/utils"
+)
+
+const (
+	statusModeText = "text"
+	statusModeWaybar = "waybar"
+	statusModeI3bar = "i3bar"
+)
+
+type statusOptions struct {
+	line string
+	width int
+	scroll bool
+	mode string
+	follow bool
+	interval time.Duration
+}
+
+// statusCache is shared by every gsp status run of the profile.
+func statusCache() statusline.Cache {
+	return statusline.NewCache(utils.ConfigDir())
+}
+
+func statusIcons(a *App) statusline.Icons {
+	s := a.config.Status
+
+	return statusline.Icons{
+		Playing: s.Playing,
+		Paused: s.Paused,
+		Stopped: s.Stopped,
+		Shuffle: s.Shuffle,
+		Repeat: s.Repeat,
+		RepeatOne: s.RepeatOne,
+	}
+}
+
+// fetchStatus returns the cached playback state when it is younger than
+// maxAge and asks spotify otherwise.
+func fetchStatus(ctx context.Context, a *App, cache statusline.Cache, maxAge time.Duration) (statusline.Snapshot, error) {
+	now := time.Now()
+
+	if snapshot, ok := cache.Load(); ok && snapshot.Fresh(now, maxAge) {
+		return snapshot, nil
+	}
+
+	var snapshot statusline.Snapshot
+
+	// the daemon polls on its own, its snapshot needs no cache
+	if ok, err := a.callDaemon(ctx, "now_playing", nil, &snapshot); ok {
+		return snapshot, err
+	}
+
+	status, err := getCurrentlyPlaying(ctx, a)
+
+	if err != nil {
+		return statusline.Snapshot{}, err
+	}
+
+	snapshot = statusline.Snapshot{
+		NowPlaying: output.NewNowPlaying(status),
+		FetchedAt: now,
+	}
+
+	if err := cache.Save(snapshot); err != nil {
+		logger.Error("could not write the status cache", "err", err)
+	}
+
+	return snapshot, nil
+}
+
+// renderStatus formats the state as a line for the chosen mode. Scrolling
+// advances one column per second.
+func renderStatus(tmpl *template.Template, icons statusline.Icons, opts statusOptions, n output.NowPlaying, now time.Time) (string, error) {
+	l := statusline.NewLine(n, icons)
+
+	text, err := statusline.Render(tmpl, l)
+
+	if err != nil {
+		return "", err
+	}
+
+	text = statusline.Fit(text, opts.width, opts.scroll, int(now.Unix()))
+
+	switch opts.mode {
+	case statusModeWaybar:
+		return statusline.Marshal(statusline.NewWaybar(text, l)), nil
+	case statusModeI3bar:
+		return statusline.Marshal([]statusline.I3bar{ statusline.NewI3bar(text, l) }), nil
+	}
+
+	return text, nil
+}
+
+func StatusHandler(a *App) *CliCommand {
+	opts := statusOptions{ mode: statusModeText }
+	cmd := NewCliCommand("status", "", "show what is playing as a line for tmux, polybar, waybar or i3bar")
+	cmd.Structured = true
+	cmd.Flags.StringVar(&opts.line, "line", a.config.Status.Format, "go template of the line with .Icon .State .Title .Artist .Artists .Album .Progress .Duration .Percent .Shuffle .Repeat .Device .Volume")
+	cmd.Flags.IntVar(&opts.width, "width", a.config.Status.Width, "cut the line to this many columns, 0 for no limit")
+	cmd.Flags.BoolVar(&opts.scroll, "scroll", false, "scroll lines longer than -width instead of cutting them")
+	cmd.Flags.StringVar(&opts.mode, "mode", opts.mode, "text, waybar for a custom module with return-type json, or i3bar")
+	cmd.Flags.BoolVar(&opts.follow, "follow", false, "keep running and write a new line whenever it changes")
+	cmd.Flags.DurationVar(&opts.interval, "interval", 5*time.Second, "how often -follow asks spotify for the playback state")
+	cmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		switch opts.mode {
+		case statusModeText, statusModeWaybar, statusModeI3bar:
+		default:
+			return usageError("unknown mode %s, use text, waybar or i3bar", opts.mode)
+		}
+
+		if opts.interval < time.Second {
+			return usageError("-interval must be at least 1s")
+		}
+
+		tmpl, err := statusline.ParseFormat(opts.line)
+
+		if err != nil {
+			return usageError("-line: %v", err)
+		}
+
+		if opts.follow {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return followStatus(ctx, a, tmpl, opts)
+		}
+
+		snapshot, err := fetchStatus(defaultAccessTokenCtx(a), a, statusCache(), a.config.Status.CacheFor.Duration)
+
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		if p := a.printer(); !p.Text() {
+			return output.Print(p, snapshot.At(now))
+		}
+
+		line, err := renderStatus(tmpl, statusIcons(a), opts, snapshot.At(now), now)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(line)
+
+		return nil
+	}
+
+	return cmd
+}
+
+// followStatus renders the line every second from the last snapshot and
+// only asks spotify again once per interval or when the track should have
+// ended. Failed requests keep the last line.
+func followStatus(ctx context.Context, a *App, tmpl *template.Template, opts statusOptions) error {
+	cache := statusCache()
+	icons := statusIcons(a)
+	p := a.printer()
+
+	if opts.mode == statusModeI3bar && p.Text() {
+		fmt.Println(statusline.I3barHeader)
+	}
+
+	var snapshot statusline.Snapshot
+	var last string
+	var lastState *output.NowPlaying
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+
+		if !snapshot.Fresh(now, opts.interval) {
+			if a.IsTokenExpired() {
+				if err := a.renewAccessToken(); err != nil {
+					return ExitError{ Code: ExitAuth, Err: err }
+				}
+			}
+
+			s, err := fetchStatus(defaultAccessTokenCtx(a), a, cache, opts.interval)
+
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "gsp: %v\n", err)
+			} else {
+				snapshot = s
+			}
+		}
+
+		if p.Text() {
+			line, err := renderStatus(tmpl, icons, opts, snapshot.At(now), now)
+
+			if err != nil {
+				return err
+			}
+
+			if line != last {
+				last = line
+
+				if opts.mode == statusModeI3bar {
+					line += ","
+				}
+
+				fmt.Println(line)
+			}
+		} else {
+			// structured output only reports changes of the state itself,
+			// not every second of progress
+			state := snapshot.NowPlaying
+			state.ProgressMs = 0
+
+			if lastState == nil || !reflect.DeepEqual(state, *lastState) {
+				if err := output.Print(p, snapshot.At(now)); err != nil {
+					return err
+				}
+
+				lastState = &state
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/playlist"
+)
+
+type WatchPlaylistsTick struct{}
+
+type CheckWatchedResult struct {
+	changes []playlist.Change
+	// fromDaemon changes were found by a daemon, which ran their hooks
+	fromDaemon bool
+	lastChangeId int64
+	err error
+}
+
+func (r CheckWatchedResult) Err() error {
+	return r.err
+}
+
+// WatchPlaylistsTickCmd schedules the next check of the watched playlists
+// when checking is enabled.
+func WatchPlaylistsTickCmd(a *App) tea.Cmd {
+	d := a.config.Intervals.Watch.Duration
+
+	if d == 0 {
+		return nil
+	}
+
+	return tea.Tick(d, func(_ time.Time) tea.Msg {
+		return WatchPlaylistsTick{}
+	})
+}
+
+// CheckWatchedCmd checks the watched playlists. While a daemon runs it
+// checks them itself, and the changes it logged since the last tick are
+// read back from the change log instead.
+func CheckWatchedCmd(a *App) tea.Cmd {
+	seen := a.lastChangeId
+
+	return func() tea.Msg {
+		ctx := defaultAccessTokenCtx(a)
+
+		if a.daemonRunning() && seen.Valid {
+			changes, last, err := playlist.ChangesAfter(ctx, a.db, seen.Value)
+
+			return CheckWatchedResult{
+				changes: changes,
+				fromDaemon: true,
+				lastChangeId: last,
+				err: err,
+			}
+		}
+
+		var changes []playlist.Change
+		var err error
+
+		if !a.daemonRunning() {
+			changes, err = playlist.CheckWatched(ctx, a.db, a.client, a.config.Market)
+		}
+
+		// whatever is in the log now was shown or found before the tui started
+		last, lastErr := playlist.LastChangeId(ctx, a.db)
+
+		return CheckWatchedResult{
+			changes: changes,
+			lastChangeId: last,
+			err: errors.Join(err, lastErr),
+		}
+	}
+}
+
+func printChange(change playlist.Change) {
+	fmt.Println(change)
+
+	for _, e := range change.Added {
+		by := ""
+
+		if e.AddedBy != "" {
+			by = " by " + e.AddedBy
+		}
+
+		fmt.Printf("\t+ %s%s\n", entryName(e), by)
+	}
+
+	for _, e := range change.Removed {
+		fmt.Printf("\t- %s\n", entryName(e))
+	}
+}
+
+// changeRecords flattens a change into a record per track, like the change
+// log lists them.
+func changeRecords(change playlist.Change) []output.PlaylistChange {
+	var records []output.PlaylistChange
+
+	for _, section := range []struct {
+		change string
+		entries []playlist.Entry
+	}{
+		{ "added", change.Added },
+		{ "removed", change.Removed },
+	} {
+		for _, e := range section.entries {
+			records = append(records, output.PlaylistChange{
+				DetectedAt: change.DetectedAt,
+				PlaylistId: change.PlaylistId,
+				Playlist: change.PlaylistName,
+				Change: section.change,
+				Uri: e.Uri,
+				Title: e.Name,
+				Artists: append([]string{}, e.Artists...),
+				AddedBy: e.AddedBy,
+			})
+		}
+	}
+
+	return records
+}
+
+func printWatches(ctx context.Context, a *App) error {
+	watches, err := database.New(a.db).ListPlaylistWatches(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if p := a.printer(); !p.Text() {
+		records := make([]output.WatchedPlaylist, 0, len(watches))
+
+		for _, w := range watches {
+			records = append(records, output.WatchedPlaylist{ Id: w.PlaylistID, Name: w.Name, CheckedAt: w.CheckedAt })
+		}
+
+		return output.List(p, records)
+	}
+
+	if len(watches) == 0 {
+		fmt.Println("no watched playlists, add one with gsp playlist watch add <playlist>")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "NAME\tID\tCHECKED")
+
+	for _, w := range watches {
+		checked := "never"
+
+		if t, err := time.Parse(time.RFC3339, w.CheckedAt); err == nil {
+			checked = t.Local().Format(time.DateTime)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", w.Name, w.PlaylistID, checked)
+	}
+
+	return tw.Flush()
+}
+
+func printChangeLog(ctx context.Context, a *App, ref string, limit int) error {
+	params := database.ListPlaylistChangesParams{
+		AllPlaylists: ref == "",
+		Limit: int64(limit),
+	}
+
+	if ref != "" {
+		p, err := playlist.Resolve(ctx, a.db, a.client, ref)
+
+		if err != nil {
+			return err
+		}
+
+		params.PlaylistID = p.Id
+	}
+
+	changes, err := database.New(a.db).ListPlaylistChanges(ctx, params)
+
+	if err != nil {
+		return err
+	}
+
+	if p := a.printer(); !p.Text() {
+		records := make([]output.PlaylistChange, 0, len(changes))
+
+		for _, c := range changes {
+			records = append(records, output.PlaylistChange{
+				DetectedAt: c.DetectedAt,
+				PlaylistId: c.PlaylistID,
+				Playlist: c.PlaylistName,
+				Change: c.Change,
+				Uri: c.TrackUri,
+				Title: c.TrackName,
+				Artists: splitArtists(c.ArtistNames),
+				AddedBy: c.AddedBy,
+			})
+		}
+
+		return output.List(p, records)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "DETECTED\tPLAYLIST\tCHANGE\tTRACK\tADDED BY")
+
+	for _, c := range changes {
+		detected := c.DetectedAt
+
+		if t, err := time.Parse(time.RFC3339, c.DetectedAt); err == nil {
+			detected = t.Local().Format(time.DateTime)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s - %s\t%s\n", detected, c.PlaylistName, c.Change, c.ArtistNames, c.TrackName, c.AddedBy)
+	}
+
+	return tw.Flush()
+}
+
+func watchHandler(a *App) *CliCommand {
+	listCmd := NewCliCommand("list", "", "list the watched playlists")
+	listCmd.Structured = true
+	listCmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		return printWatches(context.Background(), a)
+	}
+
+	addCmd := NewCliCommand("add", "<playlist>", "start watching a playlist")
+	addCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		ctx := defaultAccessTokenCtx(a)
+
+		p, err := playlist.Resolve(ctx, a.db, a.client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		if err := playlist.Watch(ctx, a.db, a.client, a.config.Market, p); err != nil {
+			return err
+		}
+
+		fmt.Printf("watching %s\n", p.Name)
+
+		return nil
+	}
+
+	removeCmd := NewCliCommand("remove", "<playlist>", "stop watching a playlist, its change log is kept")
+	removeCmd.Run = func(args ...string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		ctx := defaultAccessTokenCtx(a)
+
+		p, err := playlist.Resolve(ctx, a.db, a.client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		removed, err := playlist.Unwatch(ctx, a.db, p.Id)
+
+		if err != nil {
+			return err
+		}
+
+		if !removed {
+			return fmt.Errorf("%s is not watched", p.Name)
+		}
+
+		fmt.Printf("stopped watching %s, its change log is kept\n", p.Name)
+
+		return nil
+	}
+
+	checkCmd := NewCliCommand("check", "", "check the watched playlists for changes now")
+	checkCmd.Structured = true
+	checkCmd.Run = func(args ...string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		changes, err := playlist.CheckWatched(defaultAccessTokenCtx(a), a.db, a.client, a.config.Market)
+
+		for _, change := range changes {
+			a.runHooks(playlistHookEvent(change))
+		}
+
+		defer a.waitForHooks()
+
+		if p := a.printer(); !p.Text() {
+			records := []output.PlaylistChange{}
+
+			for _, change := range changes {
+				records = append(records, changeRecords(change)...)
+			}
+
+			return errors.Join(output.List(p, records), err)
+		}
+
+		for _, change := range changes {
+			printChange(change)
+		}
+
+		if len(changes) == 0 && err == nil {
+			fmt.Println("no changes")
+		}
+
+		return err
+	}
+
+	var limit int
+	logCmd := NewCliCommand("log", "[playlist]", "show the changes found in watched playlists")
+	logCmd.Flags.IntVar(&limit, "n", 50, "how many changes to show")
+	logCmd.Structured = true
+	logCmd.Run = func(args ...string) error {
+		if len(args) > 1 {
+			return errUsage
+		}
+
+		var ref string
+
+		if len(args) == 1 {
+			ref = args[0]
+		}
+
+		return printChangeLog(defaultAccessTokenCtx(a), a, ref, limit)
+	}
+
+	cmd := NewCliCommand("watch", "", "watch playlists for added and removed tracks")
+
+	return cmd.Add(listCmd, addCmd, removeCmd, checkCmd, logCmd)
+}
@@ -52,6 +52,7 @@ var defaultScopes = []string{
 	"user-read-recently-played",
 	"user-library-modify",
 	"user-library-read",
+	"user-follow-read",
 	"user-read-email",
 	"user-read-private",
 }
@@ -352,6 +353,10 @@ func (u *urlValues) setAfter(after int) {
 	u.v.Set("after", strconv.Itoa(after))
 }
 
+func (u *urlValues) setAfterCursor(after string) {
+	u.v.Set("after", after)
+}
+
 func (u *urlValues) setBefore(before int) {
 	u.v.Set("before", strconv.Itoa(before))
 }
@@ -384,8 +389,20 @@ func (u *urlValues) setUri(uri string) {
 	u.v.Set("uri", uri)
 }
 
+func (u *urlValues) setIds(ids []string) {
+	u.v.Set("ids", strings.Join(ids, ","))
+}
+
+func (u *urlValues) setFields(fields []string) {
+	u.v.Set("fields", strings.Join(fields, ","))
+}
+
 func (u *urlValues) setPercent(percent int) {
-	u.v.Set("percent", strconv.Itoa(percent))
+	u.v.Set("volume_percent", strconv.Itoa(percent))
+}
+
+func (u *urlValues) setPositionMs(ms int) {
+	u.v.Set("position_ms", strconv.Itoa(ms))
 }
 
 func (u *urlValues) setResponseType(t string) {
@@ -984,7 +1001,13 @@ type GetPlaylistParams struct {
 }
 
 func (p GetPlaylistParams) set(u *urlValues) {
-	u.setMarket(p.Market)
+	if p.Market != "" {
+		u.setMarket(p.Market)
+	}
+
+	if len(p.Fields) > 0 {
+		u.setFields(p.Fields)
+	}
 }
 
 func (c *Client) GetPlaylist(ctx context.Context, params GetPlaylistParams) (types.Playlist, error) {
@@ -1033,6 +1056,10 @@ func (c *Client) AddItemsToPlaylist(ctx context.Context, params AddItemsToPlayli
 
 	payload["uris"] = params.Uris
 
+	if params.Position.Valid {
+		payload["position"] = params.Position.Value
+	}
+
 	data, err := json.Marshal(payload)
 
 	if err != nil {
@@ -1054,6 +1081,225 @@ func (c *Client) AddItemsToPlaylist(ctx context.Context, params AddItemsToPlayli
 	return snapshot, nil
 }
 
+// MaxAddItems is the most uris AddItemsToPlaylist accepts per request.
+const MaxAddItems = 100
+
+type ReplacePlaylistItemsParams struct {
+	Id string
+	Uris []string
+}
+
+// ReplacePlaylistItems sets the items of the playlist to at most MaxAddItems
+// uris, an empty list clears it.
+func (c *Client) ReplacePlaylistItems(ctx context.Context, params ReplacePlaylistItemsParams) (types.PlaylistSnapshot, error) {
+	var snapshot types.PlaylistSnapshot
+
+	u, err := createBaseApiUrl("playlists", params.Id, "tracks")
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	uris := params.Uris
+
+	if uris == nil {
+		uris = []string{}
+	}
+
+	data, err := json.Marshal(map[string]any{ "uris": uris })
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	req, err := NewRequestFromContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(data))
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	if err := fetchResponse(c, req, &snapshot); err != nil {
+		return snapshot, err
+	}
+
+	return snapshot, nil
+}
+
+type ReorderPlaylistItemsParams struct {
+	Id string
+	RangeStart int
+	RangeLength int
+	InsertBefore int
+	SnapshotId string
+}
+
+// ReorderPlaylistItems moves RangeLength items starting at RangeStart in front
+// of the item at InsertBefore. Both positions refer to the playlist before
+// the move.
+func (c *Client) ReorderPlaylistItems(ctx context.Context, params ReorderPlaylistItemsParams) (types.PlaylistSnapshot, error) {
+	var snapshot types.PlaylistSnapshot
+
+	u, err := createBaseApiUrl("playlists", params.Id, "tracks")
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	payload := map[string]any{
+		"range_start": params.RangeStart,
+		"insert_before": params.InsertBefore,
+		"range_length": max(params.RangeLength, 1),
+	}
+
+	if params.SnapshotId != "" {
+		payload["snapshot_id"] = params.SnapshotId
+	}
+
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	req, err := NewRequestFromContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(data))
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	if err := fetchResponse(c, req, &snapshot); err != nil {
+		return snapshot, err
+	}
+
+	return snapshot, nil
+}
+
+type RemovePlaylistItemsParams struct {
+	Id string
+	Uris []string
+	SnapshotId string
+}
+
+// RemovePlaylistItems removes every occurrence of the uris, at most
+// MaxAddItems per request.
+func (c *Client) RemovePlaylistItems(ctx context.Context, params RemovePlaylistItemsParams) (types.PlaylistSnapshot, error) {
+	var snapshot types.PlaylistSnapshot
+
+	u, err := createBaseApiUrl("playlists", params.Id, "tracks")
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	tracks := make([]map[string]string, 0, len(params.Uris))
+
+	for _, uri := range params.Uris {
+		tracks = append(tracks, map[string]string{ "uri": uri })
+	}
+
+	payload := map[string]any{
+		"tracks": tracks,
+	}
+
+	if params.SnapshotId != "" {
+		payload["snapshot_id"] = params.SnapshotId
+	}
+
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	req, err := NewRequestFromContext(ctx, http.MethodDelete, u.String(), bytes.NewBuffer(data))
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	if err := fetchResponse(c, req, &snapshot); err != nil {
+		return snapshot, err
+	}
+
+	return snapshot, nil
+}
+
+type CreatePlaylistParams struct {
+	UserId string
+	Name string
+	Description string
+	Public bool
+	Collaborative bool
+}
+
+func (c *Client) CreatePlaylist(ctx context.Context, params CreatePlaylistParams) (types.Playlist, error) {
+	var playlist types.Playlist
+
+	u, err := createBaseApiUrl("users", params.UserId, "playlists")
+
+	if err != nil {
+		return playlist, err
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"name": params.Name,
+		"description": params.Description,
+		"public": params.Public,
+		"collaborative": params.Collaborative,
+	})
+
+	if err != nil {
+		return playlist, err
+	}
+
+	req, err := NewRequestFromContext(ctx, http.MethodPost, u.String(), bytes.NewBuffer(data))
+
+	if err != nil {
+		return playlist, err
+	}
+
+	if err := fetchResponse(c, req, &playlist); err != nil {
+		return playlist, err
+	}
+
+	return playlist, nil
+}
+
+type ChangePlaylistDetailsParams struct {
+	Id string
+	Name string
+	Description string
+	Public bool
+	Collaborative bool
+}
+
+func (c *Client) ChangePlaylistDetails(ctx context.Context, params ChangePlaylistDetailsParams) error {
+	u, err := createBaseApiUrl("playlists", params.Id)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"name": params.Name,
+		"description": params.Description,
+		"public": params.Public,
+		"collaborative": params.Collaborative,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	req, err := NewRequestFromContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(data))
+
+	if err != nil {
+		return err
+	}
+
+	return fetchResponse(c, req, nil)
+}
+
 func (c *Client) GetPlaylistItems(accessToken string, playlistId string) (types.Page[types.PlaylistItemUnion], error) {
 	var page types.Page[types.PlaylistItemUnion]
 
@@ -1082,6 +1328,189 @@ func (c *Client) GetPlaylistItems(accessToken string, playlistId string) (types.
 	return page, nil
 }
 
+type GetPlaylistItemsParams struct {
+	Id string
+	Market string
+	Limit int
+	Offset int
+}
+
+func (p GetPlaylistItemsParams) set(u *urlValues) {
+	if p.Market != "" {
+		u.setMarket(p.Market)
+	}
+
+	if p.Limit != 0 {
+		u.setLimit(p.Limit)
+	}
+
+	u.setOffset(p.Offset)
+}
+
+func (c *Client) GetPlaylistItemsPage(ctx context.Context, params GetPlaylistItemsParams) (types.Page[types.PlaylistItemUnion], error) {
+	var page types.Page[types.PlaylistItemUnion]
+
+	u, err := createBaseApiUrl("playlists", params.Id, "tracks")
+
+	if err != nil {
+		return page, err
+	}
+
+	setAndEncodeUrl(u, params)
+
+	req, err := NewRequestFromContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return page, err
+	}
+
+	if err := fetchResponse(c, req, &page); err != nil {
+		return page, err
+	}
+
+	return page, nil
+}
+
+const maxPlaylistItemsLimit = 100
+
+// GetAllPlaylistItems follows the pagination of the playlist items endpoint
+// and returns every item in the playlist. The market decides which tracks are
+// playable, "from_token" for the country of the user.
+func (c *Client) GetAllPlaylistItems(ctx context.Context, id string, market string) ([]types.PlaylistItemUnion, error) {
+	var items []types.PlaylistItemUnion
+
+	params := GetPlaylistItemsParams{
+		Id: id,
+		Market: market,
+		Limit: maxPlaylistItemsLimit,
+	}
+
+	for {
+		page, err := c.GetPlaylistItemsPage(ctx, params)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+
+		if !page.Next.Valid || len(page.Items) == 0 {
+			break
+		}
+
+		params.Offset += len(page.Items)
+	}
+
+	return items, nil
+}
+
+const maxPlaylistsLimit = 50
+
+func (c *Client) GetAllCurrentUsersPlaylists(ctx context.Context) ([]types.SimplifiedPlaylistObject, error) {
+	var playlists []types.SimplifiedPlaylistObject
+
+	var offset int
+
+	for {
+		page, err := c.GetCurrentUsersPlaylists(ctx, maxPlaylistsLimit, offset)
+
+		if err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, page.Items...)
+
+		if page.Next == "" || len(page.Items) == 0 {
+			break
+		}
+
+		offset += len(page.Items)
+	}
+
+	return playlists, nil
+}
+
+// MaxSeveralArtists is the most ids GetSeveralArtists accepts per request.
+const MaxSeveralArtists = 50
+
+type GetSeveralArtistsParams struct {
+	Ids []string
+}
+
+func (p GetSeveralArtistsParams) set(u *urlValues) {
+	u.setIds(p.Ids)
+}
+
+func (c *Client) GetSeveralArtists(ctx context.Context, params GetSeveralArtistsParams) ([]types.Artist, error) {
+	var result struct {
+		Artists []types.Artist `json:"artists"`
+	}
+
+	u, err := createBaseApiUrl("artists")
+
+	if err != nil {
+		return nil, err
+	}
+
+	setAndEncodeUrl(u, params)
+
+	req, err := NewRequestFromContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fetchResponse(c, req, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Artists, nil
+}
+
+// MaxSeveralTracks is the most ids GetSeveralTracks accepts per request.
+const MaxSeveralTracks = 50
+
+type GetSeveralTracksParams struct {
+	Ids []string
+	Market string
+}
+
+func (p GetSeveralTracksParams) set(u *urlValues) {
+	u.setIds(p.Ids)
+
+	if p.Market != "" {
+		u.setMarket(p.Market)
+	}
+}
+
+// GetSeveralTracks looks up to MaxSeveralTracks tracks by id. Ids that do
+// not exist come back as tracks with an empty uri.
+func (c *Client) GetSeveralTracks(ctx context.Context, params GetSeveralTracksParams) ([]types.Track, error) {
+	var result struct {
+		Tracks []types.Track `json:"tracks"`
+	}
+
+	u, err := createBaseApiUrl("tracks")
+
+	if err != nil {
+		return nil, err
+	}
+
+	setAndEncodeUrl(u, params)
+
+	req, err := NewRequestFromContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fetchResponse(c, req, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Tracks, nil
+}
+
 type AddItemToQueueParams struct {
 	Uri string
 	DeviceId string
@@ -1216,28 +1645,89 @@ func encodeUrl(u *url.URL, values url.Values) {
 }
 
 
-func (c *Client) GetUsersSavedTracks(ctx context.Context) (types.Page[types.SavedTrack], error) {
+type SavedItemsParams struct {
+	Limit int
+	Offset int
+	Market string
+}
+
+func (p SavedItemsParams) set(u *urlValues) {
+	if p.Limit != 0 {
+		u.setLimit(p.Limit)
+	}
+
+	u.setOffset(p.Offset)
+
+	if p.Market != "" {
+		u.setMarket(p.Market)
+	}
+}
+
+func (c *Client) GetUsersSavedTracks(ctx context.Context, params SavedItemsParams) (types.Page[types.SavedTrack], error) {
 	var page types.Page[types.SavedTrack]
 
-	accessToken, err := GetAccessToken(ctx)
+	u, err := createUsersTracksUrl()
 
 	if err != nil {
-		return page, ErrAccessTokenNotFound
+		return page, err
 	}
 
-	u, err := createUsersTracksUrl()
+	setAndEncodeUrl(u, params)
+
+	req, err := NewRequestFromContext(ctx, http.MethodGet, u.String(), nil)
 
 	if err != nil {
 		return page, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err := fetchResponse(c, req, &page); err != nil {
+		return page, err
+	}
+
+	return page, nil
+}
+
+// SaveTracks adds the tracks with ids to the liked songs of the user.
+func (c *Client) SaveTracks(ctx context.Context, ids []string) error {
+	u, err := createUsersTracksUrl()
+
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(map[string]any{ "ids": ids })
+
+	if err != nil {
+		return err
+	}
+
+	req, err := NewRequestFromContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(data))
+
+	if err != nil {
+		return err
+	}
+
+	setContentTypeHeader(req, "application/json")
+
+	return fetchResponse(c, req, nil)
+}
+
+func (c *Client) GetUsersSavedAlbums(ctx context.Context, params SavedItemsParams) (types.Page[types.SavedAlbum], error) {
+	var page types.Page[types.SavedAlbum]
+
+	u, err := createApiUrl("albums")
 
 	if err != nil {
 		return page, err
 	}
 
-	setAuthorizationHeader(req, accessToken)
+	setAndEncodeUrl(u, params)
+
+	req, err := NewRequestFromContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return page, err
+	}
 
 	if err := fetchResponse(c, req, &page); err != nil {
 		return page, err
@@ -1246,6 +1736,47 @@ func (c *Client) GetUsersSavedTracks(ctx context.Context) (types.Page[types.Save
 	return page, nil
 }
 
+type FollowedArtistsParams struct {
+	After string
+	Limit int
+}
+
+func (p FollowedArtistsParams) set(u *urlValues) {
+	u.v.Set("type", "artist")
+
+	if p.Limit != 0 {
+		u.setLimit(p.Limit)
+	}
+
+	if p.After != "" {
+		u.setAfterCursor(p.After)
+	}
+}
+
+func (c *Client) GetFollowedArtists(ctx context.Context, params FollowedArtistsParams) (types.FollowedArtists, error) {
+	var artists types.FollowedArtists
+
+	u, err := createApiUrl("following")
+
+	if err != nil {
+		return artists, err
+	}
+
+	setAndEncodeUrl(u, params)
+
+	req, err := NewRequestFromContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return artists, err
+	}
+
+	if err := fetchResponse(c, req, &artists); err != nil {
+		return artists, err
+	}
+
+	return artists, nil
+}
+
 type RecentlyPlayedTracksParams struct {
 	Limit int
 	After int
@@ -1294,6 +1825,66 @@ func (c *Client) GetRecentlyPlayedTracks(ctx context.Context, params RecentlyPla
 
 }
 
+type SeekToPositionParams struct {
+	PositionMs int
+	DeviceId string
+}
+
+func (p SeekToPositionParams) set(u *urlValues) {
+	u.setPositionMs(p.PositionMs)
+	if p.DeviceId != "" {
+		u.setDeviceId(p.DeviceId)
+	}
+}
+
+func (c *Client) SeekToPosition(ctx context.Context, params SeekToPositionParams) error {
+	u, err := createPlaybackUrl("seek")
+
+	if err != nil {
+		return err
+	}
+
+	setAndEncodeUrl(u, params)
+
+	req, err := NewRequestFromContext(ctx, "PUT", u.String(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	return fetchResponse(c, req, nil)
+}
+
+type TogglePlaybackShuffleParams struct {
+	State bool
+	DeviceId string
+}
+
+func (p TogglePlaybackShuffleParams) set(u *urlValues) {
+	u.setState(strconv.FormatBool(p.State))
+	if p.DeviceId != "" {
+		u.setDeviceId(p.DeviceId)
+	}
+}
+
+func (c *Client) TogglePlaybackShuffle(ctx context.Context, params TogglePlaybackShuffleParams) error {
+	u, err := createPlaybackUrl("shuffle")
+
+	if err != nil {
+		return err
+	}
+
+	setAndEncodeUrl(u, params)
+
+	req, err := NewRequestFromContext(ctx, "PUT", u.String(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	return fetchResponse(c, req, nil)
+}
+
 type SetRepeatModeParams struct {
 	State string // required
 	DeviceId string
@@ -1377,6 +1968,18 @@ func (p GetSearchResultsParams) set(u *urlValues) {
 	} else {
 		u.setType(p.Type)
 	}
+
+	if p.Market != "" {
+		u.setMarket(p.Market)
+	}
+
+	if p.Limit > 0 {
+		u.setLimit(p.Limit)
+	}
+
+	if p.Offset > 0 {
+		u.setOffset(p.Offset)
+	}
 }
 
 func (c *Client) GetSearchResults(ctx context.Context, params GetSearchResultsParams) (types.SearchResult, error) {
@@ -1470,7 +2073,8 @@ func handleResponse(resp *http.Response, v any) error {
 }
 
 func checkResponseCode(resp *http.Response) error {
-	if resp.StatusCode == http.StatusOK {
+	// creating a playlist or adding items to one answers with 201
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
 		return nil
 	}
 
@@ -2,68 +2,83 @@ package main
 
 import (
 	"github.com/arjunmoola/go-spotify/app"
+	"github.com/arjunmoola/go-spotify/config"
 	"github.com/arjunmoola/go-spotify/utils"
 
-	"log"
+	"fmt"
+	"io"
 	"os"
 )
 
-func runCli(commands *app.CliCommands) error {
-	cmd := os.Args[1]
-	var args []string
+func run() int {
+	opts, args, err := app.ParseCliOptions(os.Args[1:])
 
-	if len(os.Args) > 2 {
-		args = os.Args[2:]
-	}
-
-	if err := commands.Run(cmd, args...); err != nil {
-		return err
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gsp: %v\nrun gsp help for usage\n", err)
+		return app.ExitCode(err)
 	}
 
-	return nil
-
-}
+	utils.SetProfile(opts.Profile)
 
-func main() {
 	if err := utils.InitializeConfigDir(); err != nil {
-		log.Fatal(err)
+		fmt.Fprintf(os.Stderr, "gsp: %v\n", err)
+		return app.ExitFailure
 	}
 
 	logFile, err := utils.OpenLogFile()
 
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintf(os.Stderr, "gsp: %v\n", err)
+		return app.ExitFailure
 	}
 
 	defer logFile.Close()
 
-	logger := utils.NewLogger(logFile)
+	var logOutput io.Writer = logFile
+
+	if opts.Verbose {
+		logOutput = io.MultiWriter(logFile, os.Stderr)
+	}
+
+	logger := utils.NewLogger(logOutput)
 	app.SetupLogger(logger)
 
 	db, err := utils.InitializeDB()
 
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintf(os.Stderr, "gsp: %v\n", err)
+		return app.ExitFailure
 	}
 
+	defer db.Close()
+
 	a := app.New(db)
+	a.SetCliOptions(opts)
 
-	cli := app.NewCliCommands(a)
+	configPath := opts.ConfigPath
 
-	if len(os.Args) == 1 {
+	if configPath == "" {
+		configPath = config.Path()
+	}
+
+	// a broken config file should not lock the user out of gsp config
+	if err := a.LoadConfig(configPath); err != nil && !(len(args) > 0 && (args[0] == "config" || args[0] == "help")) {
+		fmt.Fprintf(os.Stderr, "gsp: %s: %v\n", configPath, err)
+		return app.ExitConfig
+	}
+
+	if len(args) == 0 {
 		if err := a.Run(); err != nil {
-			log.Fatal(err)
-		}
-	} else {
-		if err := a.SetupCli(); err != nil {
-			log.Fatal(err)
+			fmt.Fprintf(os.Stderr, "gsp: %v\n", err)
+			return app.ExitFailure
 		}
 
-		if err := runCli(cli); err != nil {
-			log.Fatal(err)
-		}
+		return app.ExitOK
 	}
 
+	return app.NewCliCommands(a).Execute(args)
+}
 
-
+func main() {
+	os.Exit(run())
 }
@@ -0,0 +1,518 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/arjunmoola/go-spotify/poller"
+	"github.com/arjunmoola/go-spotify/utils"
+)
+
+const fileName = "config.toml"
+
+// Path is where the config file lives, next to the database and the log.
+func Path() string {
+	return filepath.Join(utils.ConfigDir(), fileName)
+}
+
+type Config struct {
+	Market string `toml:"market"`
+	StartupView string `toml:"startup_view"`
+	Theme Theme `toml:"theme"`
+	Intervals Intervals `toml:"intervals"`
+	PageSizes PageSizes `toml:"page_sizes"`
+	Export Export `toml:"export"`
+	Status Status `toml:"status"`
+	API API `toml:"api"`
+	MPRIS MPRIS `toml:"mpris"`
+	Notifications Notifications `toml:"notifications"`
+	Hooks Hooks `toml:"hooks"`
+	Keys Keys `toml:"keys"`
+}
+
+type Theme struct {
+	Accent string `toml:"accent"`
+	Error string `toml:"error"`
+	Link string `toml:"link"`
+}
+
+type Intervals struct {
+	Playback Duration `toml:"playback"`
+	// PlaybackPaused is how often the playback state is polled while paused
+	// or nothing plays
+	PlaybackPaused Duration `toml:"playback_paused"`
+	Sync Duration `toml:"sync"`
+	Reload Duration `toml:"reload"`
+	// Watch is how often watched playlists are checked for changes
+	Watch Duration `toml:"watch"`
+}
+
+type PageSizes struct {
+	RecentlyPlayed int `toml:"recently_played"`
+	CurrentSession int `toml:"current_session"`
+	Playlists int `toml:"playlists"`
+	Search int `toml:"search"`
+}
+
+// Export configures playlist exports started from the tui. An empty
+// directory means the exports directory next to the config file.
+type Export struct {
+	Format string `toml:"format"`
+	Directory string `toml:"directory"`
+}
+
+// ExportDir returns the directory exports are written to.
+func (c Config) ExportDir() string {
+	if c.Export.Directory != "" {
+		return c.Export.Directory
+	}
+
+	return filepath.Join(utils.ConfigDir(), "exports")
+}
+
+// Status configures gsp status, the line shown in tmux and status bars.
+// Format is a go template, see gsp help status for its fields.
+type Status struct {
+	Format string `toml:"format"`
+	// Width truncates the line to this many columns, 0 for no limit
+	Width int `toml:"width"`
+	// CacheFor is how long a fetched playback state is reused, so a status
+	// bar running gsp status every second does not call spotify every time
+	CacheFor Duration `toml:"cache_for"`
+	Playing string `toml:"playing"`
+	Paused string `toml:"paused"`
+	Stopped string `toml:"stopped"`
+	Shuffle string `toml:"shuffle"`
+	Repeat string `toml:"repeat"`
+	RepeatOne string `toml:"repeat_one"`
+}
+
+// API configures the http api gsp daemon serves for stream decks, home
+// automation and web widgets.
+type API struct {
+	// Address is the host:port to listen on, empty to not serve the api
+	Address string `toml:"address"`
+	// Token is what clients authorize with. When empty gsp daemon generates
+	// one and keeps it in api_token next to the config file
+	Token string `toml:"token"`
+	// AllowOrigin lets web pages from this origin call the api, "*" for any
+	AllowOrigin string `toml:"allow_origin"`
+}
+
+// MPRIS makes gsp daemon a media player on the session bus, so media keys
+// and desktop widgets control spotify.
+type MPRIS struct {
+	Enabled bool `toml:"enabled"`
+}
+
+// Notifications shows a desktop notification when the track changes. It is
+// never shown over ssh.
+type Notifications struct {
+	Enabled bool `toml:"enabled"`
+	// MinInterval is the least time between two notifications, a burst of
+	// skips only shows the track it ended on
+	MinInterval Duration `toml:"min_interval"`
+	// Timeout is how long a notification stays, 0 for the default of the
+	// desktop
+	Timeout Duration `toml:"timeout"`
+	// Actions adds skip and like buttons to the notification
+	Actions bool `toml:"actions"`
+}
+
+// Hooks are commands run when something happens, e.g. the track changes.
+// They get the event as json on stdin and in GSP_ environment variables.
+// While gsp daemon runs it runs the hooks, and failed hooks are reported in
+// its output rather than in the tui.
+type Hooks struct {
+	// Timeout is how long a hook may run before it is killed
+	Timeout Duration `toml:"timeout"`
+	// Concurrency is how many hooks may run at the same time
+	Concurrency int `toml:"concurrency"`
+	Commands []Hook `toml:"command"`
+}
+
+// Hook runs either Run through sh -c or Exec directly on Events, which are
+// the events of gsp watch or playlist_modified.
+type Hook struct {
+	Events []string `toml:"events"`
+	Run string `toml:"run"`
+	Exec []string `toml:"exec"`
+}
+
+// PlaylistModified is the hook event for a change found in a watched
+// playlist.
+const PlaylistModified = "playlist_modified"
+
+func hookEvents() []string {
+	events := make([]string, 0, len(poller.Kinds)+1)
+
+	for _, kind := range poller.Kinds {
+		events = append(events, string(kind))
+	}
+
+	return append(events, PlaylistModified)
+}
+
+// Command is what the hook runs, for messages.
+func (h Hook) Command() string {
+	if h.Run != "" {
+		return h.Run
+	}
+
+	return strings.Join(h.Exec, " ")
+}
+
+func (h Hook) String() string {
+	return strings.Join(h.Events, ",") + ": " + h.Command()
+}
+
+// Keys maps every action of the tui to the keys that trigger it. Keys are
+// written the way bubbletea reports them, e.g. "p", "A" or "ctrl+r".
+type Keys struct {
+	PlayPause []string `toml:"play_pause"`
+	Next []string `toml:"next"`
+	Previous []string `toml:"previous"`
+	Queue []string `toml:"queue"`
+	AddToPlaylist []string `toml:"add_to_playlist"`
+	AddPlaying []string `toml:"add_playing"`
+	Collapse []string `toml:"collapse"`
+	Refresh []string `toml:"refresh"`
+	Search []string `toml:"search"`
+	Command []string `toml:"command"`
+	Quit []string `toml:"quit"`
+	Export []string `toml:"export"`
+	EditPlaylist []string `toml:"edit_playlist"`
+	Dedupe []string `toml:"dedupe"`
+	// SaveQueue saves the queue as a playlist, or the session when the
+	// current session is shown
+	SaveQueue []string `toml:"save_queue"`
+}
+
+// Duration is a time.Duration written as a string like "1s" or "15m".
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+
+	if err != nil {
+		return fmt.Errorf("invalid duration %q, use values like \"500ms\", \"1s\" or \"15m\"", text)
+	}
+
+	d.Duration = v
+
+	return nil
+}
+
+const (
+	StartupNone = "none"
+	StartupRecentlyPlayed = "recently_played"
+	StartupTopTracks = "top_tracks"
+	StartupLikedSongs = "liked_songs"
+)
+
+var startupViews = []string{ StartupNone, StartupRecentlyPlayed, StartupTopTracks, StartupLikedSongs }
+
+var exportFormats = []string{ "csv", "json", "m3u", "xspf" }
+
+// reservedKeys are used for navigation and text input and cannot be bound.
+var reservedKeys = []string{ "esc", "enter", "up", "down", "left", "right", "tab", "j", "k", "h", "l" }
+
+func Default() Config {
+	return Config{
+		Market: "US",
+		StartupView: StartupNone,
+		Theme: Theme{
+			Accent: "200",
+			Error: "9",
+			Link: "10",
+		},
+		Intervals: Intervals{
+			Playback: Duration{ time.Second },
+			PlaybackPaused: Duration{ 5*time.Second },
+			Sync: Duration{ 0 },
+			Reload: Duration{ 2*time.Second },
+			Watch: Duration{ 5*time.Minute },
+		},
+		PageSizes: PageSizes{
+			RecentlyPlayed: 30,
+			CurrentSession: 10,
+			Playlists: 10,
+			Search: 20,
+		},
+		Export: Export{
+			Format: "csv",
+		},
+		Status: Status{
+			Format: "{{.Icon}} {{.Artist}} - {{.Title}}",
+			CacheFor: Duration{ 5*time.Second },
+			Playing: "▶",
+			Paused: "⏸",
+			Stopped: "■",
+			Shuffle: "🔀",
+			Repeat: "🔁",
+			RepeatOne: "🔂",
+		},
+		Notifications: Notifications{
+			MinInterval: Duration{ 3*time.Second },
+			Actions: true,
+		},
+		Hooks: Hooks{
+			Timeout: Duration{ 10*time.Second },
+			Concurrency: 4,
+		},
+		Keys: Keys{
+			PlayPause: []string{ "p" },
+			Next: []string{ "n" },
+			Previous: []string{ "b" },
+			Queue: []string{ "a" },
+			AddToPlaylist: []string{ "A" },
+			AddPlaying: []string{ "C" },
+			Collapse: []string{ "c" },
+			Refresh: []string{ "ctrl+r" },
+			Search: []string{ "/" },
+			Command: []string{ ":" },
+			Quit: []string{ "ctrl+c" },
+			Export: []string{ "e" },
+			EditPlaylist: []string{ "E" },
+			Dedupe: []string{ "D" },
+			SaveQueue: []string{ "S" },
+		},
+	}
+}
+
+// Load reads the config file at path on top of the defaults, so the file only
+// needs to contain the settings that differ. A missing file is not an error.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+
+	if err != nil {
+		return cfg, err
+	}
+
+	return Parse(data, path)
+}
+
+func Parse(data []byte, name string) (Config, error) {
+	cfg := Default()
+
+	md, err := toml.Decode(string(data), &cfg)
+
+	if err != nil {
+		var perr toml.ParseError
+
+		if errors.As(err, &perr) {
+			return cfg, fmt.Errorf("%s:%d: %s", name, perr.Position.Line, perr.Message)
+		}
+
+		return cfg, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+
+		return cfg, fmt.Errorf("%s: unknown settings %s, see gsp config get for the available ones", name, strings.Join(keys, ", "))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return cfg, nil
+}
+
+var (
+	marketPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+)
+
+func validColor(c string) bool {
+	if hexColorPattern.MatchString(c) {
+		return true
+	}
+
+	n, err := strconv.Atoi(c)
+
+	return err == nil && n >= 0 && n <= 255
+}
+
+// Validate reports every invalid setting at once instead of stopping at the
+// first one.
+func (c Config) Validate() error {
+	var errs []error
+
+	if !marketPattern.MatchString(c.Market) {
+		errs = append(errs, fmt.Errorf("market: %q is not an ISO 3166-1 alpha-2 country code like \"US\"", c.Market))
+	}
+
+	if !slices.Contains(startupViews, c.StartupView) {
+		errs = append(errs, fmt.Errorf("startup_view: %q must be one of %s", c.StartupView, strings.Join(startupViews, ", ")))
+	}
+
+	colors := []struct{ key, value string }{
+		{ "theme.accent", c.Theme.Accent },
+		{ "theme.error", c.Theme.Error },
+		{ "theme.link", c.Theme.Link },
+	}
+
+	for _, color := range colors {
+		if !validColor(color.value) {
+			errs = append(errs, fmt.Errorf("%s: %q must be an ansi color between 0 and 255 or a hex color like \"#ff00aa\"", color.key, color.value))
+		}
+	}
+
+	if c.Intervals.Playback.Duration < 500*time.Millisecond {
+		errs = append(errs, fmt.Errorf("intervals.playback: must be at least 500ms, got %s", c.Intervals.Playback))
+	}
+
+	if c.Intervals.PlaybackPaused.Duration < c.Intervals.Playback.Duration {
+		errs = append(errs, fmt.Errorf("intervals.playback_paused: must be at least intervals.playback (%s), got %s", c.Intervals.Playback, c.Intervals.PlaybackPaused))
+	}
+
+	if c.Intervals.Sync.Duration != 0 && c.Intervals.Sync.Duration < time.Minute {
+		errs = append(errs, fmt.Errorf("intervals.sync: must be 0 to only sync on startup or at least 1m, got %s", c.Intervals.Sync))
+	}
+
+	if c.Intervals.Watch.Duration != 0 && c.Intervals.Watch.Duration < time.Minute {
+		errs = append(errs, fmt.Errorf("intervals.watch: must be 0 to not check watched playlists or at least 1m, got %s", c.Intervals.Watch))
+	}
+
+	if c.Intervals.Reload.Duration < 500*time.Millisecond {
+		errs = append(errs, fmt.Errorf("intervals.reload: must be at least 500ms, got %s", c.Intervals.Reload))
+	}
+
+	pageSizes := []struct{ key string; value int }{
+		{ "page_sizes.recently_played", c.PageSizes.RecentlyPlayed },
+		{ "page_sizes.current_session", c.PageSizes.CurrentSession },
+		{ "page_sizes.playlists", c.PageSizes.Playlists },
+		{ "page_sizes.search", c.PageSizes.Search },
+	}
+
+	for _, size := range pageSizes {
+		if size.value < 1 || size.value > 50 {
+			errs = append(errs, fmt.Errorf("%s: must be between 1 and 50, got %d", size.key, size.value))
+		}
+	}
+
+	if !slices.Contains(exportFormats, c.Export.Format) {
+		errs = append(errs, fmt.Errorf("export.format: %q must be one of %s", c.Export.Format, strings.Join(exportFormats, ", ")))
+	}
+
+	if c.Status.Format == "" {
+		errs = append(errs, fmt.Errorf("status.format: must not be empty"))
+	}
+
+	if c.Status.Width < 0 {
+		errs = append(errs, fmt.Errorf("status.width: must be 0 for no limit or more, got %d", c.Status.Width))
+	}
+
+	if c.Status.CacheFor.Duration < 0 || c.Status.CacheFor.Duration > time.Minute {
+		errs = append(errs, fmt.Errorf("status.cache_for: must be between 0 and 1m, got %s", c.Status.CacheFor))
+	}
+
+	if c.API.Address != "" {
+		if _, _, err := net.SplitHostPort(c.API.Address); err != nil {
+			errs = append(errs, fmt.Errorf("api.address: %q must be host:port like \"127.0.0.1:8765\"", c.API.Address))
+		}
+	}
+
+	if c.API.Token != "" && len(c.API.Token) < 16 {
+		errs = append(errs, fmt.Errorf("api.token: must be at least 16 characters or empty to generate one"))
+	}
+
+	if c.Notifications.MinInterval.Duration < 0 || c.Notifications.MinInterval.Duration > time.Minute {
+		errs = append(errs, fmt.Errorf("notifications.min_interval: must be between 0 and 1m, got %s", c.Notifications.MinInterval))
+	}
+
+	if c.Notifications.Timeout.Duration < 0 || c.Notifications.Timeout.Duration > time.Minute {
+		errs = append(errs, fmt.Errorf("notifications.timeout: must be 0 for the default of the desktop or up to 1m, got %s", c.Notifications.Timeout))
+	}
+
+	if c.Hooks.Timeout.Duration < time.Second || c.Hooks.Timeout.Duration > 10*time.Minute {
+		errs = append(errs, fmt.Errorf("hooks.timeout: must be between 1s and 10m, got %s", c.Hooks.Timeout))
+	}
+
+	if c.Hooks.Concurrency < 1 || c.Hooks.Concurrency > 32 {
+		errs = append(errs, fmt.Errorf("hooks.concurrency: must be between 1 and 32, got %d", c.Hooks.Concurrency))
+	}
+
+	for i, hook := range c.Hooks.Commands {
+		if (hook.Run == "") == (len(hook.Exec) == 0) {
+			errs = append(errs, fmt.Errorf("hooks.command[%d]: set either run or exec", i))
+		}
+
+		if len(hook.Events) == 0 {
+			errs = append(errs, fmt.Errorf("hooks.command[%d]: at least one event is required", i))
+		}
+
+		for _, event := range hook.Events {
+			if !slices.Contains(hookEvents(), event) {
+				errs = append(errs, fmt.Errorf("hooks.command[%d]: unknown event %q, use one of %s", i, event, strings.Join(hookEvents(), ", ")))
+			}
+		}
+	}
+
+	bound := make(map[string]string)
+
+	for _, binding := range c.Keys.bindings() {
+		if len(binding.keys) == 0 {
+			errs = append(errs, fmt.Errorf("keys.%s: at least one key is required", binding.action))
+		}
+
+		for _, key := range binding.keys {
+			if slices.Contains(reservedKeys, key) {
+				errs = append(errs, fmt.Errorf("keys.%s: %q is reserved for navigation", binding.action, key))
+				continue
+			}
+
+			if other, ok := bound[key]; ok {
+				errs = append(errs, fmt.Errorf("keys.%s: %q is already bound to %s", binding.action, key, other))
+				continue
+			}
+
+			bound[key] = binding.action
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Save writes the complete config to path.
+func (c Config) Save(path string) error {
+	var buf bytes.Buffer
+
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
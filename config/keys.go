@@ -0,0 +1,74 @@
+package config
+
+// Actions of the tui that can be rebound in the [keys] table. The names are
+// never valid key strings so a lookup result can be compared against both.
+const (
+	ActionPlayPause = "play_pause"
+	ActionNext = "next"
+	ActionPrevious = "previous"
+	ActionQueue = "queue"
+	ActionAddToPlaylist = "add_to_playlist"
+	ActionAddPlaying = "add_playing"
+	ActionCollapse = "collapse"
+	ActionRefresh = "refresh"
+	ActionSearch = "search"
+	ActionCommand = "command"
+	ActionQuit = "quit"
+	ActionExport = "export"
+	ActionEditPlaylist = "edit_playlist"
+	ActionDedupe = "dedupe"
+	ActionSaveQueue = "save_queue"
+)
+
+type binding struct {
+	action string
+	keys []string
+}
+
+func (k Keys) bindings() []binding {
+	return []binding{
+		{ ActionPlayPause, k.PlayPause },
+		{ ActionNext, k.Next },
+		{ ActionPrevious, k.Previous },
+		{ ActionQueue, k.Queue },
+		{ ActionAddToPlaylist, k.AddToPlaylist },
+		{ ActionAddPlaying, k.AddPlaying },
+		{ ActionCollapse, k.Collapse },
+		{ ActionRefresh, k.Refresh },
+		{ ActionSearch, k.Search },
+		{ ActionCommand, k.Command },
+		{ ActionQuit, k.Quit },
+		{ ActionExport, k.Export },
+		{ ActionEditPlaylist, k.EditPlaylist },
+		{ ActionDedupe, k.Dedupe },
+		{ ActionSaveQueue, k.SaveQueue },
+	}
+}
+
+// Keymap resolves pressed keys to actions.
+type Keymap map[string]string
+
+func (k Keys) Keymap() Keymap {
+	keymap := make(Keymap)
+
+	for _, binding := range k.bindings() {
+		for _, key := range binding.keys {
+			// bubbletea reports the space bar as a literal space
+			if key == "space" {
+				key = " "
+			}
+			keymap[key] = binding.action
+		}
+	}
+
+	return keymap
+}
+
+// Lookup returns the action bound to key, or key itself when it is unbound.
+func (k Keymap) Lookup(key string) string {
+	if action, ok := k[key]; ok {
+		return action
+	}
+
+	return key
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Setting is a single leaf of the config addressed by its dotted toml key,
+// e.g. "theme.accent" or "keys.play_pause".
+type Setting struct {
+	Key string
+	value reflect.Value
+}
+
+// String formats the value the way Set accepts it.
+func (s Setting) String() string {
+	switch v := s.value.Interface().(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	return fmt.Sprint(s.value.Interface())
+}
+
+func (s Setting) set(text string) error {
+	if u, ok := s.value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(text))
+	}
+
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(text)
+	case reflect.Int:
+		n, err := strconv.Atoi(text)
+
+		if err != nil {
+			return fmt.Errorf("%q is not a number", text)
+		}
+
+		s.value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", text)
+		}
+
+		s.value.SetBool(b)
+	case reflect.Slice:
+		if s.value.Type() != reflect.TypeOf([]string{}) {
+			return fmt.Errorf("can only be changed in the config file")
+		}
+
+		var keys []string
+
+		for _, key := range strings.Split(text, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+
+		s.value.Set(reflect.ValueOf(keys))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+
+	return nil
+}
+
+// Settings lists every setting of c in file order.
+func (c *Config) Settings() []Setting {
+	return collect("", reflect.ValueOf(c).Elem())
+}
+
+func collect(prefix string, v reflect.Value) []Setting {
+	var settings []Setting
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key := prefix + field.Tag.Get("toml")
+		value := v.Field(i)
+
+		if value.Kind() == reflect.Struct && field.Type != reflect.TypeOf(Duration{}) {
+			settings = append(settings, collect(key + ".", value)...)
+			continue
+		}
+
+		settings = append(settings, Setting{ Key: key, value: value })
+	}
+
+	return settings
+}
+
+func (c *Config) lookup(key string) (Setting, error) {
+	var prefixed []string
+
+	for _, setting := range c.Settings() {
+		if setting.Key == key {
+			return setting, nil
+		}
+
+		if strings.HasPrefix(setting.Key, key + ".") {
+			prefixed = append(prefixed, setting.Key)
+		}
+	}
+
+	if len(prefixed) > 0 {
+		return Setting{}, fmt.Errorf("%s is a table, use one of %s", key, strings.Join(prefixed, ", "))
+	}
+
+	return Setting{}, fmt.Errorf("unknown setting %s", key)
+}
+
+func (c *Config) Get(key string) (string, error) {
+	setting, err := c.lookup(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	return setting.String(), nil
+}
+
+// Set parses text into the setting named by key and validates the result.
+// Lists like key bindings are written comma separated.
+func (c *Config) Set(key string, text string) error {
+	setting, err := c.lookup(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := setting.set(text); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	return c.Validate()
+}
@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// dialTimeout is short because the cli falls back to calling spotify
+// itself when no daemon answers.
+const dialTimeout = 200*time.Millisecond
+
+// Client calls a running daemon. It is safe for concurrent use, calls are
+// sent one at a time.
+type Client struct {
+	mu sync.Mutex
+	conn net.Conn
+	reader *bufio.Reader
+	nextId int64
+}
+
+func Dial(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn: conn,
+		reader: bufio.NewReader(conn),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call sends method with params and decodes the result into result, which
+// may be nil.
+func (c *Client) Call(ctx context.Context, method string, params any, result any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextId++
+
+	req := Request{
+		JSONRPC: version,
+		Id: c.nextId,
+		Method: method,
+	}
+
+	if params != nil {
+		data, err := json.Marshal(params)
+
+		if err != nil {
+			return err
+		}
+
+		req.Params = data
+	}
+
+	deadline, ok := ctx.Deadline()
+
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(req)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	line, err := c.reader.ReadBytes('\n')
+
+	if err != nil {
+		return err
+	}
+
+	var resp Response
+
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return err
+	}
+
+	if resp.Id != req.Id {
+		return fmt.Errorf("daemon answered request %d instead of %d", resp.Id, req.Id)
+	}
+
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(resp.Result, result)
+}
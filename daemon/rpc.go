@@ -0,0 +1,56 @@
+// Package daemon is the json-rpc 2.0 protocol gsp daemon speaks over a unix
+// socket. Requests and responses are single json objects, one per line.
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+const socketName = "gsp.sock"
+
+// SocketPath is where the daemon of the profile in dir listens.
+func SocketPath(dir string) string {
+	return filepath.Join(dir, socketName)
+}
+
+const version = "2.0"
+
+// Error codes from the json-rpc 2.0 spec. Errors of the methods themselves
+// use CodeFailed.
+const (
+	CodeParse = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams = -32602
+	CodeFailed = -32000
+)
+
+type Request struct {
+	JSONRPC string `json:"jsonrpc"`
+	Id int64 `json:"id"`
+	Method string `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type Response struct {
+	JSONRPC string `json:"jsonrpc"`
+	Id int64 `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error *Error `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code int `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("daemon: %s", e.Message)
+}
+
+// InvalidParams is returned by handlers that cannot decode their params.
+func InvalidParams(err error) error {
+	return &Error{ Code: CodeInvalidParams, Message: err.Error() }
+}
@@ -0,0 +1,164 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// HandlerFunc answers a single method. The result is encoded as json.
+type HandlerFunc func(ctx context.Context, params json.RawMessage) (any, error)
+
+type Server struct {
+	handlers map[string]HandlerFunc
+	logger *slog.Logger
+}
+
+func NewServer(logger *slog.Logger) *Server {
+	return &Server{
+		handlers: make(map[string]HandlerFunc),
+		logger: logger,
+	}
+}
+
+func (s *Server) Handle(method string, h HandlerFunc) {
+	s.handlers[method] = h
+}
+
+// Serve listens on path until ctx is done. A socket left behind by a daemon
+// that died is replaced, a live one is an error.
+func (s *Server) Serve(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return fmt.Errorf("a daemon is already listening on %s", path)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(path)
+
+	// only the user may talk to the daemon, it hands out access tokens
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return err
+	}
+
+	var wg sync.WaitGroup
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+
+		if err != nil {
+			wg.Wait()
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	// unblocks the scanner on shutdown, and is undone when the client
+	// disconnects first
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	enc := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		resp := s.handle(ctx, scanner.Bytes())
+
+		if err := enc.Encode(resp); err != nil {
+			s.logger.Error("writing response", "err", err)
+			return
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, line []byte) Response {
+	var req Request
+
+	if err := json.Unmarshal(line, &req); err != nil {
+		return errorResponse(0, &Error{ Code: CodeParse, Message: err.Error() })
+	}
+
+	if req.JSONRPC != version || req.Method == "" {
+		return errorResponse(req.Id, &Error{ Code: CodeInvalidRequest, Message: "not a json-rpc 2.0 request" })
+	}
+
+	h, ok := s.handlers[req.Method]
+
+	if !ok {
+		return errorResponse(req.Id, &Error{ Code: CodeMethodNotFound, Message: "unknown method " + req.Method })
+	}
+
+	result, err := h(ctx, req.Params)
+
+	if err != nil {
+		s.logger.Error("method failed", "method", req.Method, "err", err)
+
+		var rpcErr *Error
+
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{ Code: CodeFailed, Message: err.Error() }
+		}
+
+		return errorResponse(req.Id, rpcErr)
+	}
+
+	data, err := json.Marshal(result)
+
+	if err != nil {
+		return errorResponse(req.Id, &Error{ Code: CodeFailed, Message: err.Error() })
+	}
+
+	return Response{
+		JSONRPC: version,
+		Id: req.Id,
+		Result: data,
+	}
+}
+
+func errorResponse(id int64, err *Error) Response {
+	return Response{
+		JSONRPC: version,
+		Id: id,
+		Error: err,
+	}
+}
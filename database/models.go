@@ -8,6 +8,21 @@ import (
 	"database/sql"
 )
 
+type Artist struct {
+	ID         string
+	Name       string
+	Uri        string
+	Genres     string
+	Popularity int64
+	Followed   bool
+}
+
+type ArtistGenre struct {
+	ArtistID  string
+	Genres    string
+	FetchedAt string
+}
+
 type Config struct {
 	ID           int64
 	ClientSecret string
@@ -18,3 +33,118 @@ type Config struct {
 	RefreshToken sql.NullString
 	ExpiresAt    sql.NullString
 }
+
+type PlayHistory struct {
+	ID         int64
+	PlayKey    string
+	PlayedAt   string
+	TrackUri   sql.NullString
+	TrackName  string
+	ArtistName string
+	AlbumName  sql.NullString
+	MsPlayed   int64
+	Source     string
+}
+
+type Playlist struct {
+	ID              string
+	Name            string
+	Description     string
+	OwnerID         string
+	OwnerName       string
+	SnapshotID      string
+	ItemsSnapshotID string
+	Collaborative   bool
+	Public          bool
+	Uri             string
+	Total           int64
+	Position        int64
+	SyncedAt        string
+}
+
+type PlaylistChange struct {
+	ID           int64
+	PlaylistID   string
+	PlaylistName string
+	SnapshotID   string
+	DetectedAt   string
+	Change       string
+	TrackUri     string
+	TrackName    string
+	ArtistNames  string
+	AddedBy      string
+}
+
+type PlaylistItem struct {
+	PlaylistID string
+	Position   int64
+	TrackUri   string
+	AddedAt    string
+	AddedBy    string
+}
+
+type PlaylistWatch struct {
+	PlaylistID string
+	Name       string
+	SnapshotID string
+	Entries    string
+	CheckedAt  string
+	CreatedAt  string
+}
+
+type SavedAlbum struct {
+	ID          string
+	Name        string
+	Uri         string
+	ArtistNames string
+	ReleaseDate string
+	TotalTracks int64
+	AddedAt     string
+}
+
+type SavedTrack struct {
+	TrackUri string
+	AddedAt  string
+}
+
+type SmartPlaylist struct {
+	Name            string
+	Rules           string
+	PlaylistID      string
+	RefreshInterval string
+	RefreshedAt     string
+	CreatedAt       string
+}
+
+type Track struct {
+	Uri         string
+	ID          string
+	Type        string
+	Name        string
+	ArtistNames string
+	AlbumID     string
+	AlbumName   string
+	AlbumUri    string
+	ReleaseDate string
+	DurationMs  int64
+	Popularity  int64
+	Explicit    bool
+	Isrc        string
+	IsLocal     bool
+}
+
+type TrackArtist struct {
+	TrackUri string
+	ArtistID string
+	Position int64
+}
+
+type UiState struct {
+	Profile           string
+	DefaultPlaylistID string
+	ExpandedSections  string
+	TableTitle        string
+	CursorRow         int64
+	CursorCol         int64
+	UpdatedAt         string
+}
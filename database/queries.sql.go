@@ -10,6 +10,132 @@ import (
 	"database/sql"
 )
 
+const clearFollowedArtists = `-- name: ClearFollowedArtists :exec
+UPDATE artists SET followed = 0
+`
+
+func (q *Queries) ClearFollowedArtists(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, clearFollowedArtists)
+	return err
+}
+
+const clearSearchIndex = `-- name: ClearSearchIndex :exec
+DELETE FROM library_search
+`
+
+func (q *Queries) ClearSearchIndex(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, clearSearchIndex)
+	return err
+}
+
+const countPlayHistory = `-- name: CountPlayHistory :one
+SELECT COUNT(*) FROM play_history
+`
+
+func (q *Queries) CountPlayHistory(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPlayHistory)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSavedAlbums = `-- name: CountSavedAlbums :one
+SELECT COUNT(*) FROM saved_albums
+`
+
+func (q *Queries) CountSavedAlbums(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSavedAlbums)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSavedTracks = `-- name: CountSavedTracks :one
+SELECT COUNT(*) FROM saved_tracks
+`
+
+func (q *Queries) CountSavedTracks(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSavedTracks)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteOrphanedPlaylistItems = `-- name: DeleteOrphanedPlaylistItems :exec
+DELETE FROM playlist_items WHERE playlist_id NOT IN (SELECT id FROM playlists)
+`
+
+func (q *Queries) DeleteOrphanedPlaylistItems(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteOrphanedPlaylistItems)
+	return err
+}
+
+const deletePlaylistItems = `-- name: DeletePlaylistItems :exec
+DELETE FROM playlist_items WHERE playlist_id = ?
+`
+
+func (q *Queries) DeletePlaylistItems(ctx context.Context, playlistID string) error {
+	_, err := q.db.ExecContext(ctx, deletePlaylistItems, playlistID)
+	return err
+}
+
+const deletePlaylistWatch = `-- name: DeletePlaylistWatch :execrows
+DELETE FROM playlist_watches WHERE playlist_id = ?
+`
+
+func (q *Queries) DeletePlaylistWatch(ctx context.Context, playlistID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePlaylistWatch, playlistID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSavedAlbums = `-- name: DeleteSavedAlbums :exec
+DELETE FROM saved_albums
+`
+
+func (q *Queries) DeleteSavedAlbums(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteSavedAlbums)
+	return err
+}
+
+const deleteSavedTracks = `-- name: DeleteSavedTracks :exec
+DELETE FROM saved_tracks
+`
+
+func (q *Queries) DeleteSavedTracks(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteSavedTracks)
+	return err
+}
+
+const deleteSmartPlaylist = `-- name: DeleteSmartPlaylist :exec
+DELETE FROM smart_playlists WHERE name = ?
+`
+
+func (q *Queries) DeleteSmartPlaylist(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, deleteSmartPlaylist, name)
+	return err
+}
+
+const deleteStalePlaylists = `-- name: DeleteStalePlaylists :exec
+DELETE FROM playlists WHERE synced_at != ?
+`
+
+func (q *Queries) DeleteStalePlaylists(ctx context.Context, syncedAt string) error {
+	_, err := q.db.ExecContext(ctx, deleteStalePlaylists, syncedAt)
+	return err
+}
+
+const deleteTrackArtists = `-- name: DeleteTrackArtists :exec
+DELETE FROM track_artists WHERE track_uri = ?
+`
+
+func (q *Queries) DeleteTrackArtists(ctx context.Context, trackUri string) error {
+	_, err := q.db.ExecContext(ctx, deleteTrackArtists, trackUri)
+	return err
+}
+
 const getClientInfo = `-- name: GetClientInfo :one
 SELECT client_secret, client_id, redirect_uri, authorized, access_token, refresh_token, expires_at FROM config WHERE id = 1
 `
@@ -39,6 +165,152 @@ func (q *Queries) GetClientInfo(ctx context.Context) (GetClientInfoRow, error) {
 	return i, err
 }
 
+const getLatestSavedAlbum = `-- name: GetLatestSavedAlbum :one
+SELECT id, added_at FROM saved_albums ORDER BY added_at DESC LIMIT 1
+`
+
+type GetLatestSavedAlbumRow struct {
+	ID      string
+	AddedAt string
+}
+
+func (q *Queries) GetLatestSavedAlbum(ctx context.Context) (GetLatestSavedAlbumRow, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSavedAlbum)
+	var i GetLatestSavedAlbumRow
+	err := row.Scan(&i.ID, &i.AddedAt)
+	return i, err
+}
+
+const getLatestSavedTrack = `-- name: GetLatestSavedTrack :one
+SELECT track_uri, added_at FROM saved_tracks ORDER BY added_at DESC LIMIT 1
+`
+
+type GetLatestSavedTrackRow struct {
+	TrackUri string
+	AddedAt  string
+}
+
+func (q *Queries) GetLatestSavedTrack(ctx context.Context) (GetLatestSavedTrackRow, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSavedTrack)
+	var i GetLatestSavedTrackRow
+	err := row.Scan(&i.TrackUri, &i.AddedAt)
+	return i, err
+}
+
+const getPlaylist = `-- name: GetPlaylist :one
+SELECT id, name, description, owner_id, owner_name, snapshot_id, items_snapshot_id, collaborative, public, uri, total, position, synced_at FROM playlists WHERE id = ?
+`
+
+func (q *Queries) GetPlaylist(ctx context.Context, id string) (Playlist, error) {
+	row := q.db.QueryRowContext(ctx, getPlaylist, id)
+	var i Playlist
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.OwnerID,
+		&i.OwnerName,
+		&i.SnapshotID,
+		&i.ItemsSnapshotID,
+		&i.Collaborative,
+		&i.Public,
+		&i.Uri,
+		&i.Total,
+		&i.Position,
+		&i.SyncedAt,
+	)
+	return i, err
+}
+
+const getSmartPlaylist = `-- name: GetSmartPlaylist :one
+SELECT name, rules, playlist_id, refresh_interval, refreshed_at, created_at FROM smart_playlists WHERE name = ?
+`
+
+func (q *Queries) GetSmartPlaylist(ctx context.Context, name string) (SmartPlaylist, error) {
+	row := q.db.QueryRowContext(ctx, getSmartPlaylist, name)
+	var i SmartPlaylist
+	err := row.Scan(
+		&i.Name,
+		&i.Rules,
+		&i.PlaylistID,
+		&i.RefreshInterval,
+		&i.RefreshedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTrackUriByName = `-- name: GetTrackUriByName :one
+SELECT track_uri FROM play_history
+WHERE track_name = ? AND artist_name = ? AND track_uri IS NOT NULL AND track_uri != ''
+LIMIT 1
+`
+
+type GetTrackUriByNameParams struct {
+	TrackName  string
+	ArtistName string
+}
+
+func (q *Queries) GetTrackUriByName(ctx context.Context, arg GetTrackUriByNameParams) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getTrackUriByName, arg.TrackName, arg.ArtistName)
+	var track_uri sql.NullString
+	err := row.Scan(&track_uri)
+	return track_uri, err
+}
+
+const getUiState = `-- name: GetUiState :one
+SELECT profile, default_playlist_id, expanded_sections, table_title, cursor_row, cursor_col, updated_at FROM ui_state WHERE profile = ?
+`
+
+func (q *Queries) GetUiState(ctx context.Context, profile string) (UiState, error) {
+	row := q.db.QueryRowContext(ctx, getUiState, profile)
+	var i UiState
+	err := row.Scan(
+		&i.Profile,
+		&i.DefaultPlaylistID,
+		&i.ExpandedSections,
+		&i.TableTitle,
+		&i.CursorRow,
+		&i.CursorCol,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const indexPlaylists = `-- name: IndexPlaylists :exec
+INSERT INTO library_search (uri, kind, name, artists, album, playlists)
+SELECT uri, 'playlist', name, owner_name, '', name FROM playlists
+`
+
+func (q *Queries) IndexPlaylists(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, indexPlaylists)
+	return err
+}
+
+const indexTracks = `-- name: IndexTracks :exec
+INSERT INTO library_search (uri, kind, name, artists, album, playlists)
+SELECT
+    tracks.uri,
+    'track',
+    tracks.name,
+    tracks.artist_names,
+    tracks.album_name,
+    COALESCE((
+        SELECT group_concat(name, char(31)) FROM (
+            SELECT DISTINCT playlists.name
+            FROM playlist_items
+            JOIN playlists ON playlists.id = playlist_items.playlist_id
+            WHERE playlist_items.track_uri = tracks.uri
+        )
+    ), '')
+FROM tracks
+`
+
+func (q *Queries) IndexTracks(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, indexTracks)
+	return err
+}
+
 const insertConfig = `-- name: InsertConfig :exec
 INSERT INTO config (client_secret, client_id, redirect_uri, authorized, access_token, refresh_token, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)
 `
@@ -66,6 +338,932 @@ func (q *Queries) InsertConfig(ctx context.Context, arg InsertConfigParams) erro
 	return err
 }
 
+const insertPlayHistory = `-- name: InsertPlayHistory :execrows
+INSERT OR IGNORE INTO play_history (play_key, played_at, track_uri, track_name, artist_name, album_name, ms_played, source) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertPlayHistoryParams struct {
+	PlayKey    string
+	PlayedAt   string
+	TrackUri   sql.NullString
+	TrackName  string
+	ArtistName string
+	AlbumName  sql.NullString
+	MsPlayed   int64
+	Source     string
+}
+
+func (q *Queries) InsertPlayHistory(ctx context.Context, arg InsertPlayHistoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertPlayHistory,
+		arg.PlayKey,
+		arg.PlayedAt,
+		arg.TrackUri,
+		arg.TrackName,
+		arg.ArtistName,
+		arg.AlbumName,
+		arg.MsPlayed,
+		arg.Source,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const insertPlaylistChange = `-- name: InsertPlaylistChange :exec
+INSERT INTO playlist_changes (
+    playlist_id, playlist_name, snapshot_id, detected_at, change, track_uri, track_name, artist_names, added_by
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertPlaylistChangeParams struct {
+	PlaylistID   string
+	PlaylistName string
+	SnapshotID   string
+	DetectedAt   string
+	Change       string
+	TrackUri     string
+	TrackName    string
+	ArtistNames  string
+	AddedBy      string
+}
+
+func (q *Queries) InsertPlaylistChange(ctx context.Context, arg InsertPlaylistChangeParams) error {
+	_, err := q.db.ExecContext(ctx, insertPlaylistChange,
+		arg.PlaylistID,
+		arg.PlaylistName,
+		arg.SnapshotID,
+		arg.DetectedAt,
+		arg.Change,
+		arg.TrackUri,
+		arg.TrackName,
+		arg.ArtistNames,
+		arg.AddedBy,
+	)
+	return err
+}
+
+const insertPlaylistItem = `-- name: InsertPlaylistItem :exec
+INSERT INTO playlist_items (playlist_id, position, track_uri, added_at, added_by) VALUES (?, ?, ?, ?, ?)
+`
+
+type InsertPlaylistItemParams struct {
+	PlaylistID string
+	Position   int64
+	TrackUri   string
+	AddedAt    string
+	AddedBy    string
+}
+
+func (q *Queries) InsertPlaylistItem(ctx context.Context, arg InsertPlaylistItemParams) error {
+	_, err := q.db.ExecContext(ctx, insertPlaylistItem,
+		arg.PlaylistID,
+		arg.Position,
+		arg.TrackUri,
+		arg.AddedAt,
+		arg.AddedBy,
+	)
+	return err
+}
+
+const insertSavedAlbum = `-- name: InsertSavedAlbum :exec
+INSERT OR REPLACE INTO saved_albums (id, name, uri, artist_names, release_date, total_tracks, added_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertSavedAlbumParams struct {
+	ID          string
+	Name        string
+	Uri         string
+	ArtistNames string
+	ReleaseDate string
+	TotalTracks int64
+	AddedAt     string
+}
+
+func (q *Queries) InsertSavedAlbum(ctx context.Context, arg InsertSavedAlbumParams) error {
+	_, err := q.db.ExecContext(ctx, insertSavedAlbum,
+		arg.ID,
+		arg.Name,
+		arg.Uri,
+		arg.ArtistNames,
+		arg.ReleaseDate,
+		arg.TotalTracks,
+		arg.AddedAt,
+	)
+	return err
+}
+
+const insertSavedTrack = `-- name: InsertSavedTrack :exec
+INSERT OR REPLACE INTO saved_tracks (track_uri, added_at) VALUES (?, ?)
+`
+
+type InsertSavedTrackParams struct {
+	TrackUri string
+	AddedAt  string
+}
+
+func (q *Queries) InsertSavedTrack(ctx context.Context, arg InsertSavedTrackParams) error {
+	_, err := q.db.ExecContext(ctx, insertSavedTrack, arg.TrackUri, arg.AddedAt)
+	return err
+}
+
+const insertTrackArtist = `-- name: InsertTrackArtist :exec
+INSERT INTO track_artists (track_uri, artist_id, position) VALUES (?, ?, ?)
+`
+
+type InsertTrackArtistParams struct {
+	TrackUri string
+	ArtistID string
+	Position int64
+}
+
+func (q *Queries) InsertTrackArtist(ctx context.Context, arg InsertTrackArtistParams) error {
+	_, err := q.db.ExecContext(ctx, insertTrackArtist, arg.TrackUri, arg.ArtistID, arg.Position)
+	return err
+}
+
+const listArtistsMissingGenres = `-- name: ListArtistsMissingGenres :many
+SELECT DISTINCT ta.artist_id FROM track_artists ta
+JOIN artists a ON a.id = ta.artist_id
+LEFT JOIN artist_genres g ON g.artist_id = ta.artist_id
+WHERE g.artist_id IS NULL AND a.genres = ''
+`
+
+func (q *Queries) ListArtistsMissingGenres(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listArtistsMissingGenres)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var artist_id string
+		if err := rows.Scan(&artist_id); err != nil {
+			return nil, err
+		}
+		items = append(items, artist_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowedArtists = `-- name: ListFollowedArtists :many
+SELECT id, name, uri, genres, popularity, followed FROM artists WHERE followed = 1 ORDER BY name
+`
+
+func (q *Queries) ListFollowedArtists(ctx context.Context) ([]Artist, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowedArtists)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Artist
+	for rows.Next() {
+		var i Artist
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Uri,
+			&i.Genres,
+			&i.Popularity,
+			&i.Followed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlayedUrisSince = `-- name: ListPlayedUrisSince :many
+SELECT track_uri FROM play_history
+WHERE played_at >= ? AND track_uri IS NOT NULL AND track_uri != ''
+ORDER BY played_at
+`
+
+func (q *Queries) ListPlayedUrisSince(ctx context.Context, playedAt string) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, listPlayedUrisSince, playedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var track_uri sql.NullString
+		if err := rows.Scan(&track_uri); err != nil {
+			return nil, err
+		}
+		items = append(items, track_uri)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaylistChanges = `-- name: ListPlaylistChanges :many
+SELECT id, playlist_id, playlist_name, snapshot_id, detected_at, change, track_uri, track_name, artist_names, added_by
+FROM playlist_changes
+WHERE ? OR playlist_id = ?
+ORDER BY id DESC
+LIMIT ?
+`
+
+type ListPlaylistChangesParams struct {
+	AllPlaylists bool
+	PlaylistID   string
+	Limit        int64
+}
+
+func (q *Queries) ListPlaylistChanges(ctx context.Context, arg ListPlaylistChangesParams) ([]PlaylistChange, error) {
+	rows, err := q.db.QueryContext(ctx, listPlaylistChanges, arg.AllPlaylists, arg.PlaylistID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlaylistChange
+	for rows.Next() {
+		var i PlaylistChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.PlaylistID,
+			&i.PlaylistName,
+			&i.SnapshotID,
+			&i.DetectedAt,
+			&i.Change,
+			&i.TrackUri,
+			&i.TrackName,
+			&i.ArtistNames,
+			&i.AddedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaylistChangesAfter = `-- name: ListPlaylistChangesAfter :many
+SELECT id, playlist_id, playlist_name, snapshot_id, detected_at, change, track_uri, track_name, artist_names, added_by
+FROM playlist_changes
+WHERE id > ?
+ORDER BY id
+`
+
+func (q *Queries) ListPlaylistChangesAfter(ctx context.Context, id int64) ([]PlaylistChange, error) {
+	rows, err := q.db.QueryContext(ctx, listPlaylistChangesAfter, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlaylistChange
+	for rows.Next() {
+		var i PlaylistChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.PlaylistID,
+			&i.PlaylistName,
+			&i.SnapshotID,
+			&i.DetectedAt,
+			&i.Change,
+			&i.TrackUri,
+			&i.TrackName,
+			&i.ArtistNames,
+			&i.AddedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaylistItems = `-- name: ListPlaylistItems :many
+SELECT playlist_items.position, playlist_items.added_at, playlist_items.added_by, tracks.uri, tracks.id, tracks.type, tracks.name, tracks.artist_names, tracks.album_id, tracks.album_name, tracks.album_uri, tracks.release_date, tracks.duration_ms, tracks.popularity, tracks.explicit, tracks.isrc, tracks.is_local
+FROM playlist_items
+JOIN tracks ON tracks.uri = playlist_items.track_uri
+WHERE playlist_items.playlist_id = ?
+ORDER BY playlist_items.position
+`
+
+type ListPlaylistItemsRow struct {
+	Position    int64
+	AddedAt     string
+	AddedBy     string
+	Uri         string
+	ID          string
+	Type        string
+	Name        string
+	ArtistNames string
+	AlbumID     string
+	AlbumName   string
+	AlbumUri    string
+	ReleaseDate string
+	DurationMs  int64
+	Popularity  int64
+	Explicit    bool
+	Isrc        string
+	IsLocal     bool
+}
+
+func (q *Queries) ListPlaylistItems(ctx context.Context, playlistID string) ([]ListPlaylistItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPlaylistItems, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPlaylistItemsRow
+	for rows.Next() {
+		var i ListPlaylistItemsRow
+		if err := rows.Scan(
+			&i.Position,
+			&i.AddedAt,
+			&i.AddedBy,
+			&i.Uri,
+			&i.ID,
+			&i.Type,
+			&i.Name,
+			&i.ArtistNames,
+			&i.AlbumID,
+			&i.AlbumName,
+			&i.AlbumUri,
+			&i.ReleaseDate,
+			&i.DurationMs,
+			&i.Popularity,
+			&i.Explicit,
+			&i.Isrc,
+			&i.IsLocal,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaylistWatches = `-- name: ListPlaylistWatches :many
+SELECT playlist_id, name, snapshot_id, entries, checked_at, created_at FROM playlist_watches ORDER BY name
+`
+
+func (q *Queries) ListPlaylistWatches(ctx context.Context) ([]PlaylistWatch, error) {
+	rows, err := q.db.QueryContext(ctx, listPlaylistWatches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlaylistWatch
+	for rows.Next() {
+		var i PlaylistWatch
+		if err := rows.Scan(
+			&i.PlaylistID,
+			&i.Name,
+			&i.SnapshotID,
+			&i.Entries,
+			&i.CheckedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaylists = `-- name: ListPlaylists :many
+SELECT id, name, description, owner_id, owner_name, snapshot_id, items_snapshot_id, collaborative, public, uri, total, position, synced_at FROM playlists ORDER BY position
+`
+
+func (q *Queries) ListPlaylists(ctx context.Context) ([]Playlist, error) {
+	rows, err := q.db.QueryContext(ctx, listPlaylists)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Playlist
+	for rows.Next() {
+		var i Playlist
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.OwnerID,
+			&i.OwnerName,
+			&i.SnapshotID,
+			&i.ItemsSnapshotID,
+			&i.Collaborative,
+			&i.Public,
+			&i.Uri,
+			&i.Total,
+			&i.Position,
+			&i.SyncedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSavedAlbums = `-- name: ListSavedAlbums :many
+SELECT id, name, uri, artist_names, release_date, total_tracks, added_at FROM saved_albums ORDER BY added_at DESC
+`
+
+func (q *Queries) ListSavedAlbums(ctx context.Context) ([]SavedAlbum, error) {
+	rows, err := q.db.QueryContext(ctx, listSavedAlbums)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SavedAlbum
+	for rows.Next() {
+		var i SavedAlbum
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Uri,
+			&i.ArtistNames,
+			&i.ReleaseDate,
+			&i.TotalTracks,
+			&i.AddedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSavedTracks = `-- name: ListSavedTracks :many
+SELECT saved_tracks.added_at, tracks.uri, tracks.id, tracks.type, tracks.name, tracks.artist_names, tracks.album_id, tracks.album_name, tracks.album_uri, tracks.release_date, tracks.duration_ms, tracks.popularity, tracks.explicit, tracks.isrc, tracks.is_local
+FROM saved_tracks
+JOIN tracks ON tracks.uri = saved_tracks.track_uri
+ORDER BY saved_tracks.added_at DESC
+`
+
+type ListSavedTracksRow struct {
+	AddedAt     string
+	Uri         string
+	ID          string
+	Type        string
+	Name        string
+	ArtistNames string
+	AlbumID     string
+	AlbumName   string
+	AlbumUri    string
+	ReleaseDate string
+	DurationMs  int64
+	Popularity  int64
+	Explicit    bool
+	Isrc        string
+	IsLocal     bool
+}
+
+func (q *Queries) ListSavedTracks(ctx context.Context) ([]ListSavedTracksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSavedTracks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSavedTracksRow
+	for rows.Next() {
+		var i ListSavedTracksRow
+		if err := rows.Scan(
+			&i.AddedAt,
+			&i.Uri,
+			&i.ID,
+			&i.Type,
+			&i.Name,
+			&i.ArtistNames,
+			&i.AlbumID,
+			&i.AlbumName,
+			&i.AlbumUri,
+			&i.ReleaseDate,
+			&i.DurationMs,
+			&i.Popularity,
+			&i.Explicit,
+			&i.Isrc,
+			&i.IsLocal,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSmartCandidates = `-- name: ListSmartCandidates :many
+SELECT
+    t.uri,
+    t.name,
+    t.artist_names,
+    t.album_name,
+    t.popularity,
+    t.explicit,
+    CAST(min(src.added_at) AS TEXT) AS added_at,
+    CAST(coalesce((
+        SELECT group_concat(coalesce(nullif(g.genres, ''), a.genres), ',')
+        FROM track_artists ta
+        JOIN artists a ON a.id = ta.artist_id
+        LEFT JOIN artist_genres g ON g.artist_id = ta.artist_id
+        WHERE ta.track_uri = t.uri
+    ), '') AS TEXT) AS genres,
+    CAST(coalesce((SELECT max(h.played_at) FROM play_history h WHERE h.track_uri = t.uri), '') AS TEXT) AS last_played_at,
+    (SELECT count(*) FROM play_history h WHERE h.track_uri = t.uri) AS plays
+FROM (
+    SELECT track_uri, added_at FROM saved_tracks WHERE ?
+    UNION ALL
+    SELECT track_uri, added_at FROM playlist_items
+    WHERE (? OR playlist_id = ?) AND playlist_id != ?
+) src
+JOIN tracks t ON t.uri = src.track_uri
+WHERE t.type = 'track' AND t.is_local = 0
+GROUP BY t.uri
+`
+
+type ListSmartCandidatesParams struct {
+	Saved             bool
+	AllPlaylists      bool
+	PlaylistID        string
+	ExcludePlaylistID string
+}
+
+type ListSmartCandidatesRow struct {
+	Uri          string
+	Name         string
+	ArtistNames  string
+	AlbumName    string
+	Popularity   int64
+	Explicit     bool
+	AddedAt      string
+	Genres       string
+	LastPlayedAt string
+	Plays        int64
+}
+
+func (q *Queries) ListSmartCandidates(ctx context.Context, arg ListSmartCandidatesParams) ([]ListSmartCandidatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSmartCandidates,
+		arg.Saved,
+		arg.AllPlaylists,
+		arg.PlaylistID,
+		arg.ExcludePlaylistID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSmartCandidatesRow
+	for rows.Next() {
+		var i ListSmartCandidatesRow
+		if err := rows.Scan(
+			&i.Uri,
+			&i.Name,
+			&i.ArtistNames,
+			&i.AlbumName,
+			&i.Popularity,
+			&i.Explicit,
+			&i.AddedAt,
+			&i.Genres,
+			&i.LastPlayedAt,
+			&i.Plays,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSmartPlaylists = `-- name: ListSmartPlaylists :many
+SELECT name, rules, playlist_id, refresh_interval, refreshed_at, created_at FROM smart_playlists ORDER BY name
+`
+
+func (q *Queries) ListSmartPlaylists(ctx context.Context) ([]SmartPlaylist, error) {
+	rows, err := q.db.QueryContext(ctx, listSmartPlaylists)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SmartPlaylist
+	for rows.Next() {
+		var i SmartPlaylist
+		if err := rows.Scan(
+			&i.Name,
+			&i.Rules,
+			&i.PlaylistID,
+			&i.RefreshInterval,
+			&i.RefreshedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrackArtistsByPlaylist = `-- name: ListTrackArtistsByPlaylist :many
+SELECT track_artists.track_uri, artists.id, artists.name, artists.uri
+FROM track_artists
+JOIN artists ON artists.id = track_artists.artist_id
+WHERE track_artists.track_uri IN (SELECT track_uri FROM playlist_items WHERE playlist_id = ?)
+ORDER BY track_artists.track_uri, track_artists.position
+`
+
+type ListTrackArtistsByPlaylistRow struct {
+	TrackUri string
+	ID       string
+	Name     string
+	Uri      string
+}
+
+func (q *Queries) ListTrackArtistsByPlaylist(ctx context.Context, playlistID string) ([]ListTrackArtistsByPlaylistRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTrackArtistsByPlaylist, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTrackArtistsByPlaylistRow
+	for rows.Next() {
+		var i ListTrackArtistsByPlaylistRow
+		if err := rows.Scan(
+			&i.TrackUri,
+			&i.ID,
+			&i.Name,
+			&i.Uri,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrackArtistsBySavedTracks = `-- name: ListTrackArtistsBySavedTracks :many
+SELECT track_artists.track_uri, artists.id, artists.name, artists.uri
+FROM track_artists
+JOIN artists ON artists.id = track_artists.artist_id
+WHERE track_artists.track_uri IN (SELECT track_uri FROM saved_tracks)
+ORDER BY track_artists.track_uri, track_artists.position
+`
+
+type ListTrackArtistsBySavedTracksRow struct {
+	TrackUri string
+	ID       string
+	Name     string
+	Uri      string
+}
+
+func (q *Queries) ListTrackArtistsBySavedTracks(ctx context.Context) ([]ListTrackArtistsBySavedTracksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTrackArtistsBySavedTracks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTrackArtistsBySavedTracksRow
+	for rows.Next() {
+		var i ListTrackArtistsBySavedTracksRow
+		if err := rows.Scan(
+			&i.TrackUri,
+			&i.ID,
+			&i.Name,
+			&i.Uri,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchLibrary = `-- name: SearchLibrary :many
+SELECT uri, kind, name, artists, album, playlists FROM library_search
+WHERE library_search MATCH ?
+ORDER BY rank
+LIMIT ?
+`
+
+type SearchLibraryParams struct {
+	Query string
+	Limit int64
+}
+
+type SearchLibraryRow struct {
+	Uri       string
+	Kind      string
+	Name      string
+	Artists   string
+	Album     string
+	Playlists string
+}
+
+func (q *Queries) SearchLibrary(ctx context.Context, arg SearchLibraryParams) ([]SearchLibraryRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchLibrary, arg.Query, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchLibraryRow
+	for rows.Next() {
+		var i SearchLibraryRow
+		if err := rows.Scan(
+			&i.Uri,
+			&i.Kind,
+			&i.Name,
+			&i.Artists,
+			&i.Album,
+			&i.Playlists,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setDefaultPlaylistID = `-- name: SetDefaultPlaylistID :exec
+INSERT INTO ui_state (profile, default_playlist_id, updated_at) VALUES (?, ?, ?)
+ON CONFLICT (profile) DO UPDATE SET
+    default_playlist_id = excluded.default_playlist_id,
+    updated_at = excluded.updated_at
+`
+
+type SetDefaultPlaylistIDParams struct {
+	Profile           string
+	DefaultPlaylistID string
+	UpdatedAt         string
+}
+
+func (q *Queries) SetDefaultPlaylistID(ctx context.Context, arg SetDefaultPlaylistIDParams) error {
+	_, err := q.db.ExecContext(ctx, setDefaultPlaylistID, arg.Profile, arg.DefaultPlaylistID, arg.UpdatedAt)
+	return err
+}
+
+const setPlayHistoryTrackUri = `-- name: SetPlayHistoryTrackUri :exec
+UPDATE play_history SET track_uri = ? WHERE play_key = ? AND track_uri IS NULL
+`
+
+type SetPlayHistoryTrackUriParams struct {
+	TrackUri sql.NullString
+	PlayKey  string
+}
+
+func (q *Queries) SetPlayHistoryTrackUri(ctx context.Context, arg SetPlayHistoryTrackUriParams) error {
+	_, err := q.db.ExecContext(ctx, setPlayHistoryTrackUri, arg.TrackUri, arg.PlayKey)
+	return err
+}
+
+const setPlaylistItemsSnapshot = `-- name: SetPlaylistItemsSnapshot :exec
+UPDATE playlists SET items_snapshot_id = ? WHERE id = ?
+`
+
+type SetPlaylistItemsSnapshotParams struct {
+	ItemsSnapshotID string
+	ID              string
+}
+
+func (q *Queries) SetPlaylistItemsSnapshot(ctx context.Context, arg SetPlaylistItemsSnapshotParams) error {
+	_, err := q.db.ExecContext(ctx, setPlaylistItemsSnapshot, arg.ItemsSnapshotID, arg.ID)
+	return err
+}
+
+const setPlaylistWatchChecked = `-- name: SetPlaylistWatchChecked :exec
+UPDATE playlist_watches SET checked_at = ? WHERE playlist_id = ?
+`
+
+type SetPlaylistWatchCheckedParams struct {
+	CheckedAt  string
+	PlaylistID string
+}
+
+func (q *Queries) SetPlaylistWatchChecked(ctx context.Context, arg SetPlaylistWatchCheckedParams) error {
+	_, err := q.db.ExecContext(ctx, setPlaylistWatchChecked, arg.CheckedAt, arg.PlaylistID)
+	return err
+}
+
+const setSmartPlaylistRefreshed = `-- name: SetSmartPlaylistRefreshed :exec
+UPDATE smart_playlists SET playlist_id = ?, refreshed_at = ? WHERE name = ?
+`
+
+type SetSmartPlaylistRefreshedParams struct {
+	PlaylistID  string
+	RefreshedAt string
+	Name        string
+}
+
+func (q *Queries) SetSmartPlaylistRefreshed(ctx context.Context, arg SetSmartPlaylistRefreshedParams) error {
+	_, err := q.db.ExecContext(ctx, setSmartPlaylistRefreshed, arg.PlaylistID, arg.RefreshedAt, arg.Name)
+	return err
+}
+
+const updatePlaylistWatch = `-- name: UpdatePlaylistWatch :exec
+UPDATE playlist_watches SET name = ?, snapshot_id = ?, entries = ?, checked_at = ? WHERE playlist_id = ?
+`
+
+type UpdatePlaylistWatchParams struct {
+	Name       string
+	SnapshotID string
+	Entries    string
+	CheckedAt  string
+	PlaylistID string
+}
+
+func (q *Queries) UpdatePlaylistWatch(ctx context.Context, arg UpdatePlaylistWatchParams) error {
+	_, err := q.db.ExecContext(ctx, updatePlaylistWatch,
+		arg.Name,
+		arg.SnapshotID,
+		arg.Entries,
+		arg.CheckedAt,
+		arg.PlaylistID,
+	)
+	return err
+}
+
 const updateTokens = `-- name: UpdateTokens :exec
 UPDATE config
 SET
@@ -87,3 +1285,243 @@ func (q *Queries) UpdateTokens(ctx context.Context, arg UpdateTokensParams) erro
 	_, err := q.db.ExecContext(ctx, updateTokens, arg.AccessToken, arg.RefreshToken, arg.ExpiresAt)
 	return err
 }
+
+const upsertArtist = `-- name: UpsertArtist :exec
+INSERT INTO artists (id, name, uri) VALUES (?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET name = excluded.name, uri = excluded.uri
+`
+
+type UpsertArtistParams struct {
+	ID   string
+	Name string
+	Uri  string
+}
+
+func (q *Queries) UpsertArtist(ctx context.Context, arg UpsertArtistParams) error {
+	_, err := q.db.ExecContext(ctx, upsertArtist, arg.ID, arg.Name, arg.Uri)
+	return err
+}
+
+const upsertArtistGenres = `-- name: UpsertArtistGenres :exec
+INSERT INTO artist_genres (artist_id, genres, fetched_at) VALUES (?, ?, ?)
+ON CONFLICT (artist_id) DO UPDATE SET genres = excluded.genres, fetched_at = excluded.fetched_at
+`
+
+type UpsertArtistGenresParams struct {
+	ArtistID  string
+	Genres    string
+	FetchedAt string
+}
+
+func (q *Queries) UpsertArtistGenres(ctx context.Context, arg UpsertArtistGenresParams) error {
+	_, err := q.db.ExecContext(ctx, upsertArtistGenres, arg.ArtistID, arg.Genres, arg.FetchedAt)
+	return err
+}
+
+const upsertFollowedArtist = `-- name: UpsertFollowedArtist :exec
+INSERT INTO artists (id, name, uri, genres, popularity, followed) VALUES (?, ?, ?, ?, ?, 1)
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    uri = excluded.uri,
+    genres = excluded.genres,
+    popularity = excluded.popularity,
+    followed = 1
+`
+
+type UpsertFollowedArtistParams struct {
+	ID         string
+	Name       string
+	Uri        string
+	Genres     string
+	Popularity int64
+}
+
+func (q *Queries) UpsertFollowedArtist(ctx context.Context, arg UpsertFollowedArtistParams) error {
+	_, err := q.db.ExecContext(ctx, upsertFollowedArtist,
+		arg.ID,
+		arg.Name,
+		arg.Uri,
+		arg.Genres,
+		arg.Popularity,
+	)
+	return err
+}
+
+const upsertPlaylist = `-- name: UpsertPlaylist :exec
+INSERT INTO playlists (id, name, description, owner_id, owner_name, snapshot_id, collaborative, public, uri, total, position, synced_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    description = excluded.description,
+    owner_id = excluded.owner_id,
+    owner_name = excluded.owner_name,
+    snapshot_id = excluded.snapshot_id,
+    collaborative = excluded.collaborative,
+    public = excluded.public,
+    uri = excluded.uri,
+    total = excluded.total,
+    position = excluded.position,
+    synced_at = excluded.synced_at
+`
+
+type UpsertPlaylistParams struct {
+	ID            string
+	Name          string
+	Description   string
+	OwnerID       string
+	OwnerName     string
+	SnapshotID    string
+	Collaborative bool
+	Public        bool
+	Uri           string
+	Total         int64
+	Position      int64
+	SyncedAt      string
+}
+
+func (q *Queries) UpsertPlaylist(ctx context.Context, arg UpsertPlaylistParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPlaylist,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.OwnerID,
+		arg.OwnerName,
+		arg.SnapshotID,
+		arg.Collaborative,
+		arg.Public,
+		arg.Uri,
+		arg.Total,
+		arg.Position,
+		arg.SyncedAt,
+	)
+	return err
+}
+
+const upsertPlaylistWatch = `-- name: UpsertPlaylistWatch :exec
+INSERT INTO playlist_watches (playlist_id, name, created_at) VALUES (?, ?, ?)
+ON CONFLICT (playlist_id) DO UPDATE SET name = excluded.name
+`
+
+type UpsertPlaylistWatchParams struct {
+	PlaylistID string
+	Name       string
+	CreatedAt  string
+}
+
+func (q *Queries) UpsertPlaylistWatch(ctx context.Context, arg UpsertPlaylistWatchParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPlaylistWatch, arg.PlaylistID, arg.Name, arg.CreatedAt)
+	return err
+}
+
+const upsertSmartPlaylist = `-- name: UpsertSmartPlaylist :exec
+INSERT INTO smart_playlists (name, rules, refresh_interval, created_at) VALUES (?, ?, ?, ?)
+ON CONFLICT (name) DO UPDATE SET rules = excluded.rules, refresh_interval = excluded.refresh_interval
+`
+
+type UpsertSmartPlaylistParams struct {
+	Name            string
+	Rules           string
+	RefreshInterval string
+	CreatedAt       string
+}
+
+func (q *Queries) UpsertSmartPlaylist(ctx context.Context, arg UpsertSmartPlaylistParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSmartPlaylist,
+		arg.Name,
+		arg.Rules,
+		arg.RefreshInterval,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const upsertTrack = `-- name: UpsertTrack :exec
+INSERT INTO tracks (uri, id, type, name, artist_names, album_id, album_name, album_uri, release_date, duration_ms, popularity, explicit, isrc, is_local)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (uri) DO UPDATE SET
+    id = excluded.id,
+    type = excluded.type,
+    name = excluded.name,
+    artist_names = excluded.artist_names,
+    album_id = excluded.album_id,
+    album_name = excluded.album_name,
+    album_uri = excluded.album_uri,
+    release_date = excluded.release_date,
+    duration_ms = excluded.duration_ms,
+    popularity = excluded.popularity,
+    explicit = excluded.explicit,
+    isrc = excluded.isrc,
+    is_local = excluded.is_local
+`
+
+type UpsertTrackParams struct {
+	Uri         string
+	ID          string
+	Type        string
+	Name        string
+	ArtistNames string
+	AlbumID     string
+	AlbumName   string
+	AlbumUri    string
+	ReleaseDate string
+	DurationMs  int64
+	Popularity  int64
+	Explicit    bool
+	Isrc        string
+	IsLocal     bool
+}
+
+func (q *Queries) UpsertTrack(ctx context.Context, arg UpsertTrackParams) error {
+	_, err := q.db.ExecContext(ctx, upsertTrack,
+		arg.Uri,
+		arg.ID,
+		arg.Type,
+		arg.Name,
+		arg.ArtistNames,
+		arg.AlbumID,
+		arg.AlbumName,
+		arg.AlbumUri,
+		arg.ReleaseDate,
+		arg.DurationMs,
+		arg.Popularity,
+		arg.Explicit,
+		arg.Isrc,
+		arg.IsLocal,
+	)
+	return err
+}
+
+const upsertUiState = `-- name: UpsertUiState :exec
+INSERT INTO ui_state (profile, default_playlist_id, expanded_sections, table_title, cursor_row, cursor_col, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (profile) DO UPDATE SET
+    default_playlist_id = excluded.default_playlist_id,
+    expanded_sections = excluded.expanded_sections,
+    table_title = excluded.table_title,
+    cursor_row = excluded.cursor_row,
+    cursor_col = excluded.cursor_col,
+    updated_at = excluded.updated_at
+`
+
+type UpsertUiStateParams struct {
+	Profile           string
+	DefaultPlaylistID string
+	ExpandedSections  string
+	TableTitle        string
+	CursorRow         int64
+	CursorCol         int64
+	UpdatedAt         string
+}
+
+func (q *Queries) UpsertUiState(ctx context.Context, arg UpsertUiStateParams) error {
+	_, err := q.db.ExecContext(ctx, upsertUiState,
+		arg.Profile,
+		arg.DefaultPlaylistID,
+		arg.ExpandedSections,
+		arg.TableTitle,
+		arg.CursorRow,
+		arg.CursorCol,
+		arg.UpdatedAt,
+	)
+	return err
+}
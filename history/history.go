@@ -0,0 +1,435 @@
+package history
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+const (
+	SourceApi = "api"
+	SourceExport = "export"
+	SourceExtendedExport = "extended_export"
+)
+
+const streamingHistoryTimeLayout = "2006-01-02 15:04"
+
+var ErrNoHistoryFiles = errors.New("no streaming history files found")
+
+type Play struct {
+	PlayedAt time.Time
+	TrackUri string
+	TrackName string
+	ArtistName string
+	AlbumName string
+	MsPlayed int
+	Source string
+}
+
+// Key identifies a play independently of the source it was read from. The
+// account export only has minute precision, so plays are compared by the
+// minute they ended in.
+func (p Play) Key() string {
+	playedAt := p.PlayedAt.UTC().Truncate(time.Minute).Format(time.RFC3339)
+	return strings.Join([]string{
+		strings.ToLower(p.TrackName),
+		strings.ToLower(p.ArtistName),
+		playedAt,
+	}, "\x1f")
+}
+
+func FromPlayHistory(item types.PlayHistory) (Play, error) {
+	playedAt, err := time.Parse(time.RFC3339, item.PlayedAt)
+
+	if err != nil {
+		return Play{}, err
+	}
+
+	var artists []string
+
+	for _, artist := range item.Track.Artists {
+		artists = append(artists, artist.Name)
+	}
+
+	play := Play{
+		PlayedAt: playedAt,
+		TrackUri: item.Track.Uri,
+		TrackName: item.Track.Name,
+		AlbumName: item.Track.Album.Name,
+		MsPlayed: item.Track.DurationMs,
+		Source: SourceApi,
+	}
+
+	if len(artists) > 0 {
+		play.ArtistName = artists[0]
+	}
+
+	return play, nil
+}
+
+type streamingHistoryEntry struct {
+	// StreamingHistory*.json
+	EndTime string `json:"endTime"`
+	ArtistName string `json:"artistName"`
+	TrackName string `json:"trackName"`
+	MsPlayed int `json:"msPlayed"`
+
+	// endsong_*.json and Streaming_History_Audio_*.json
+	Ts string `json:"ts"`
+	ExtendedMsPlayed int `json:"ms_played"`
+	MasterTrackName types.Optional[string] `json:"master_metadata_track_name"`
+	MasterArtistName types.Optional[string] `json:"master_metadata_album_artist_name"`
+	MasterAlbumName types.Optional[string] `json:"master_metadata_album_album_name"`
+	SpotifyTrackUri types.Optional[string] `json:"spotify_track_uri"`
+	EpisodeName types.Optional[string] `json:"episode_name"`
+	EpisodeShowName types.Optional[string] `json:"episode_show_name"`
+	SpotifyEpisodeUri types.Optional[string] `json:"spotify_episode_uri"`
+}
+
+func (e streamingHistoryEntry) isExtended() bool {
+	return e.Ts != ""
+}
+
+func (e streamingHistoryEntry) play() (Play, bool, error) {
+	if !e.isExtended() {
+		playedAt, err := time.ParseInLocation(streamingHistoryTimeLayout, e.EndTime, time.UTC)
+
+		if err != nil {
+			return Play{}, false, err
+		}
+
+		play := Play{
+			PlayedAt: playedAt,
+			TrackName: e.TrackName,
+			ArtistName: e.ArtistName,
+			MsPlayed: e.MsPlayed,
+			Source: SourceExport,
+		}
+
+		return play, play.TrackName != "", nil
+	}
+
+	playedAt, err := time.Parse(time.RFC3339, e.Ts)
+
+	if err != nil {
+		return Play{}, false, err
+	}
+
+	play := Play{
+		PlayedAt: playedAt,
+		MsPlayed: e.ExtendedMsPlayed,
+		Source: SourceExtendedExport,
+	}
+
+	switch {
+	case e.MasterTrackName.Valid:
+		play.TrackName = e.MasterTrackName.Value
+		play.ArtistName = e.MasterArtistName.Value
+		play.AlbumName = e.MasterAlbumName.Value
+		play.TrackUri = e.SpotifyTrackUri.Value
+	case e.EpisodeName.Valid:
+		play.TrackName = e.EpisodeName.Value
+		play.ArtistName = e.EpisodeShowName.Value
+		play.TrackUri = e.SpotifyEpisodeUri.Value
+	}
+
+	return play, play.TrackName != "", nil
+}
+
+func isHistoryFile(name string) bool {
+	base := path.Base(name)
+
+	if path.Ext(base) != ".json" {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(base, "StreamingHistory"):
+		return true
+	case strings.HasPrefix(base, "endsong_"):
+		return true
+	case strings.HasPrefix(base, "Streaming_History_Audio_"):
+		return true
+	}
+
+	return false
+}
+
+// ReadPlays decodes every play contained in a single history file.
+func ReadPlays(fsys fs.FS, name string) ([]Play, error) {
+	data, err := fs.ReadFile(fsys, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []streamingHistoryEntry
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	plays := make([]Play, 0, len(entries))
+
+	for i, entry := range entries {
+		play, ok, err := entry.play()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: entry %d: %w", name, i, err)
+		}
+
+		if !ok {
+			continue
+		}
+
+		plays = append(plays, play)
+	}
+
+	return plays, nil
+}
+
+func findHistoryFiles(fsys fs.FS) ([]string, error) {
+	var files []string
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !isHistoryFile(name) {
+			return nil
+		}
+
+		files = append(files, name)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+type Progress struct {
+	File string
+	FileIndex int
+	FileCount int
+	Plays int
+	Imported int
+	Duplicates int
+}
+
+type ImportResult struct {
+	Files int
+	Plays int
+	Imported int
+	Duplicates int
+}
+
+type Importer struct {
+	db *sql.DB
+	progress func(Progress)
+}
+
+func NewImporter(db *sql.DB) *Importer {
+	return &Importer{
+		db: db,
+	}
+}
+
+func (i *Importer) OnProgress(f func(Progress)) {
+	i.progress = f
+}
+
+// Import reads a data export from either an extracted directory or the zip
+// file Spotify sends and adds every play it contains to the local history.
+func (i *Importer) Import(ctx context.Context, src string) (ImportResult, error) {
+	var result ImportResult
+
+	info, err := os.Stat(src)
+
+	if err != nil {
+		return result, err
+	}
+
+	var fsys fs.FS
+
+	if info.IsDir() {
+		fsys = os.DirFS(src)
+	} else {
+		r, err := zip.OpenReader(src)
+
+		if err != nil {
+			return result, err
+		}
+
+		defer r.Close()
+
+		fsys = r
+	}
+
+	files, err := findHistoryFiles(fsys)
+
+	if err != nil {
+		return result, err
+	}
+
+	if len(files) == 0 {
+		return result, ErrNoHistoryFiles
+	}
+
+	for idx, file := range files {
+		plays, err := ReadPlays(fsys, file)
+
+		if err != nil {
+			return result, err
+		}
+
+		imported, err := i.insert(ctx, plays)
+
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", file, err)
+		}
+
+		result.Files++
+		result.Plays += len(plays)
+		result.Imported += imported
+		result.Duplicates += len(plays) - imported
+
+		if i.progress != nil {
+			i.progress(Progress{
+				File: file,
+				FileIndex: idx+1,
+				FileCount: len(files),
+				Plays: result.Plays,
+				Imported: result.Imported,
+				Duplicates: result.Duplicates,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func (i *Importer) insert(ctx context.Context, plays []Play) (int, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	n, err := Record(ctx, database.New(i.db).WithTx(tx), plays)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Record inserts plays that have not been recorded yet and returns how many
+// were new. Plays without a uri are matched against earlier plays of the same
+// track, and already recorded plays missing a uri get it filled in.
+func Record(ctx context.Context, q *database.Queries, plays []Play) (int, error) {
+	var imported int
+
+	for _, play := range plays {
+		if play.TrackUri == "" {
+			uri, err := q.GetTrackUriByName(ctx, database.GetTrackUriByNameParams{
+				TrackName: play.TrackName,
+				ArtistName: play.ArtistName,
+			})
+
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return imported, err
+			}
+
+			play.TrackUri = uri.String
+		}
+
+		key := play.Key()
+
+		n, err := q.InsertPlayHistory(ctx, database.InsertPlayHistoryParams{
+			PlayKey: key,
+			PlayedAt: play.PlayedAt.UTC().Format(time.RFC3339),
+			TrackUri: nullString(play.TrackUri),
+			TrackName: play.TrackName,
+			ArtistName: play.ArtistName,
+			AlbumName: nullString(play.AlbumName),
+			MsPlayed: int64(play.MsPlayed),
+			Source: play.Source,
+		})
+
+		if err != nil {
+			return imported, err
+		}
+
+		if n == 0 && play.TrackUri != "" {
+			err := q.SetPlayHistoryTrackUri(ctx, database.SetPlayHistoryTrackUriParams{
+				TrackUri: nullString(play.TrackUri),
+				PlayKey: key,
+			})
+
+			if err != nil {
+				return imported, err
+			}
+		}
+
+		imported += int(n)
+	}
+
+	return imported, nil
+}
+
+// PlayedSince returns the uris of the tracks played since the given time in
+// the order they were first played, each once.
+func PlayedSince(ctx context.Context, q *database.Queries, since time.Time) ([]string, error) {
+	rows, err := q.ListPlayedUrisSince(ctx, since.UTC().Format(time.RFC3339))
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var uris []string
+
+	for _, uri := range rows {
+		if seen[uri.String] {
+			continue
+		}
+
+		seen[uri.String] = true
+		uris = append(uris, uri.String)
+	}
+
+	return uris, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{
+		String: s,
+		Valid: s != "",
+	}
+}
@@ -0,0 +1,210 @@
+// Package hooks runs the commands users configure for events like a track
+// change, so notifications, lighting or logging can be built without
+// changing gsp. A hook gets the event as json on stdin and its fields in
+// GSP_ environment variables.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/config"
+)
+
+// maxPending is how many hooks may wait for a free slot before new ones are
+// dropped, so a burst of events does not pile up processes.
+const maxPending = 32
+
+// maxStderr is how much of the output of a failed hook is kept for the
+// error.
+const maxStderr = 512
+
+// Event is what hooks are run with. Data is written to stdin as json and
+// Env is added to the environment next to GSP_EVENT.
+type Event struct {
+	Name string
+	Data any
+	Env map[string]string
+}
+
+// Error is a hook that failed, timed out or was dropped.
+type Error struct {
+	Event string
+	Hook config.Hook
+	Stderr string
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s hook %s failed: %v", e.Event, e.Hook.Command(), e.Err)
+
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type Runner struct {
+	logger *slog.Logger
+	onError func(error)
+	wg sync.WaitGroup
+
+	mu sync.Mutex
+	cfg config.Hooks
+	slots chan struct{}
+	pending int
+}
+
+// New returns a runner for the hooks of cfg. onError is called with every
+// *Error from the goroutine the hook ran on, nil to only log them.
+func New(cfg config.Hooks, logger *slog.Logger, onError func(error)) *Runner {
+	r := &Runner{
+		logger: logger,
+		onError: onError,
+	}
+
+	r.SetConfig(cfg)
+
+	return r
+}
+
+// SetConfig replaces the hooks, e.g. after the config was reloaded. Hooks
+// that already run are not affected.
+func (r *Runner) SetConfig(cfg config.Hooks) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cfg = cfg
+	r.slots = make(chan struct{}, max(cfg.Concurrency, 1))
+}
+
+// Fire starts every hook of the event and returns right away.
+func (r *Runner) Fire(e Event) {
+	var dropped []error
+
+	r.mu.Lock()
+
+	for _, hook := range r.cfg.Commands {
+		if !slices.Contains(hook.Events, e.Name) {
+			continue
+		}
+
+		if r.pending >= maxPending {
+			dropped = append(dropped, &Error{ Event: e.Name, Hook: hook, Err: fmt.Errorf("dropped, %d hooks are already waiting", r.pending) })
+			continue
+		}
+
+		r.pending++
+		r.wg.Add(1)
+
+		go r.run(hook, e, r.slots, r.cfg.Timeout.Duration)
+	}
+
+	r.mu.Unlock()
+
+	for _, err := range dropped {
+		r.fail(err)
+	}
+}
+
+// Wait blocks until every started hook is done, before a command exits.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Runner) run(hook config.Hook, e Event, slots chan struct{}, timeout time.Duration) {
+	defer r.wg.Done()
+
+	slots <- struct{}{}
+
+	r.mu.Lock()
+	r.pending--
+	r.mu.Unlock()
+
+	defer func() { <-slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	stderr, err := execute(ctx, hook, e)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("killed after %s", timeout)
+	}
+
+	if err != nil {
+		r.fail(&Error{ Event: e.Name, Hook: hook, Stderr: stderr, Err: err })
+		return
+	}
+
+	r.logger.Info("hook ran", "event", e.Name, "hook", hook.Command(), "took", time.Since(start))
+}
+
+func (r *Runner) fail(err error) {
+	r.logger.Error("hook failed", "err", err)
+
+	if r.onError != nil {
+		r.onError(err)
+	}
+}
+
+func execute(ctx context.Context, hook config.Hook, e Event) (string, error) {
+	stdin, err := json.Marshal(e.Data)
+
+	if err != nil {
+		return "", err
+	}
+
+	var cmd *exec.Cmd
+
+	if hook.Run != "" {
+		cmd = exec.CommandContext(ctx, "sh", "-c", hook.Run)
+	} else {
+		cmd = exec.CommandContext(ctx, hook.Exec[0], hook.Exec[1:]...)
+	}
+
+	var stderr bytes.Buffer
+
+	cmd.Stdin = bytes.NewReader(append(stdin, '\n'))
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "GSP_EVENT=" + e.Name)
+	// a hook that leaves children holding its output must not hang past
+	// the timeout
+	cmd.WaitDelay = time.Second
+
+	for key, value := range e.Env {
+		cmd.Env = append(cmd.Env, key + "=" + value)
+	}
+
+	err = cmd.Run()
+
+	return lastLine(stderr.String()), err
+}
+
+// lastLine is the last non empty line of a hook's stderr, which usually
+// says what went wrong.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	line := strings.TrimSpace(lines[len(lines)-1])
+
+	if len(line) > maxStderr {
+		line = line[:maxStderr] + "…"
+	}
+
+	return line
+}
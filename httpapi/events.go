@@ -0,0 +1,50 @@
+package httpapi
+
+import (
+	"sync"
+
+	"github.com/arjunmoola/go-spotify/output"
+)
+
+// broker hands every playback change to the event streams that are open.
+type broker struct {
+	mu sync.Mutex
+	subscribers map[chan output.NowPlaying]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{
+		subscribers: make(map[chan output.NowPlaying]struct{}),
+	}
+}
+
+func (b *broker) subscribe() chan output.NowPlaying {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan output.NowPlaying, 8)
+	b.subscribers[ch] = struct{}{}
+
+	return ch
+}
+
+func (b *broker) unsubscribe(ch chan output.NowPlaying) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subscribers, ch)
+}
+
+// publish never blocks, a stream that does not keep up misses changes
+// rather than holding up the others.
+func (b *broker) publish(n output.NowPlaying) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Package httpapi serves the player over http for stream decks, home
+// automation and web widgets, so they control spotify through gsp instead
+// of each logging in on their own.
+//
+// Every request needs the token, either as "Authorization: Bearer <token>"
+// or as the token query parameter for clients like EventSource that cannot
+// set headers.
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/output"
+)
+
+// Player is what the api controls.
+type Player interface {
+	NowPlaying(ctx context.Context) (output.NowPlaying, error)
+	Play(ctx context.Context) error
+	Pause(ctx context.Context) error
+	Toggle(ctx context.Context) error
+	Skip(ctx context.Context, direction string) error
+	SetVolume(ctx context.Context, percent int) error
+	Queue(ctx context.Context) ([]output.QueueItem, error)
+	AddToQueue(ctx context.Context, uri string) error
+	Search(ctx context.Context, query string, limit int) ([]output.SearchResult, error)
+	Devices(ctx context.Context) ([]output.Device, error)
+}
+
+// keepAlive is how often an idle event stream gets a comment so proxies
+// and browsers do not close it.
+const keepAlive = 15*time.Second
+
+type Server struct {
+	player Player
+	token string
+	// allowOrigin is sent as Access-Control-Allow-Origin so a web widget
+	// served elsewhere may call the api, empty to not send it
+	allowOrigin string
+	logger *slog.Logger
+	events *broker
+}
+
+func NewServer(player Player, token, allowOrigin string, logger *slog.Logger) *Server {
+	return &Server{
+		player: player,
+		token: token,
+		allowOrigin: allowOrigin,
+		logger: logger,
+		events: newBroker(),
+	}
+}
+
+// Publish sends a playback change to the open event streams.
+func (s *Server) Publish(n output.NowPlaying) {
+	s.events.publish(n)
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/now-playing", s.nowPlaying)
+	mux.HandleFunc("POST /api/play", s.action(s.player.Play))
+	mux.HandleFunc("POST /api/pause", s.action(s.player.Pause))
+	mux.HandleFunc("POST /api/toggle", s.action(s.player.Toggle))
+	mux.HandleFunc("POST /api/next", s.action(func(ctx context.Context) error {
+		return s.player.Skip(ctx, "next")
+	}))
+	mux.HandleFunc("POST /api/previous", s.action(func(ctx context.Context) error {
+		return s.player.Skip(ctx, "previous")
+	}))
+	mux.HandleFunc("PUT /api/volume", s.volume)
+	mux.HandleFunc("GET /api/queue", s.queue)
+	mux.HandleFunc("POST /api/queue", s.addToQueue)
+	mux.HandleFunc("GET /api/search", s.search)
+	mux.HandleFunc("GET /api/devices", s.devices)
+	mux.HandleFunc("GET /api/events", s.stream)
+
+	return s.cors(s.auth(mux))
+}
+
+// ListenAndServe serves the api on addr until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	if s.token == "" {
+		return errors.New("refusing to serve the api without a token")
+	}
+
+	ln, err := net.Listen("tcp", addr)
+
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler: s.Handler(),
+		ReadHeaderTimeout: 5*time.Second,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.allowOrigin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", s.allowOrigin)
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT")
+		}
+
+		// preflight requests carry no token
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or wrong token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) nowPlaying(w http.ResponseWriter, r *http.Request) {
+	n, err := s.player.NowPlaying(r.Context())
+
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (s *Server) action(f func(ctx context.Context) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := f(r.Context()); err != nil {
+			s.fail(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// volume takes the percent as json, {"percent": 40}, or as a query
+// parameter, ?percent=40, which is easier on a stream deck.
+func (s *Server) volume(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Percent *int `json:"percent"`
+	}
+
+	if v := r.URL.Query().Get("percent"); v != "" {
+		percent, err := strconv.Atoi(v)
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "percent must be a number")
+			return
+		}
+
+		body.Percent = &percent
+	} else if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+
+	if body.Percent == nil || *body.Percent < 0 || *body.Percent > 100 {
+		writeError(w, http.StatusBadRequest, "percent must be between 0 and 100")
+		return
+	}
+
+	s.action(func(ctx context.Context) error {
+		return s.player.SetVolume(ctx, *body.Percent)
+	})(w, r)
+}
+
+func (s *Server) queue(w http.ResponseWriter, r *http.Request) {
+	items, err := s.player.Queue(r.Context())
+
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(items))
+}
+
+func (s *Server) addToQueue(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Uri string `json:"uri"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+
+	if !strings.HasPrefix(body.Uri, "spotify:track:") && !strings.HasPrefix(body.Uri, "spotify:episode:") {
+		writeError(w, http.StatusBadRequest, "uri must be a spotify track or episode uri")
+		return
+	}
+
+	s.action(func(ctx context.Context) error {
+		return s.player.AddToQueue(ctx, body.Uri)
+	})(w, r)
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	limit := 20
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+
+		if err != nil || n < 1 || n > 50 {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and 50")
+			return
+		}
+
+		limit = n
+	}
+
+	results, err := s.player.Search(r.Context(), query, limit)
+
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(results))
+}
+
+func (s *Server) devices(w http.ResponseWriter, r *http.Request) {
+	devices, err := s.player.Devices(r.Context())
+
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(devices))
+}
+
+// stream sends the playback state as server-sent events, first the state
+// when the stream opens and then every change.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	if n, err := s.player.NowPlaying(r.Context()); err == nil {
+		writeEvent(w, n)
+	}
+
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case n := <-ch:
+			writeEvent(w, n)
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, n output.NowPlaying) {
+	data, _ := json.Marshal(n)
+	fmt.Fprintf(w, "event: playback\ndata: %s\n\n", data)
+}
+
+// fail answers with the status spotify gave for client errors like a
+// missing device and with 502 when spotify itself failed.
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, err error) {
+	s.logger.Error("api request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+
+	status := http.StatusInternalServerError
+
+	var spotifyErr client.SpotifyError
+
+	if errors.As(err, &spotifyErr) {
+		status = http.StatusBadGateway
+
+		if spotifyErr.Status >= 400 && spotifyErr.Status < 500 && spotifyErr.Status != http.StatusUnauthorized {
+			status = spotifyErr.Status
+		}
+	}
+
+	writeError(w, status, err.Error())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{ "error": msg })
+}
+
+// nonNil makes empty lists [] instead of null.
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+
+	return items
+}
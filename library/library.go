@@ -0,0 +1,260 @@
+package library
+
+import (
+	"context"
+	"strings"
+
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// Playlists returns the mirrored playlists in the order the api listed them.
+func Playlists(ctx context.Context, q *database.Queries) ([]types.SimplifiedPlaylistObject, error) {
+	rows, err := q.ListPlaylists(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]types.SimplifiedPlaylistObject, 0, len(rows))
+
+	for _, row := range rows {
+		playlists = append(playlists, PlaylistFromRow(row))
+	}
+
+	return playlists, nil
+}
+
+func PlaylistFromRow(row database.Playlist) types.SimplifiedPlaylistObject {
+	return types.SimplifiedPlaylistObject{
+		Id: row.ID,
+		Name: row.Name,
+		Description: row.Description,
+		Collaborative: row.Collaborative,
+		Public: row.Public,
+		SnapshotId: row.SnapshotID,
+		Uri: row.Uri,
+		Type: "playlist",
+		Tracks: types.SimplifiedPlaylistTrack{
+			Total: int(row.Total),
+		},
+		Owner: types.Owner{
+			Id: row.OwnerID,
+			DisplayName: types.Optional[string]{
+				Value: row.OwnerName,
+				Valid: row.OwnerName != "",
+			},
+		},
+	}
+}
+
+type trackArtistRow struct {
+	TrackUri string
+	ID string
+	Name string
+	Uri string
+}
+
+func groupArtists(rows []trackArtistRow) map[string][]types.SimplifiedArtist {
+	artists := make(map[string][]types.SimplifiedArtist)
+
+	for _, row := range rows {
+		artists[row.TrackUri] = append(artists[row.TrackUri], types.SimplifiedArtist{
+			Id: row.ID,
+			Name: row.Name,
+			Uri: row.Uri,
+			Type: "artist",
+		})
+	}
+
+	return artists
+}
+
+type trackColumns struct {
+	Uri string
+	ID string
+	Type string
+	Name string
+	ArtistNames string
+	AlbumID string
+	AlbumName string
+	AlbumUri string
+	ReleaseDate string
+	DurationMs int64
+	Popularity int64
+	Explicit bool
+	Isrc string
+	IsLocal bool
+}
+
+func (t trackColumns) item(artists map[string][]types.SimplifiedArtist) types.ItemUnion {
+	if t.Type == "episode" {
+		return types.ItemUnion{
+			Type: "episode",
+			Episode: &types.Episode{
+				Id: t.ID,
+				Name: t.Name,
+				Uri: t.Uri,
+				Type: "episode",
+				DurationMs: int(t.DurationMs),
+				ReleaseDate: t.ReleaseDate,
+				Explicit: t.Explicit,
+			},
+		}
+	}
+
+	track := t.track(artists)
+
+	return types.ItemUnion{
+		Type: "track",
+		Track: &track,
+	}
+}
+
+func (t trackColumns) track(artists map[string][]types.SimplifiedArtist) types.Track {
+	trackArtists, ok := artists[t.Uri]
+
+	// local files have no artist ids, fall back to the stored names
+	if !ok && t.ArtistNames != "" {
+		for _, name := range strings.Split(t.ArtistNames, ", ") {
+			trackArtists = append(trackArtists, types.SimplifiedArtist{ Name: name, Type: "artist" })
+		}
+	}
+
+	return types.Track{
+		Uri: t.Uri,
+		Id: t.ID,
+		Type: "track",
+		Name: t.Name,
+		Artists: trackArtists,
+		Album: types.Album{
+			Id: t.AlbumID,
+			Name: t.AlbumName,
+			Uri: t.AlbumUri,
+			ReleaseDate: t.ReleaseDate,
+		},
+		DurationMs: int(t.DurationMs),
+		Popularity: int(t.Popularity),
+		Explicit: t.Explicit,
+		ExternalIds: types.ExternalIds{
+			Isrc: t.Isrc,
+		},
+		IsLocal: t.IsLocal,
+		IsPlayable: true,
+	}
+}
+
+// PlaylistItems returns the mirrored items of a playlist in playlist order.
+func PlaylistItems(ctx context.Context, q *database.Queries, id string) ([]types.PlaylistItemUnion, error) {
+	rows, err := q.ListPlaylistItems(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	artistRows, err := q.ListTrackArtistsByPlaylist(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]trackArtistRow, 0, len(artistRows))
+
+	for _, row := range artistRows {
+		converted = append(converted, trackArtistRow(row))
+	}
+
+	artists := groupArtists(converted)
+
+	items := make([]types.PlaylistItemUnion, 0, len(rows))
+
+	for _, row := range rows {
+		columns := trackColumns{
+			Uri: row.Uri,
+			ID: row.ID,
+			Type: row.Type,
+			Name: row.Name,
+			ArtistNames: row.ArtistNames,
+			AlbumID: row.AlbumID,
+			AlbumName: row.AlbumName,
+			AlbumUri: row.AlbumUri,
+			ReleaseDate: row.ReleaseDate,
+			DurationMs: row.DurationMs,
+			Popularity: row.Popularity,
+			Explicit: row.Explicit,
+			Isrc: row.Isrc,
+			IsLocal: row.IsLocal,
+		}
+
+		item := types.PlaylistItemUnion{
+			AddedAt: types.Optional[string]{
+				Value: row.AddedAt,
+				Valid: row.AddedAt != "",
+			},
+			IsLocal: row.IsLocal,
+			Track: columns.item(artists),
+		}
+
+		if row.AddedBy != "" {
+			item.AddedBy = types.Optional[types.User]{
+				Value: types.User{ Id: row.AddedBy },
+				Valid: true,
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// SavedTracks returns the mirrored liked songs, most recently saved first.
+func SavedTracks(ctx context.Context, q *database.Queries) ([]types.SavedTrack, error) {
+	rows, err := q.ListSavedTracks(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	artistRows, err := q.ListTrackArtistsBySavedTracks(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]trackArtistRow, 0, len(artistRows))
+
+	for _, row := range artistRows {
+		converted = append(converted, trackArtistRow(row))
+	}
+
+	artists := groupArtists(converted)
+
+	tracks := make([]types.SavedTrack, 0, len(rows))
+
+	for _, row := range rows {
+		columns := trackColumns{
+			Uri: row.Uri,
+			ID: row.ID,
+			Type: row.Type,
+			Name: row.Name,
+			ArtistNames: row.ArtistNames,
+			AlbumID: row.AlbumID,
+			AlbumName: row.AlbumName,
+			AlbumUri: row.AlbumUri,
+			ReleaseDate: row.ReleaseDate,
+			DurationMs: row.DurationMs,
+			Popularity: row.Popularity,
+			Explicit: row.Explicit,
+			Isrc: row.Isrc,
+			IsLocal: row.IsLocal,
+		}
+
+		tracks = append(tracks, types.SavedTrack{
+			AddedAt: row.AddedAt,
+			Track: columns.track(artists),
+		})
+	}
+
+	return tracks, nil
+}
@@ -0,0 +1,95 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/arjunmoola/go-spotify/database"
+)
+
+const defaultSearchLimit = 50
+
+// playlistSeparator joins the names of the playlists a track is in, see the
+// IndexTracks query.
+const playlistSeparator = "\x1f"
+
+type SearchResult struct {
+	Uri string
+	Kind string
+	Name string
+	Artists string
+	Album string
+	Playlists []string
+}
+
+// RebuildSearchIndex repopulates the full text index from the mirror. It is
+// cheap enough to run after every sync.
+func RebuildSearchIndex(ctx context.Context, db *sql.DB) error {
+	s := &Syncer{ db: db }
+	return s.withTx(ctx, func(q *database.Queries) error {
+		if err := q.ClearSearchIndex(ctx); err != nil {
+			return err
+		}
+
+		if err := q.IndexTracks(ctx); err != nil {
+			return err
+		}
+
+		return q.IndexPlaylists(ctx)
+	})
+}
+
+// matchQuery turns free text into an fts5 query where every word has to
+// match the start of a token in any of the indexed columns.
+func matchQuery(s string) string {
+	var terms []string
+
+	for _, word := range strings.Fields(s) {
+		word = strings.ReplaceAll(word, `"`, `""`)
+		terms = append(terms, `"` + word + `"*`)
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func Search(ctx context.Context, q *database.Queries, query string, limit int) ([]SearchResult, error) {
+	match := matchQuery(query)
+
+	if match == "" {
+		return nil, nil
+	}
+
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	rows, err := q.SearchLibrary(ctx, database.SearchLibraryParams{
+		Query: match,
+		Limit: int64(limit),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+
+	for _, row := range rows {
+		result := SearchResult{
+			Uri: row.Uri,
+			Kind: row.Kind,
+			Name: row.Name,
+			Artists: row.Artists,
+			Album: row.Album,
+		}
+
+		if row.Kind == "track" && row.Playlists != "" {
+			result.Playlists = strings.Split(row.Playlists, playlistSeparator)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
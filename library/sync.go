@@ -0,0 +1,584 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+const savedItemsLimit = 50
+
+type SyncResult struct {
+	Playlists int
+	PlaylistsUpdated int
+	PlaylistsSkipped int
+	PlaylistsRemoved int
+	SavedTracks int
+	SavedAlbums int
+	FollowedArtists int
+	// Warnings are parts of the library that were skipped but do not fail
+	// the sync
+	Warnings []string
+}
+
+func (r SyncResult) String() string {
+	return fmt.Sprintf(
+		"%d playlists (%d updated, %d unchanged, %d removed), %d saved tracks, %d saved albums, %d followed artists",
+		r.Playlists, r.PlaylistsUpdated, r.PlaylistsSkipped, r.PlaylistsRemoved, r.SavedTracks, r.SavedAlbums, r.FollowedArtists,
+	)
+}
+
+// Syncer mirrors the current user's library into the local database. Only
+// the parts of the library that changed since the last sync are refetched.
+type Syncer struct {
+	db *sql.DB
+	client *client.Client
+	market string
+	full bool
+	progress func(string)
+}
+
+// NewSyncer returns a syncer that fetches tracks for the market, which
+// decides which of them are playable.
+func NewSyncer(db *sql.DB, c *client.Client, market string) *Syncer {
+	return &Syncer{
+		db: db,
+		client: c,
+		market: market,
+	}
+}
+
+// SetFull makes the next sync refetch everything instead of relying on
+// snapshot ids and the most recently saved items.
+func (s *Syncer) SetFull(b bool) {
+	s.full = b
+}
+
+func (s *Syncer) OnProgress(f func(string)) {
+	s.progress = f
+}
+
+func (s *Syncer) report(format string, args ...any) {
+	if s.progress != nil {
+		s.progress(fmt.Sprintf(format, args...))
+	}
+}
+
+// Sync expects ctx to carry an access token, see client.WithAccessToken. A
+// part of the library that fails does not stop the others, and the search
+// index is rebuilt from whatever was synced.
+func (s *Syncer) Sync(ctx context.Context) (SyncResult, error) {
+	var result SyncResult
+	var errs []error
+
+	if err := s.SyncPlaylists(ctx, &result); err != nil {
+		errs = append(errs, fmt.Errorf("syncing playlists: %w", err))
+	}
+
+	if err := s.SyncSavedTracks(ctx, &result); err != nil {
+		errs = append(errs, fmt.Errorf("syncing saved tracks: %w", err))
+	}
+
+	if err := s.SyncSavedAlbums(ctx, &result); err != nil {
+		errs = append(errs, fmt.Errorf("syncing saved albums: %w", err))
+	}
+
+	var spotifyErr client.SpotifyError
+
+	if err := s.SyncFollowedArtists(ctx, &result); errors.As(err, &spotifyErr) && spotifyErr.Status == http.StatusForbidden {
+		// tokens from before user-follow-read was requested lack the scope
+		result.Warnings = append(result.Warnings, "log in again to sync followed artists")
+	} else if err != nil {
+		errs = append(errs, fmt.Errorf("syncing followed artists: %w", err))
+	}
+
+	if err := RebuildSearchIndex(ctx, s.db); err != nil {
+		errs = append(errs, fmt.Errorf("rebuilding search index: %w", err))
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func (s *Syncer) withTx(ctx context.Context, f func(q *database.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := f(database.New(s.db).WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Syncer) SyncPlaylists(ctx context.Context, result *SyncResult) error {
+	playlists, err := s.client.GetAllCurrentUsersPlaylists(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	q := database.New(s.db)
+	syncedAt := time.Now().UTC().Format(time.RFC3339Nano)
+
+	result.Playlists = len(playlists)
+
+	for i, playlist := range playlists {
+		local, err := q.GetPlaylist(ctx, playlist.Id)
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err := q.UpsertPlaylist(ctx, upsertPlaylistParams(playlist, i, syncedAt)); err != nil {
+			return err
+		}
+
+		if !s.full && local.ItemsSnapshotID == playlist.SnapshotId {
+			result.PlaylistsSkipped++
+			continue
+		}
+
+		s.report("syncing playlist %s (%d/%d)", playlist.Name, i+1, len(playlists))
+
+		items, err := s.client.GetAllPlaylistItems(ctx, playlist.Id, s.market)
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", playlist.Name, err)
+		}
+
+		err = s.withTx(ctx, func(q *database.Queries) error {
+			return replacePlaylistItems(ctx, q, playlist.Id, playlist.SnapshotId, items)
+		})
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", playlist.Name, err)
+		}
+
+		result.PlaylistsUpdated++
+	}
+
+	var before int
+
+	if local, err := q.ListPlaylists(ctx); err == nil {
+		before = len(local)
+	}
+
+	if err := q.DeleteStalePlaylists(ctx, syncedAt); err != nil {
+		return err
+	}
+
+	if err := q.DeleteOrphanedPlaylistItems(ctx); err != nil {
+		return err
+	}
+
+	result.PlaylistsRemoved = max(before-len(playlists), 0)
+
+	return nil
+}
+
+func upsertPlaylistParams(p types.SimplifiedPlaylistObject, position int, syncedAt string) database.UpsertPlaylistParams {
+	return database.UpsertPlaylistParams{
+		ID: p.Id,
+		Name: p.Name,
+		Description: p.Description,
+		OwnerID: p.Owner.Id,
+		OwnerName: p.Owner.DisplayName.Value,
+		SnapshotID: p.SnapshotId,
+		Collaborative: p.Collaborative,
+		Public: p.Public,
+		Uri: p.Uri,
+		Total: int64(p.Tracks.Total),
+		Position: int64(position),
+		SyncedAt: syncedAt,
+	}
+}
+
+// ReplacePlaylistItems overwrites the mirrored items of a playlist. It is
+// used by the sync itself and by commands that modify a playlist and already
+// know its new contents.
+func ReplacePlaylistItems(ctx context.Context, db *sql.DB, id string, snapshotId string, items []types.PlaylistItemUnion) error {
+	s := &Syncer{ db: db }
+	return s.withTx(ctx, func(q *database.Queries) error {
+		return replacePlaylistItems(ctx, q, id, snapshotId, items)
+	})
+}
+
+func replacePlaylistItems(ctx context.Context, q *database.Queries, id string, snapshotId string, items []types.PlaylistItemUnion) error {
+	if err := q.DeletePlaylistItems(ctx, id); err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		uri, err := upsertItem(ctx, q, item.Track)
+
+		if err != nil {
+			return err
+		}
+
+		if uri == "" {
+			continue
+		}
+
+		err = q.InsertPlaylistItem(ctx, database.InsertPlaylistItemParams{
+			PlaylistID: id,
+			Position: int64(i),
+			TrackUri: uri,
+			AddedAt: item.AddedAt.Value,
+			AddedBy: item.AddedBy.Value.Id,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return q.SetPlaylistItemsSnapshot(ctx, database.SetPlaylistItemsSnapshotParams{
+		ItemsSnapshotID: snapshotId,
+		ID: id,
+	})
+}
+
+// upsertItem stores a track or episode and returns its uri. Items that no
+// longer resolve to a track, like removed tracks, return an empty uri.
+func upsertItem(ctx context.Context, q *database.Queries, item types.ItemUnion) (string, error) {
+	switch {
+	case item.Type == "track" && item.Track != nil:
+		return item.Track.Uri, UpsertTrack(ctx, q, *item.Track)
+	case item.Type == "episode" && item.Episode != nil:
+		episode := item.Episode
+		err := q.UpsertTrack(ctx, database.UpsertTrackParams{
+			Uri: episode.Uri,
+			ID: episode.Id,
+			Type: "episode",
+			Name: episode.Name,
+			ReleaseDate: episode.ReleaseDate,
+			DurationMs: int64(episode.DurationMs),
+			Explicit: episode.Explicit,
+		})
+		return episode.Uri, err
+	}
+
+	return "", nil
+}
+
+func UpsertTrack(ctx context.Context, q *database.Queries, track types.Track) error {
+	if track.Uri == "" {
+		return nil
+	}
+
+	names := make([]string, 0, len(track.Artists))
+
+	for _, artist := range track.Artists {
+		names = append(names, artist.Name)
+	}
+
+	err := q.UpsertTrack(ctx, database.UpsertTrackParams{
+		Uri: track.Uri,
+		ID: track.Id,
+		Type: "track",
+		Name: track.Name,
+		ArtistNames: strings.Join(names, ", "),
+		AlbumID: track.Album.Id,
+		AlbumName: track.Album.Name,
+		AlbumUri: track.Album.Uri,
+		ReleaseDate: track.Album.ReleaseDate,
+		DurationMs: int64(track.DurationMs),
+		Popularity: int64(track.Popularity),
+		Explicit: track.Explicit,
+		Isrc: track.ExternalIds.Isrc,
+		IsLocal: track.IsLocal,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := q.DeleteTrackArtists(ctx, track.Uri); err != nil {
+		return err
+	}
+
+	for i, artist := range track.Artists {
+		if artist.Id == "" {
+			continue
+		}
+
+		err := q.UpsertArtist(ctx, database.UpsertArtistParams{
+			ID: artist.Id,
+			Name: artist.Name,
+			Uri: artist.Uri,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		err = q.InsertTrackArtist(ctx, database.InsertTrackArtistParams{
+			TrackUri: track.Uri,
+			ArtistID: artist.Id,
+			Position: int64(i),
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncSavedTracks walks the saved tracks newest first and stops at the most
+// recently saved track already in the mirror. If the totals disagree
+// afterwards, tracks were removed and the whole list is refetched.
+func (s *Syncer) SyncSavedTracks(ctx context.Context, result *SyncResult) error {
+	q := database.New(s.db)
+
+	latest, err := q.GetLatestSavedTrack(ctx)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	full := s.full
+
+	for {
+		total, err := s.syncSavedTracks(ctx, latest, full)
+
+		if err != nil {
+			return err
+		}
+
+		count, err := q.CountSavedTracks(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if int(count) == total || full {
+			result.SavedTracks = int(count)
+			return nil
+		}
+
+		full = true
+	}
+}
+
+func (s *Syncer) syncSavedTracks(ctx context.Context, latest database.GetLatestSavedTrackRow, full bool) (int, error) {
+	var total int
+
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		if full {
+			if err := q.DeleteSavedTracks(ctx); err != nil {
+				return err
+			}
+		}
+
+		params := client.SavedItemsParams{
+			Limit: savedItemsLimit,
+			Market: s.market,
+		}
+
+		for {
+			page, err := s.client.GetUsersSavedTracks(ctx, params)
+
+			if err != nil {
+				return err
+			}
+
+			total = page.Total
+
+			for _, saved := range page.Items {
+				if !full && saved.Track.Uri == latest.TrackUri && saved.AddedAt == latest.AddedAt {
+					return nil
+				}
+
+				if err := UpsertTrack(ctx, q, saved.Track); err != nil {
+					return err
+				}
+
+				err := q.InsertSavedTrack(ctx, database.InsertSavedTrackParams{
+					TrackUri: saved.Track.Uri,
+					AddedAt: saved.AddedAt,
+				})
+
+				if err != nil {
+					return err
+				}
+			}
+
+			s.report("synced %d/%d saved tracks", min(params.Offset+len(page.Items), total), total)
+
+			if !page.Next.Valid || len(page.Items) == 0 {
+				return nil
+			}
+
+			params.Offset += len(page.Items)
+		}
+	})
+
+	return total, err
+}
+
+func (s *Syncer) SyncSavedAlbums(ctx context.Context, result *SyncResult) error {
+	q := database.New(s.db)
+
+	latest, err := q.GetLatestSavedAlbum(ctx)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	full := s.full
+
+	for {
+		total, err := s.syncSavedAlbums(ctx, latest, full)
+
+		if err != nil {
+			return err
+		}
+
+		count, err := q.CountSavedAlbums(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if int(count) == total || full {
+			result.SavedAlbums = int(count)
+			return nil
+		}
+
+		full = true
+	}
+}
+
+func (s *Syncer) syncSavedAlbums(ctx context.Context, latest database.GetLatestSavedAlbumRow, full bool) (int, error) {
+	var total int
+
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		if full {
+			if err := q.DeleteSavedAlbums(ctx); err != nil {
+				return err
+			}
+		}
+
+		params := client.SavedItemsParams{
+			Limit: savedItemsLimit,
+			Market: s.market,
+		}
+
+		for {
+			page, err := s.client.GetUsersSavedAlbums(ctx, params)
+
+			if err != nil {
+				return err
+			}
+
+			total = page.Total
+
+			for _, saved := range page.Items {
+				album := saved.Album
+
+				if !full && album.Id == latest.ID && saved.AddedAt == latest.AddedAt {
+					return nil
+				}
+
+				names := make([]string, 0, len(album.Artists))
+
+				for _, artist := range album.Artists {
+					names = append(names, artist.Name)
+				}
+
+				err := q.InsertSavedAlbum(ctx, database.InsertSavedAlbumParams{
+					ID: album.Id,
+					Name: album.Name,
+					Uri: album.Uri,
+					ArtistNames: strings.Join(names, ", "),
+					ReleaseDate: album.ReleaseDate,
+					TotalTracks: int64(album.TotalTracks),
+					AddedAt: saved.AddedAt,
+				})
+
+				if err != nil {
+					return err
+				}
+			}
+
+			if !page.Next.Valid || len(page.Items) == 0 {
+				return nil
+			}
+
+			params.Offset += len(page.Items)
+		}
+	})
+
+	return total, err
+}
+
+// SyncFollowedArtists always refetches the followed artists, the endpoint
+// is cursor based and has no ordering to sync incrementally against.
+func (s *Syncer) SyncFollowedArtists(ctx context.Context, result *SyncResult) error {
+	var artists []types.Artist
+
+	params := client.FollowedArtistsParams{
+		Limit: savedItemsLimit,
+	}
+
+	for {
+		page, err := s.client.GetFollowedArtists(ctx, params)
+
+		if err != nil {
+			return err
+		}
+
+		artists = append(artists, page.Artists.Items...)
+
+		if !page.Artists.Next.Valid || page.Artists.Cursors.After == "" {
+			break
+		}
+
+		params.After = page.Artists.Cursors.After
+	}
+
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		if err := q.ClearFollowedArtists(ctx); err != nil {
+			return err
+		}
+
+		for _, artist := range artists {
+			err := q.UpsertFollowedArtist(ctx, database.UpsertFollowedArtistParams{
+				ID: artist.Id,
+				Name: artist.Name,
+				Uri: artist.Uri,
+				Genres: strings.Join(artist.Genres, ","),
+				Popularity: int64(artist.Popularity),
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	result.FollowedArtists = len(artists)
+
+	return nil
+}
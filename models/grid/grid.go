@@ -185,6 +185,11 @@ func (m *Model) SetCurrentCellStyle(style lipgloss.Style) {
 	m.Styles.CurrentCell = style
 }
 
+func (m *Model) SetAccentColor(c lipgloss.TerminalColor) {
+	m.Styles.CurrentCell = m.Styles.CurrentCell.BorderForeground(c)
+	m.Styles.Focus = m.Styles.Focus.BorderForeground(c)
+}
+
 func (m *Model) SetCellDimensions(width, height int) {
 	m.cellWidth = width
 	m.cellHeight = height
@@ -232,6 +237,15 @@ func (m *Model) SetCursor(pos Position) {
 	m.pos = pos
 }
 
+// Selectable reports whether pos is a cell the cursor can move to.
+func (m Model) Selectable(pos Position) bool {
+	if pos.Row < 0 || pos.Row >= len(m.models) || m.readOnly[pos.Row] {
+		return false
+	}
+
+	return pos.Col >= 0 && pos.Col < len(m.models[pos.Row])
+}
+
 type direction int 
 
 const (
@@ -224,6 +224,12 @@ func (l *NestedList) SetWidth(w int) {
 	l.width = w
 }
 
+func (l *NestedList) SetAccentColor(c lipgloss.TerminalColor) {
+	l.Styles.selectedItem = l.Styles.selectedItem.BorderForeground(c)
+	l.Styles.selectedTitleStyle = l.Styles.selectedTitleStyle.Foreground(c)
+	l.Styles.nestedSelectedItem = l.Styles.nestedSelectedItem.Foreground(c)
+}
+
 func New(items []Item) NestedList {
 	return NestedList{
 		items: items,
@@ -311,6 +317,10 @@ func (l NestedList) GetItem(i int) Item {
 	return l.items[i]
 }
 
+func (l NestedList) Len() int {
+	return len(l.items)
+}
+
 func (l NestedList) Index() int {
 	return l.idx
 }
@@ -122,6 +122,11 @@ func (m *Model) PressButton() tea.Cmd {
 	})
 }
 
+func (m *Model) SetAccentColor(c lipgloss.TerminalColor) {
+	m.styles.selectedItem = m.styles.selectedItem.Foreground(c)
+	m.styles.buttonPressedStyle = m.styles.buttonPressedStyle.Background(c)
+}
+
 func (m *Model) SetButtonPressDuration(d time.Duration) {
 	m.buttonPressDur = d
 }
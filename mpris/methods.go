@@ -0,0 +1,217 @@
+package mpris
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	errUnknownInterface = "org.freedesktop.DBus.Error.UnknownInterface"
+	errUnknownProperty = "org.freedesktop.DBus.Error.UnknownProperty"
+	errReadOnly = "org.freedesktop.DBus.Error.PropertyReadOnly"
+	errInvalidArgs = "org.freedesktop.DBus.Error.InvalidArgs"
+	errNotSupported = "org.freedesktop.DBus.Error.NotSupported"
+)
+
+func busError(name, format string, args ...any) *dbus.Error {
+	return dbus.NewError(name, []any{ fmt.Sprintf(format, args...) })
+}
+
+// root is org.mpris.MediaPlayer2. gsp has no window to raise and the
+// daemon is not quit over the bus.
+type root struct{}
+
+func (root) Raise() *dbus.Error {
+	return nil
+}
+
+func (root) Quit() *dbus.Error {
+	return busError(errNotSupported, "stop gsp daemon with gsp daemon stop")
+}
+
+// playerMethods is org.mpris.MediaPlayer2.Player.
+type playerMethods struct {
+	s *Server
+}
+
+func (p playerMethods) Next() *dbus.Error {
+	return p.s.call("Next", func(ctx context.Context) error {
+		return p.s.player.Skip(ctx, "next")
+	})
+}
+
+func (p playerMethods) Previous() *dbus.Error {
+	return p.s.call("Previous", func(ctx context.Context) error {
+		return p.s.player.Skip(ctx, "previous")
+	})
+}
+
+func (p playerMethods) Pause() *dbus.Error {
+	return p.s.call("Pause", p.s.player.Pause)
+}
+
+func (p playerMethods) PlayPause() *dbus.Error {
+	return p.s.call("PlayPause", p.s.player.Toggle)
+}
+
+// Stop pauses, spotify connect has no stopped state to go back to.
+func (p playerMethods) Stop() *dbus.Error {
+	return p.s.call("Stop", p.s.player.Pause)
+}
+
+func (p playerMethods) Play() *dbus.Error {
+	return p.s.call("Play", p.s.player.Play)
+}
+
+// SeekBy is the Seek method of the bus, named apart from io.Seeker. It
+// moves by offset microseconds, past the end skips to the next track like
+// the spec asks.
+func (p playerMethods) SeekBy(offset int64) *dbus.Error {
+	return p.s.call("Seek", func(ctx context.Context) error {
+		n, err := p.s.player.NowPlaying(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		position := int64(n.ProgressMs) + offset/1000
+
+		if position >= int64(n.DurationMs) {
+			return p.s.player.Skip(ctx, "next")
+		}
+
+		return p.s.player.Seek(ctx, int(max(position, 0)))
+	})
+}
+
+// SetPosition is ignored when the track changed since the client looked,
+// as the spec asks.
+func (p playerMethods) SetPosition(track dbus.ObjectPath, position int64) *dbus.Error {
+	return p.s.call("SetPosition", func(ctx context.Context) error {
+		n, err := p.s.player.NowPlaying(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		ms := position/1000
+
+		if track != trackId(n) || ms < 0 || ms > int64(n.DurationMs) {
+			return nil
+		}
+
+		return p.s.player.Seek(ctx, int(ms))
+	})
+}
+
+func (p playerMethods) OpenUri(uri string) *dbus.Error {
+	return busError(errNotSupported, "opening uris is not supported")
+}
+
+// properties is org.freedesktop.DBus.Properties. It asks the player on
+// every call so the position is always current.
+type properties struct {
+	s *Server
+}
+
+func (p properties) all(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	switch iface {
+	case rootInterface:
+		return rootProperties(), nil
+	case playerInterface:
+		n, err := p.s.nowPlaying()
+
+		if err != nil {
+			// an empty state reads as stopped rather than failing the client
+			p.s.logger.Error("mpris could not get the playback state", "err", err)
+			n = output.NowPlaying{}
+		}
+
+		return playerProperties(n), nil
+	}
+
+	return nil, busError(errUnknownInterface, "unknown interface %s", iface)
+}
+
+func (p properties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	props, err := p.all(iface)
+
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+
+	v, ok := props[name]
+
+	if !ok {
+		return dbus.Variant{}, busError(errUnknownProperty, "unknown property %s", name)
+	}
+
+	return v, nil
+}
+
+func (p properties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	return p.all(iface)
+}
+
+func (p properties) Set(iface, name string, value dbus.Variant) *dbus.Error {
+	if iface != playerInterface {
+		if _, err := p.all(iface); err != nil {
+			return err
+		}
+
+		return busError(errReadOnly, "%s is read only", name)
+	}
+
+	switch name {
+	case "Volume":
+		v, ok := value.Value().(float64)
+
+		if !ok {
+			return busError(errInvalidArgs, "Volume must be a double")
+		}
+
+		percent := int(min(max(v, 0), 1)*100 + 0.5)
+
+		return p.s.call("Set Volume", func(ctx context.Context) error {
+			return p.s.player.SetVolume(ctx, percent)
+		})
+	case "Shuffle":
+		on, ok := value.Value().(bool)
+
+		if !ok {
+			return busError(errInvalidArgs, "Shuffle must be a boolean")
+		}
+
+		return p.s.call("Set Shuffle", func(ctx context.Context) error {
+			return p.s.player.SetShuffle(ctx, on)
+		})
+	case "LoopStatus":
+		status, ok := value.Value().(string)
+
+		if !ok {
+			return busError(errInvalidArgs, "LoopStatus must be a string")
+		}
+
+		for state, s := range loopStatuses {
+			if s == status {
+				return p.s.call("Set LoopStatus", func(ctx context.Context) error {
+					return p.s.player.SetRepeat(ctx, state)
+				})
+			}
+		}
+
+		return busError(errInvalidArgs, "LoopStatus must be None, Track or Playlist")
+	case "Rate":
+		// spotify only plays at 1.0, the spec lets a player ignore other rates
+		return nil
+	}
+
+	if _, ok := playerProperties(output.NowPlaying{})[name]; ok {
+		return busError(errReadOnly, "%s is read only", name)
+	}
+
+	return busError(errUnknownProperty, "unknown property %s", name)
+}
@@ -0,0 +1,305 @@
+// Package mpris exposes the player as an org.mpris.MediaPlayer2 service on
+// the session bus, so media keys, playerctl and desktop widgets control the
+// device spotify connect is playing on.
+//
+// The bus is the one in DBUS_SESSION_BUS_ADDRESS, which also lets it run
+// against a private dbus-daemon.
+package mpris
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/poller"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const (
+	objectPath = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	busPrefix = "org.mpris.MediaPlayer2.gsp"
+	rootInterface = "org.mpris.MediaPlayer2"
+	playerInterface = "org.mpris.MediaPlayer2.Player"
+	propertiesInterface = "org.freedesktop.DBus.Properties"
+	noTrack = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+)
+
+// callTimeout bounds a call into the player so a slow spotify does not
+// hang the caller on the bus.
+const callTimeout = 10*time.Second
+
+// Player is what the bus controls.
+type Player interface {
+	NowPlaying(ctx context.Context) (output.NowPlaying, error)
+	Play(ctx context.Context) error
+	Pause(ctx context.Context) error
+	Toggle(ctx context.Context) error
+	Skip(ctx context.Context, direction string) error
+	Seek(ctx context.Context, positionMs int) error
+	SetVolume(ctx context.Context, percent int) error
+	SetShuffle(ctx context.Context, on bool) error
+	// SetRepeat takes the states of spotify: off, track or context.
+	SetRepeat(ctx context.Context, state string) error
+}
+
+type Server struct {
+	conn *dbus.Conn
+	player Player
+	name string
+	logger *slog.Logger
+
+	mu sync.Mutex
+	published map[string]dbus.Variant
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// BusName is the name the player of a profile owns. Every profile gets its
+// own so several can run side by side.
+func BusName(profile string) string {
+	if profile == "" {
+		return busPrefix
+	}
+
+	return busPrefix + ".profile_" + invalidNameChars.ReplaceAllString(profile, "_")
+}
+
+// Serve connects to the session bus and exports the player under the bus
+// name of profile.
+func Serve(player Player, profile string, logger *slog.Logger) (*Server, error) {
+	conn, err := dbus.ConnectSessionBus()
+
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the session bus: %w", err)
+	}
+
+	s := &Server{
+		conn: conn,
+		player: player,
+		name: BusName(profile),
+		logger: logger,
+	}
+
+	exports := []struct {
+		v any
+		names map[string]string
+		iface string
+	}{
+		{ root{}, nil, rootInterface },
+		{ playerMethods{ s }, map[string]string{ "SeekBy": "Seek" }, playerInterface },
+		{ properties{ s }, nil, propertiesInterface },
+		{ introspect.Introspectable(introspection), nil, "org.freedesktop.DBus.Introspectable" },
+	}
+
+	for _, e := range exports {
+		if err := conn.ExportWithMap(e.v, e.names, objectPath, e.iface); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	reply, err := conn.RequestName(s.name, dbus.NameFlagDoNotQueue)
+
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("%s is already taken, is another gsp daemon running?", s.name)
+	}
+
+	return s, nil
+}
+
+func (s *Server) Name() string {
+	return s.name
+}
+
+func (s *Server) Close() error {
+	s.conn.ReleaseName(s.name)
+	return s.conn.Close()
+}
+
+// Update tells the bus about the new playback state and the events the
+// poller found in it. Changed properties are sent as PropertiesChanged, a
+// seek as Seeked.
+func (s *Server) Update(n output.NowPlaying, events []poller.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	props := playerProperties(n)
+	changed := make(map[string]dbus.Variant)
+
+	for name, v := range props {
+		// clients ask for the position, it is never sent
+		if name == "Position" {
+			continue
+		}
+
+		if old, ok := s.published[name]; !ok || !reflect.DeepEqual(old.Value(), v.Value()) {
+			changed[name] = v
+		}
+	}
+
+	s.published = props
+
+	if len(changed) > 0 {
+		if err := s.conn.Emit(objectPath, propertiesInterface+".PropertiesChanged", playerInterface, changed, []string{}); err != nil {
+			s.logger.Error("could not emit PropertiesChanged", "err", err)
+		}
+	}
+
+	for _, e := range events {
+		if e.Kind != poller.Seeked {
+			continue
+		}
+
+		if err := s.conn.Emit(objectPath, playerInterface+".Seeked", microseconds(e.State.ProgressMs)); err != nil {
+			s.logger.Error("could not emit Seeked", "err", err)
+		}
+	}
+}
+
+func (s *Server) nowPlaying() (output.NowPlaying, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	return s.player.NowPlaying(ctx)
+}
+
+// call runs f on the player and turns its error into a dbus error.
+func (s *Server) call(method string, f func(ctx context.Context) error) *dbus.Error {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	if err := f(ctx); err != nil {
+		s.logger.Error("mpris call failed", "method", method, "err", err)
+		return dbus.MakeFailedError(err)
+	}
+
+	return nil
+}
+
+func microseconds(ms int) int64 {
+	return int64(ms)*1000
+}
+
+func trackId(n output.NowPlaying) dbus.ObjectPath {
+	parts := strings.Split(n.Uri, ":")
+
+	if n.Uri == "" || len(parts) < 3 {
+		return noTrack
+	}
+
+	path := dbus.ObjectPath("/org/gsp/track/" + invalidNameChars.ReplaceAllString(parts[len(parts)-1], "_"))
+
+	if !path.IsValid() {
+		return noTrack
+	}
+
+	return path
+}
+
+func metadata(n output.NowPlaying) map[string]dbus.Variant {
+	m := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(trackId(n)),
+	}
+
+	if n.Uri == "" {
+		return m
+	}
+
+	m["mpris:length"] = dbus.MakeVariant(microseconds(n.DurationMs))
+	m["xesam:title"] = dbus.MakeVariant(n.Title)
+	m["xesam:url"] = dbus.MakeVariant(n.Uri)
+
+	if len(n.Artists) > 0 {
+		m["xesam:artist"] = dbus.MakeVariant(n.Artists)
+	}
+
+	if n.Album != "" {
+		m["xesam:album"] = dbus.MakeVariant(n.Album)
+	}
+
+	if n.Image != "" {
+		m["mpris:artUrl"] = dbus.MakeVariant(n.Image)
+	}
+
+	return m
+}
+
+func playbackStatus(n output.NowPlaying) string {
+	switch {
+	case n.Uri == "":
+		return "Stopped"
+	case n.Playing:
+		return "Playing"
+	}
+
+	return "Paused"
+}
+
+var loopStatuses = map[string]string{
+	"off": "None",
+	"track": "Track",
+	"context": "Playlist",
+}
+
+func loopStatus(n output.NowPlaying) string {
+	if status, ok := loopStatuses[n.Repeat]; ok {
+		return status
+	}
+
+	return "None"
+}
+
+func volume(n output.NowPlaying) float64 {
+	if n.Device.Volume == nil {
+		return 0
+	}
+
+	return float64(*n.Device.Volume)/100
+}
+
+func playerProperties(n output.NowPlaying) map[string]dbus.Variant {
+	controllable := n.Device.Id != ""
+	loaded := controllable && n.Uri != ""
+
+	return map[string]dbus.Variant{
+		"PlaybackStatus": dbus.MakeVariant(playbackStatus(n)),
+		"LoopStatus": dbus.MakeVariant(loopStatus(n)),
+		"Rate": dbus.MakeVariant(1.0),
+		"Shuffle": dbus.MakeVariant(n.Shuffle),
+		"Metadata": dbus.MakeVariant(metadata(n)),
+		"Volume": dbus.MakeVariant(volume(n)),
+		"Position": dbus.MakeVariant(microseconds(n.ProgressMs)),
+		"MinimumRate": dbus.MakeVariant(1.0),
+		"MaximumRate": dbus.MakeVariant(1.0),
+		"CanGoNext": dbus.MakeVariant(loaded),
+		"CanGoPrevious": dbus.MakeVariant(loaded),
+		"CanPlay": dbus.MakeVariant(loaded),
+		"CanPause": dbus.MakeVariant(loaded),
+		"CanSeek": dbus.MakeVariant(loaded),
+		"CanControl": dbus.MakeVariant(true),
+	}
+}
+
+func rootProperties() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"CanQuit": dbus.MakeVariant(false),
+		"CanRaise": dbus.MakeVariant(false),
+		"HasTrackList": dbus.MakeVariant(false),
+		"Identity": dbus.MakeVariant("gsp"),
+		"SupportedUriSchemes": dbus.MakeVariant([]string{}),
+		"SupportedMimeTypes": dbus.MakeVariant([]string{}),
+	}
+}
@@ -0,0 +1,193 @@
+package mpris
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/poller"
+	"github.com/godbus/dbus/v5"
+)
+
+// sessionBus starts a private dbus-daemon and points the session bus at it.
+func sessionBus(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+
+	cmd := exec.Command("dbus-daemon", "--session", "--print-address", "--nofork")
+	stdout, err := cmd.StdoutPipe()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	addr, err := bufio.NewReader(stdout).ReadString('\n')
+
+	if err != nil {
+		t.Fatalf("could not read the address of dbus-daemon: %v", err)
+	}
+
+	addr = strings.TrimSpace(addr)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", addr)
+
+	return addr
+}
+
+type fakePlayer struct {
+	mu sync.Mutex
+	state output.NowPlaying
+	toggles int
+}
+
+func (p *fakePlayer) NowPlaying(ctx context.Context) (output.NowPlaying, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.state, nil
+}
+
+func (p *fakePlayer) Toggle(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.toggles++
+
+	return nil
+}
+
+func (p *fakePlayer) toggled() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.toggles
+}
+
+func (p *fakePlayer) Play(ctx context.Context) error { return nil }
+func (p *fakePlayer) Pause(ctx context.Context) error { return nil }
+func (p *fakePlayer) Skip(ctx context.Context, direction string) error { return nil }
+func (p *fakePlayer) Seek(ctx context.Context, positionMs int) error { return nil }
+func (p *fakePlayer) SetVolume(ctx context.Context, percent int) error { return nil }
+func (p *fakePlayer) SetShuffle(ctx context.Context, on bool) error { return nil }
+func (p *fakePlayer) SetRepeat(ctx context.Context, state string) error { return nil }
+
+func nextSignal(t *testing.T, signals chan *dbus.Signal, name string) *dbus.Signal {
+	t.Helper()
+
+	timeout := time.After(5*time.Second)
+
+	for {
+		select {
+		case s := <-signals:
+			if s.Name == name {
+				return s
+			}
+		case <-timeout:
+			t.Fatalf("no %s signal", name)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	addr := sessionBus(t)
+
+	volume := 40
+	playing := output.NowPlaying{
+		Playing: true,
+		Uri: "spotify:track:a",
+		Title: "a",
+		Device: output.Device{ Id: "device", Volume: &volume },
+	}
+	player := &fakePlayer{ state: playing }
+
+	server, err := Serve(player, "test", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer server.Close()
+
+	conn, err := dbus.Connect(addr)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	obj := conn.Object(server.Name(), objectPath)
+
+	var props map[string]dbus.Variant
+
+	if err := obj.Call(propertiesInterface+".GetAll", 0, playerInterface).Store(&props); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := props["PlaybackStatus"].Value(); status != "Playing" {
+		t.Errorf("PlaybackStatus %v, want Playing", status)
+	}
+
+	if v := props["Volume"].Value(); v != 0.4 {
+		t.Errorf("Volume %v, want 0.4", v)
+	}
+
+	if err := obj.Call(playerInterface+".PlayPause", 0).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if toggles := player.toggled(); toggles != 1 {
+		t.Errorf("PlayPause toggled %d times, want once", toggles)
+	}
+
+	if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(objectPath)); err != nil {
+		t.Fatal(err)
+	}
+
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+
+	server.Update(playing, nil)
+	nextSignal(t, signals, propertiesInterface+".PropertiesChanged")
+
+	paused := playing
+	paused.Playing = false
+	paused.ProgressMs = 60000
+
+	server.Update(paused, []poller.Event{{ Kind: poller.Seeked, State: paused }})
+
+	changed := nextSignal(t, signals, propertiesInterface+".PropertiesChanged")
+	values, _ := changed.Body[1].(map[string]dbus.Variant)
+
+	if status := values["PlaybackStatus"].Value(); status != "Paused" {
+		t.Errorf("PropertiesChanged PlaybackStatus %v, want Paused", status)
+	}
+
+	if _, ok := values["Volume"]; ok {
+		t.Errorf("PropertiesChanged sent the unchanged Volume")
+	}
+
+	seeked := nextSignal(t, signals, playerInterface+".Seeked")
+
+	if position := seeked.Body[0]; position != int64(60000000) {
+		t.Errorf("Seeked to %v, want 60000000", position)
+	}
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// coverMaxAge is how long a cover nobody showed stays in the cache.
+const coverMaxAge = 30*24*time.Hour
+
+// covers caches cover art on disk, notification servers want a file.
+type covers struct {
+	dir string
+	client *http.Client
+}
+
+func newCovers(dir string, logger *slog.Logger) *covers {
+	c := &covers{
+		dir: dir,
+		client: &http.Client{},
+	}
+
+	if err := c.prune(); err != nil {
+		logger.Error("could not prune the cover cache", "err", err)
+	}
+
+	return c
+}
+
+// get returns the path of the cover at url, downloading it the first time.
+func (c *covers) get(ctx context.Context, url string) (string, error) {
+	path := filepath.Join(c.dir, fmt.Sprintf("%x.jpg", sha1.Sum([]byte(url))))
+
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return path, nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0777); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
+	// written next to the cover first so a failed download leaves no
+	// broken file behind
+	tmp, err := os.CreateTemp(c.dir, "cover-*")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	return path, os.Rename(tmp.Name(), path)
+}
+
+// prune removes the covers not shown for coverMaxAge.
+func (c *covers) prune() error {
+	entries, err := os.ReadDir(c.dir)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+
+		if err != nil {
+			continue
+		}
+
+		if time.Since(info.ModTime()) > coverMaxAge {
+			os.Remove(filepath.Join(c.dir, entry.Name()))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+// Package notify shows a desktop notification when the track changes,
+// through org.freedesktop.Notifications on the session bus. A burst of
+// skips shows only the track it ended on, and every notification replaces
+// the one before it.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName = "org.freedesktop.Notifications"
+	objectPath = dbus.ObjectPath("/org/freedesktop/Notifications")
+	iface = "org.freedesktop.Notifications"
+	appName = "gsp"
+)
+
+// coverTimeout bounds downloading a cover, a notification without one is
+// better than a late one.
+const coverTimeout = 5*time.Second
+
+// Action is a button of the notification.
+type Action string
+
+const (
+	Skip Action = "skip"
+	Like Action = "like"
+)
+
+type Options struct {
+	// MinInterval is the least time between two notifications.
+	MinInterval time.Duration
+	// Timeout is how long a notification stays, 0 for the default of the
+	// desktop.
+	Timeout time.Duration
+	// Actions adds skip and like buttons when the desktop supports them.
+	Actions bool
+	// CoverDir is where cover art is cached, empty to show none.
+	CoverDir string
+	// OnAction is called with the button pressed and the track the
+	// notification was for.
+	OnAction func(action Action, n output.NowPlaying)
+}
+
+type Notifier struct {
+	conn *dbus.Conn
+	obj dbus.BusObject
+	opts Options
+	logger *slog.Logger
+	actions bool
+	markup bool
+	covers *covers
+
+	mu sync.Mutex
+	pending output.NowPlaying
+	timer *time.Timer
+	lastAt time.Time
+	lastId uint32
+	shown map[uint32]output.NowPlaying
+}
+
+// OverSSH reports whether gsp runs in an ssh session, where a notification
+// would pop up on a desktop nobody is looking at.
+func OverSSH() bool {
+	for _, key := range []string{ "SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY" } {
+		if os.Getenv(key) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// New connects to the notification server of the session bus.
+func New(opts Options, logger *slog.Logger) (*Notifier, error) {
+	conn, err := dbus.ConnectSessionBus()
+
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the session bus: %w", err)
+	}
+
+	n := &Notifier{
+		conn: conn,
+		obj: conn.Object(busName, objectPath),
+		opts: opts,
+		logger: logger,
+		shown: make(map[uint32]output.NowPlaying),
+	}
+
+	var capabilities []string
+
+	if err := n.obj.Call(iface + ".GetCapabilities", 0).Store(&capabilities); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("no notification server on the session bus: %w", err)
+	}
+
+	n.actions = opts.Actions && opts.OnAction != nil && slices.Contains(capabilities, "actions")
+	n.markup = slices.Contains(capabilities, "body-markup")
+
+	if opts.CoverDir != "" {
+		n.covers = newCovers(opts.CoverDir, logger)
+	}
+
+	if n.actions {
+		if err := n.listen(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// Close closes the last notification and disconnects from the bus.
+func (n *Notifier) Close() error {
+	n.mu.Lock()
+
+	if n.timer != nil {
+		n.timer.Stop()
+	}
+
+	id := n.lastId
+	n.mu.Unlock()
+
+	if id != 0 {
+		n.obj.Call(iface + ".CloseNotification", 0, id)
+	}
+
+	return n.conn.Close()
+}
+
+// TrackChanged shows n, or the track after it when more changes follow
+// within the min interval. It does not block.
+func (n *Notifier) TrackChanged(np output.NowPlaying) {
+	if np.Uri == "" {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.pending = np
+
+	if n.timer != nil {
+		// the notification already waiting shows the latest track
+		return
+	}
+
+	wait := max(n.opts.MinInterval - time.Since(n.lastAt), 0)
+	n.timer = time.AfterFunc(wait, n.flush)
+}
+
+func (n *Notifier) flush() {
+	n.mu.Lock()
+	np := n.pending
+	replaces := n.lastId
+	n.timer = nil
+	n.lastAt = time.Now()
+	n.mu.Unlock()
+
+	id, err := n.show(np, replaces)
+
+	if err != nil {
+		n.logger.Error("could not show the notification", "err", err)
+		return
+	}
+
+	n.mu.Lock()
+	delete(n.shown, replaces)
+	n.lastId = id
+	n.shown[id] = np
+	n.mu.Unlock()
+}
+
+func (n *Notifier) show(np output.NowPlaying, replaces uint32) (uint32, error) {
+	body := strings.Join(np.Artists, ", ")
+
+	if np.Album != "" {
+		body += "\n" + np.Album
+	}
+
+	if n.markup {
+		body = html.EscapeString(body)
+	}
+
+	hints := map[string]dbus.Variant{
+		"category": dbus.MakeVariant("x-gnome.music"),
+		"desktop-entry": dbus.MakeVariant(appName),
+	}
+
+	if n.covers != nil && np.Image != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), coverTimeout)
+		path, err := n.covers.get(ctx, np.Image)
+		cancel()
+
+		if err != nil {
+			n.logger.Error("could not get the cover for the notification", "err", err)
+		} else {
+			hints["image-path"] = dbus.MakeVariant(path)
+		}
+	}
+
+	var actions []string
+
+	if n.actions {
+		actions = append(actions, string(Skip), "Skip")
+
+		if np.Type == "track" {
+			actions = append(actions, string(Like), "Like")
+		}
+	}
+
+	timeout := int32(-1)
+
+	if n.opts.Timeout > 0 {
+		timeout = int32(n.opts.Timeout.Milliseconds())
+	}
+
+	var id uint32
+
+	err := n.obj.Call(iface + ".Notify", 0, appName, replaces, "", np.Title, body, actions, hints, timeout).Store(&id)
+
+	return id, err
+}
+
+// listen calls OnAction for the buttons pressed on our notifications.
+func (n *Notifier) listen() error {
+	err := n.conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(objectPath),
+		dbus.WithMatchInterface(iface),
+	)
+
+	if err != nil {
+		return fmt.Errorf("could not listen for notification actions: %w", err)
+	}
+
+	signals := make(chan *dbus.Signal, 8)
+	n.conn.Signal(signals)
+
+	go func() {
+		for signal := range signals {
+			n.handle(signal)
+		}
+	}()
+
+	return nil
+}
+
+func (n *Notifier) handle(signal *dbus.Signal) {
+	if len(signal.Body) < 2 {
+		return
+	}
+
+	id, ok := signal.Body[0].(uint32)
+
+	if !ok {
+		return
+	}
+
+	n.mu.Lock()
+	np, ours := n.shown[id]
+
+	if ours && signal.Name == iface + ".NotificationClosed" {
+		delete(n.shown, id)
+	}
+
+	n.mu.Unlock()
+
+	if !ours || signal.Name != iface + ".ActionInvoked" {
+		return
+	}
+
+	if action, ok := signal.Body[1].(string); ok && (Action(action) == Skip || Action(action) == Like) {
+		n.opts.OnAction(Action(action), np)
+	}
+}
@@ -0,0 +1,163 @@
+// Package output writes the results of cli commands as text for people or
+// as json, ndjson, tsv or a go template for scripts.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	FormatNDJSON Format = "ndjson"
+	FormatTSV Format = "tsv"
+)
+
+var Formats = []Format{ FormatText, FormatJSON, FormatNDJSON, FormatTSV }
+
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(s))
+
+	if !slices.Contains(Formats, f) {
+		return "", fmt.Errorf("unknown output format %q, use one of text, json, ndjson or tsv", s)
+	}
+
+	return f, nil
+}
+
+// Record is a result with a stable json schema. Fields are its columns in
+// tsv output, in a fixed order.
+type Record interface {
+	Fields() []string
+}
+
+var funcs = template.FuncMap{
+	"join": func(sep string, s []string) string {
+		return strings.Join(s, sep)
+	},
+	"duration": func(ms int) string {
+		return FormatDuration(ms)
+	},
+}
+
+// ParseTemplate parses a template given with --format. It is executed once
+// per record with the fields of the record, e.g. {{.Title}}.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("format").Funcs(funcs).Parse(text)
+}
+
+// Printer writes records in the format chosen on the command line. A
+// template takes precedence over the format.
+type Printer struct {
+	w io.Writer
+	format Format
+	tmpl *template.Template
+}
+
+func New(w io.Writer, format Format, tmpl *template.Template) *Printer {
+	if format == "" {
+		format = FormatText
+	}
+
+	return &Printer{
+		w: w,
+		format: format,
+		tmpl: tmpl,
+	}
+}
+
+// Text reports whether the records are written for people, in which case
+// the caller prints them itself.
+func (p *Printer) Text() bool {
+	return p.format == FormatText && p.tmpl == nil
+}
+
+// Print writes a single record, like what is playing now. Text output is
+// left to the caller.
+func Print[T Record](p *Printer, v T) error {
+	switch {
+	case p.tmpl != nil:
+		return p.execute(v)
+	case p.format == FormatJSON:
+		return p.json(v, "  ")
+	case p.format == FormatNDJSON:
+		return p.json(v, "")
+	case p.format == FormatTSV:
+		return p.tsv(v)
+	}
+
+	return nil
+}
+
+// List writes a list of records. An empty list is written as [] in json so
+// scripts never see null.
+func List[T Record](p *Printer, items []T) error {
+	if p.tmpl == nil && p.format == FormatJSON {
+		if items == nil {
+			items = []T{}
+		}
+
+		return p.json(items, "  ")
+	}
+
+	for _, item := range items {
+		if err := Print(p, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Printer) json(v any, indent string) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", indent)
+	return enc.Encode(v)
+}
+
+func (p *Printer) execute(v any) error {
+	if err := p.tmpl.Execute(p.w, v); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(p.w)
+
+	return err
+}
+
+var tsvEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+func (p *Printer) tsv(r Record) error {
+	fields := r.Fields()
+
+	for i, field := range fields {
+		fields[i] = tsvEscaper.Replace(field)
+	}
+
+	_, err := fmt.Fprintln(p.w, strings.Join(fields, "\t"))
+
+	return err
+}
+
+// FormatDuration formats milliseconds like a player does, e.g. 3:07 or
+// 1:02:45.
+func FormatDuration(ms int) string {
+	d := time.Duration(ms) * time.Millisecond
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+
+	return fmt.Sprintf("%d:%02d", m, s)
+}
@@ -0,0 +1,403 @@
+package output
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// The records below are the json schemas of gsp. Fields may be added but are
+// never renamed or removed.
+
+// NowPlaying is what is playing on the active device. Type is empty when
+// nothing is playing.
+type NowPlaying struct {
+	Playing bool `json:"playing"`
+	Type string `json:"type"`
+	Uri string `json:"uri"`
+	Title string `json:"title"`
+	Artists []string `json:"artists"`
+	Album string `json:"album"`
+	DurationMs int `json:"duration_ms"`
+	ProgressMs int `json:"progress_ms"`
+	Shuffle bool `json:"shuffle"`
+	Repeat string `json:"repeat"`
+	Context string `json:"context"`
+	Device Device `json:"device"`
+	Image string `json:"image,omitempty"`
+}
+
+func NewNowPlaying(c types.CurrentlyPlaying) NowPlaying {
+	n := NowPlaying{
+		Playing: c.IsPlaying,
+		Artists: []string{},
+		ProgressMs: c.ProgressMs.Value,
+		Shuffle: c.ShuffleState,
+		Repeat: c.RepeatState,
+		Device: NewDevice(c.Device),
+	}
+
+	if c.Context.Valid {
+		n.Context = c.Context.Value.Uri
+	}
+
+	if c.Item.Valid {
+		item := NewTrack(c.Item.Value)
+		n.Type = item.Type
+		n.Uri = item.Uri
+		n.Title = item.Title
+		n.Artists = item.Artists
+		n.Album = item.Album
+		n.DurationMs = item.DurationMs
+		n.Image = item.Image
+	}
+
+	return n
+}
+
+func (n NowPlaying) Fields() []string {
+	return []string{
+		strconv.FormatBool(n.Playing),
+		n.Type,
+		n.Uri,
+		n.Title,
+		strings.Join(n.Artists, ", "),
+		n.Album,
+		strconv.Itoa(n.DurationMs),
+		strconv.Itoa(n.ProgressMs),
+		strconv.FormatBool(n.Shuffle),
+		n.Repeat,
+		n.Context,
+		n.Device.Name,
+	}
+}
+
+// Device is a spotify connect device. Volume is null for devices that do
+// not report it.
+type Device struct {
+	Id string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Active bool `json:"active"`
+	Volume *int `json:"volume"`
+}
+
+func NewDevice(d types.Device) Device {
+	device := Device{
+		Id: d.Id.Value,
+		Name: d.Name,
+		Type: d.Type,
+		Active: d.IsActive,
+	}
+
+	if d.VolumePercent.Valid {
+		volume := d.VolumePercent.Value
+		device.Volume = &volume
+	}
+
+	return device
+}
+
+func (d Device) Fields() []string {
+	volume := ""
+
+	if d.Volume != nil {
+		volume = strconv.Itoa(*d.Volume)
+	}
+
+	return []string{ d.Id, d.Name, d.Type, strconv.FormatBool(d.Active), volume }
+}
+
+// Track is a track or an episode. Episodes have no artists or album.
+type Track struct {
+	Type string `json:"type"`
+	Uri string `json:"uri"`
+	Title string `json:"title"`
+	Artists []string `json:"artists"`
+	Album string `json:"album"`
+	DurationMs int `json:"duration_ms"`
+	// Image is the url of the largest cover art, empty when there is none
+	Image string `json:"image,omitempty"`
+}
+
+func NewTrack(item types.ItemUnion) Track {
+	t := Track{
+		Type: item.Type,
+		Artists: []string{},
+	}
+
+	switch {
+	case item.Track != nil:
+		t.Uri = item.Track.Uri
+		t.Title = item.Track.Name
+		t.Album = item.Track.Album.Name
+		t.DurationMs = item.Track.DurationMs
+		t.Image = largestImage(item.Track.Album.Images)
+
+		for _, artist := range item.Track.Artists {
+			t.Artists = append(t.Artists, artist.Name)
+		}
+	case item.Episode != nil:
+		t.Uri = item.Episode.Uri
+		t.Title = item.Episode.Name
+		t.DurationMs = item.Episode.DurationMs
+		t.Image = largestImage(item.Episode.Images)
+	}
+
+	return t
+}
+
+func largestImage(images []types.Image) string {
+	if len(images) == 0 {
+		return ""
+	}
+
+	return images[0].Url
+}
+
+func (t Track) Fields() []string {
+	return []string{ t.Type, t.Uri, t.Title, strings.Join(t.Artists, ", "), t.Album, strconv.Itoa(t.DurationMs) }
+}
+
+// QueueItem is an item of the queue. Position 0 is what is playing now.
+type QueueItem struct {
+	Position int `json:"position"`
+	Track
+}
+
+func NewQueue(q types.UsersQueue) []QueueItem {
+	var items []QueueItem
+
+	if q.CurrentlyPlaying.Valid {
+		items = append(items, QueueItem{ Position: 0, Track: NewTrack(q.CurrentlyPlaying.Value) })
+	}
+
+	for i, item := range q.Queue {
+		items = append(items, QueueItem{ Position: i + 1, Track: NewTrack(item) })
+	}
+
+	return items
+}
+
+func (q QueueItem) Fields() []string {
+	return append([]string{ strconv.Itoa(q.Position) }, q.Track.Fields()...)
+}
+
+type Playlist struct {
+	Id string `json:"id"`
+	Uri string `json:"uri"`
+	Name string `json:"name"`
+	Owner string `json:"owner"`
+	Tracks int `json:"tracks"`
+	Public bool `json:"public"`
+	Collaborative bool `json:"collaborative"`
+	SnapshotId string `json:"snapshot_id"`
+}
+
+func NewPlaylist(p types.SimplifiedPlaylistObject) Playlist {
+	owner := p.Owner.DisplayName.Value
+
+	if owner == "" {
+		owner = p.Owner.Id
+	}
+
+	return Playlist{
+		Id: p.Id,
+		Uri: p.Uri,
+		Name: p.Name,
+		Owner: owner,
+		Tracks: p.Tracks.Total,
+		Public: p.Public,
+		Collaborative: p.Collaborative,
+		SnapshotId: p.SnapshotId,
+	}
+}
+
+func (p Playlist) Fields() []string {
+	return []string{ p.Id, p.Uri, p.Name, p.Owner, strconv.Itoa(p.Tracks), strconv.FormatBool(p.Public), strconv.FormatBool(p.Collaborative), p.SnapshotId }
+}
+
+// SearchResult is a track, album, artist or playlist found by a search.
+// Playlists lists the playlists a track is in for local searches, and Owner
+// is only set for playlists.
+type SearchResult struct {
+	Kind string `json:"kind"`
+	Uri string `json:"uri"`
+	Name string `json:"name"`
+	Artists []string `json:"artists"`
+	Album string `json:"album"`
+	Owner string `json:"owner"`
+	Playlists []string `json:"playlists"`
+}
+
+func (r SearchResult) Fields() []string {
+	return []string{ r.Kind, r.Uri, r.Name, strings.Join(r.Artists, ", "), r.Album, r.Owner, strings.Join(r.Playlists, ", ") }
+}
+
+// DiffEntry is a track of a playlist diff. Side is a or b for the tracks only
+// in the first or the second playlist and both for the rest.
+type DiffEntry struct {
+	Side string `json:"side"`
+	Uri string `json:"uri"`
+	Title string `json:"title"`
+	Artists []string `json:"artists"`
+	Album string `json:"album"`
+}
+
+func (e DiffEntry) Fields() []string {
+	return []string{ e.Side, e.Uri, e.Title, strings.Join(e.Artists, ", "), e.Album }
+}
+
+// WatchedPlaylist is a playlist checked for changes. CheckedAt is empty
+// until the first check.
+type WatchedPlaylist struct {
+	Id string `json:"id"`
+	Name string `json:"name"`
+	CheckedAt string `json:"checked_at"`
+}
+
+func (w WatchedPlaylist) Fields() []string {
+	return []string{ w.Id, w.Name, w.CheckedAt }
+}
+
+// PlaylistChange is a track added to or removed from a watched playlist.
+// Change is added or removed.
+type PlaylistChange struct {
+	DetectedAt string `json:"detected_at"`
+	PlaylistId string `json:"playlist_id"`
+	Playlist string `json:"playlist"`
+	Change string `json:"change"`
+	Uri string `json:"uri"`
+	Title string `json:"title"`
+	Artists []string `json:"artists"`
+	AddedBy string `json:"added_by"`
+}
+
+func (c PlaylistChange) Fields() []string {
+	return []string{ c.DetectedAt, c.PlaylistId, c.Playlist, c.Change, c.Uri, c.Title, strings.Join(c.Artists, ", "), c.AddedBy }
+}
+
+// SmartPlaylist is a smart playlist with its rules. PlaylistId is empty
+// until the first refresh, RefreshInterval is empty when it is only
+// refreshed on request.
+type SmartPlaylist struct {
+	Name string `json:"name"`
+	PlaylistId string `json:"playlist_id"`
+	RefreshInterval string `json:"refresh_interval"`
+	RefreshedAt string `json:"refreshed_at"`
+	Description string `json:"description"`
+	Rules json.RawMessage `json:"rules"`
+}
+
+func (p SmartPlaylist) Fields() []string {
+	return []string{ p.Name, p.PlaylistId, p.RefreshInterval, p.RefreshedAt, p.Description }
+}
+
+// SmartTrack is a track a smart playlist selects, in the order it is written.
+type SmartTrack struct {
+	Uri string `json:"uri"`
+	Title string `json:"title"`
+	Artists []string `json:"artists"`
+	Album string `json:"album"`
+	AddedAt string `json:"added_at"`
+	LastPlayedAt string `json:"last_played_at"`
+	Plays int `json:"plays"`
+	Popularity int `json:"popularity"`
+}
+
+func (t SmartTrack) Fields() []string {
+	return []string{ t.Uri, t.Title, strings.Join(t.Artists, ", "), t.Album, t.AddedAt, t.LastPlayedAt, strconv.Itoa(t.Plays), strconv.Itoa(t.Popularity) }
+}
+
+// SmartRefresh is a smart playlist written to spotify.
+type SmartRefresh struct {
+	Name string `json:"name"`
+	PlaylistId string `json:"playlist_id"`
+	Tracks int `json:"tracks"`
+	Created bool `json:"created"`
+}
+
+func (r SmartRefresh) Fields() []string {
+	return []string{ r.Name, r.PlaylistId, strconv.Itoa(r.Tracks), strconv.FormatBool(r.Created) }
+}
+
+// SyncResult counts what a sync of the library fetched. Warnings are parts
+// that were skipped.
+type SyncResult struct {
+	Playlists int `json:"playlists"`
+	PlaylistsUpdated int `json:"playlists_updated"`
+	PlaylistsUnchanged int `json:"playlists_unchanged"`
+	PlaylistsRemoved int `json:"playlists_removed"`
+	SavedTracks int `json:"saved_tracks"`
+	SavedAlbums int `json:"saved_albums"`
+	FollowedArtists int `json:"followed_artists"`
+	Warnings []string `json:"warnings"`
+}
+
+func (r SyncResult) Fields() []string {
+	return []string{
+		strconv.Itoa(r.Playlists),
+		strconv.Itoa(r.PlaylistsUpdated),
+		strconv.Itoa(r.PlaylistsUnchanged),
+		strconv.Itoa(r.PlaylistsRemoved),
+		strconv.Itoa(r.SavedTracks),
+		strconv.Itoa(r.SavedAlbums),
+		strconv.Itoa(r.FollowedArtists),
+		strings.Join(r.Warnings, "; "),
+	}
+}
+
+// HistoryImport counts the plays imported from a spotify data export.
+type HistoryImport struct {
+	Files int `json:"files"`
+	Plays int `json:"plays"`
+	Imported int `json:"imported"`
+	Duplicates int `json:"duplicates"`
+}
+
+func (h HistoryImport) Fields() []string {
+	return []string{ strconv.Itoa(h.Files), strconv.Itoa(h.Plays), strconv.Itoa(h.Imported), strconv.Itoa(h.Duplicates) }
+}
+
+// SaveResult is the queue or session saved as a playlist. Skipped counts
+// the tracks the playlist already had.
+type SaveResult struct {
+	Source string `json:"source"`
+	Playlist string `json:"playlist"`
+	Saved int `json:"saved"`
+	Skipped int `json:"skipped"`
+}
+
+func (r SaveResult) Fields() []string {
+	return []string{ r.Source, r.Playlist, strconv.Itoa(r.Saved), strconv.Itoa(r.Skipped) }
+}
+
+// BackupEntry is a playlist written to a backup. Status is created, updated
+// or unchanged.
+type BackupEntry struct {
+	Status string `json:"status"`
+	Id string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (e BackupEntry) Fields() []string {
+	return []string{ e.Status, e.Id, e.Name }
+}
+
+// DaemonStatus is what the running daemon reports about itself. PolledAt
+// and LastError are empty before the first poll or error.
+type DaemonStatus struct {
+	Pid int `json:"pid"`
+	Socket string `json:"socket"`
+	StartedAt string `json:"started_at"`
+	Polls int `json:"polls"`
+	PolledAt string `json:"polled_at"`
+	LastError string `json:"last_error"`
+	TokenExpiresAt string `json:"token_expires_at"`
+}
+
+func (s DaemonStatus) Fields() []string {
+	return []string{ strconv.Itoa(s.Pid), s.Socket, s.StartedAt, strconv.Itoa(s.Polls), s.PolledAt, s.LastError, s.TokenExpiresAt }
+}
@@ -0,0 +1,271 @@
+package playlist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// Backup is the state of a playlist at one snapshot. It is written as
+// indented json with one file per playlist so a directory of backups can be
+// kept in git and changes show up as small diffs.
+type Backup struct {
+	Id string `json:"id"`
+	Name string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Owner string `json:"owner"`
+	Public bool `json:"public"`
+	Collaborative bool `json:"collaborative"`
+	SnapshotId string `json:"snapshot_id"`
+	Uri string `json:"uri"`
+	BackedUpAt string `json:"backed_up_at"`
+	Items []Entry `json:"items"`
+}
+
+func NewBackup(p types.SimplifiedPlaylistObject, items []types.PlaylistItemUnion) Backup {
+	entries := Entries(items)
+
+	if entries == nil {
+		entries = []Entry{}
+	}
+
+	return Backup{
+		Id: p.Id,
+		Name: p.Name,
+		Description: p.Description,
+		Owner: p.Owner.Id,
+		Public: p.Public,
+		Collaborative: p.Collaborative,
+		SnapshotId: p.SnapshotId,
+		Uri: p.Uri,
+		BackedUpAt: time.Now().UTC().Format(time.RFC3339),
+		Items: entries,
+	}
+}
+
+func ReadBackup(path string) (Backup, error) {
+	var b Backup
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return b, err
+	}
+
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if b.Id == "" {
+		return b, fmt.Errorf("%s is not a playlist backup", path)
+	}
+
+	return b, nil
+}
+
+// WriteBackup writes b to path through a temporary file so an interrupted
+// backup never leaves a truncated file behind.
+func WriteBackup(path string, b Backup) error {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(b); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// BackupFileName is the name of the backup of p. It ends in the id, which
+// never changes, so a renamed playlist can still be found.
+func BackupFileName(p types.SimplifiedPlaylistObject) string {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(p.Name), "-"), "-")
+
+	if name == "" {
+		return p.Id + ".json"
+	}
+
+	return name + "-" + p.Id + ".json"
+}
+
+// existingBackups maps playlist ids to the backup files in dir.
+func existingBackups(dir string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]string)
+
+	for _, file := range files {
+		base := strings.TrimSuffix(filepath.Base(file), ".json")
+
+		if i := strings.LastIndex(base, "-"); i >= 0 {
+			base = base[i+1:]
+		}
+
+		byId[base] = file
+	}
+
+	return byId, nil
+}
+
+type BackupStatus string
+
+const (
+	BackupCreated BackupStatus = "created"
+	BackupUpdated BackupStatus = "updated"
+	BackupUnchanged BackupStatus = "unchanged"
+)
+
+type BackupResult struct {
+	Created int
+	Updated int
+	Unchanged int
+}
+
+func (r BackupResult) String() string {
+	return fmt.Sprintf("%d created, %d updated, %d unchanged", r.Created, r.Updated, r.Unchanged)
+}
+
+// BackupPlaylists writes a backup of each playlist into dir. Playlists whose
+// snapshot matches the existing backup are skipped without fetching their
+// items, and backups of playlists that are gone are left alone. onWrite, if
+// not nil, is called for every playlist.
+func BackupPlaylists(ctx context.Context, c *client.Client, market string, dir string, playlists []types.SimplifiedPlaylistObject, force bool, onWrite func(p types.SimplifiedPlaylistObject, status BackupStatus)) (BackupResult, error) {
+	var result BackupResult
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return result, err
+	}
+
+	existing, err := existingBackups(dir)
+
+	if err != nil {
+		return result, err
+	}
+
+	for _, p := range playlists {
+		path := filepath.Join(dir, BackupFileName(p))
+		status := BackupCreated
+
+		if old, ok := existing[p.Id]; ok {
+			status = BackupUpdated
+
+			if b, err := ReadBackup(old); err == nil && b.SnapshotId == p.SnapshotId && b.Name == p.Name && !force {
+				result.Unchanged++
+
+				if onWrite != nil {
+					onWrite(p, BackupUnchanged)
+				}
+
+				continue
+			}
+
+			// the playlist was renamed, keep a single file for it
+			if old != path {
+				if err := os.Rename(old, path); err != nil {
+					return result, err
+				}
+			}
+		}
+
+		items, err := c.GetAllPlaylistItems(ctx, p.Id, market)
+
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", p.Name, err)
+		}
+
+		if err := WriteBackup(path, NewBackup(p, items)); err != nil {
+			return result, err
+		}
+
+		if status == BackupCreated {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+
+		if onWrite != nil {
+			onWrite(p, status)
+		}
+	}
+
+	return result, nil
+}
+
+// RestoreUris is the uri list that turns the playlist with the items current
+// into the backup. Items that are no longer available stay where they are
+// since the api cannot remove them, and local files that are not in the
+// playlist anymore are returned as skipped since they cannot be added back.
+func RestoreUris(current []types.PlaylistItemUnion, b Backup) ([]string, []Entry) {
+	old := EditUris(current)
+	locals := make(map[string]int)
+
+	for _, uri := range old {
+		if strings.HasPrefix(uri, "spotify:local:") {
+			locals[uri]++
+		}
+	}
+
+	var uris []string
+	var skipped []Entry
+
+	for _, e := range b.Items {
+		if strings.HasPrefix(e.Uri, "spotify:local:") {
+			if locals[e.Uri] == 0 {
+				skipped = append(skipped, e)
+				continue
+			}
+
+			locals[e.Uri]--
+		}
+
+		uris = append(uris, e.Uri)
+	}
+
+	// local files cannot be removed one copy at a time, so when the backup
+	// has fewer copies than the playlist the others stay at the end
+	kept := make(map[string]bool)
+
+	for _, uri := range uris {
+		kept[uri] = true
+	}
+
+	for _, uri := range old {
+		if locals[uri] > 0 && kept[uri] {
+			uris = append(uris, uri)
+			locals[uri]--
+		}
+	}
+
+	for i, uri := range old {
+		if unavailablePattern.MatchString(uri) {
+			at := min(i, len(uris))
+			uris = append(uris[:at], append([]string{ uri }, uris[at:]...)...)
+		}
+	}
+
+	return uris, skipped
+}
@@ -0,0 +1,169 @@
+package playlist
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+type Reason string
+
+const (
+	// the same uri appears more than once
+	ReasonUri Reason = "uri"
+	// another release of the same recording, e.g. from a compilation
+	ReasonIsrc Reason = "isrc"
+	// the same artist and title after Normalize, catches remasters and
+	// explicit or clean versions that have their own isrc
+	ReasonTitle Reason = "title"
+	// the track can no longer be played in the market
+	ReasonUnplayable Reason = "unplayable"
+	// the track was removed from spotify and only a null item is left
+	ReasonUnavailable Reason = "unavailable"
+)
+
+var Reasons = []Reason{ ReasonUri, ReasonIsrc, ReasonTitle, ReasonUnplayable, ReasonUnavailable }
+
+// DefaultReasons leave out unplayable, whether a track plays depends on the
+// market and a wrong one would remove tracks the user can play.
+var DefaultReasons = []Reason{ ReasonUri, ReasonIsrc, ReasonTitle, ReasonUnavailable }
+
+// Finding is an item that can be cleaned up. Positions are zero based and
+// Original is the position of the copy that is kept, or -1.
+type Finding struct {
+	Position int
+	Uri string
+	Name string
+	Reason Reason
+	Original int
+}
+
+// Removable reports whether the item has a uri the api can remove it by.
+func (f Finding) Removable() bool {
+	return f.Reason != ReasonUnavailable
+}
+
+func itemName(item types.PlaylistItemUnion) string {
+	switch {
+	case item.Track.Type == "track" && item.Track.Track != nil:
+		track := item.Track.Track
+		artists := make([]string, 0, len(track.Artists))
+
+		for _, artist := range track.Artists {
+			artists = append(artists, artist.Name)
+		}
+
+		return strings.Join(artists, ", ") + " - " + track.Name
+	case item.Track.Type == "episode" && item.Track.Episode != nil:
+		return item.Track.Episode.Name
+	}
+
+	return ""
+}
+
+// FindDuplicates checks the items for the given reasons. The first copy of a
+// duplicate is kept and every later one is reported.
+func FindDuplicates(items []types.PlaylistItemUnion, reasons []Reason) []Finding {
+	var findings []Finding
+
+	seen := map[Reason]map[string]int{
+		ReasonUri: make(map[string]int),
+		ReasonIsrc: make(map[string]int),
+		ReasonTitle: make(map[string]int),
+	}
+
+	enabled := func(r Reason) bool {
+		return slices.Contains(reasons, r)
+	}
+
+	for i, item := range items {
+		var uri, isrc, title string
+
+		switch {
+		case item.Track.Type == "track" && item.Track.Track != nil:
+			track := item.Track.Track
+			uri = track.Uri
+			isrc = strings.ToUpper(track.ExternalIds.Isrc)
+
+			if len(track.Artists) > 0 {
+				title = Normalize(track.Artists[0].Name) + "\x00" + Normalize(track.Name)
+			}
+
+			if enabled(ReasonUnplayable) && !track.IsPlayable && !track.IsLocal {
+				findings = append(findings, Finding{ Position: i, Uri: uri, Name: itemName(item), Reason: ReasonUnplayable, Original: -1 })
+				continue
+			}
+		case item.Track.Type == "episode" && item.Track.Episode != nil:
+			uri = item.Track.Episode.Uri
+		default:
+			if enabled(ReasonUnavailable) {
+				findings = append(findings, Finding{ Position: i, Reason: ReasonUnavailable, Original: -1 })
+			}
+			continue
+		}
+
+		keys := []struct{ reason Reason; key string }{
+			{ ReasonUri, uri },
+			{ ReasonIsrc, isrc },
+			{ ReasonTitle, title },
+		}
+
+		duplicate := false
+
+		for _, k := range keys {
+			if k.key == "" || !enabled(k.reason) {
+				continue
+			}
+
+			if original, ok := seen[k.reason][k.key]; ok && !duplicate {
+				findings = append(findings, Finding{ Position: i, Uri: uri, Name: itemName(item), Reason: k.reason, Original: original })
+				duplicate = true
+			}
+		}
+
+		if duplicate {
+			continue
+		}
+
+		for _, k := range keys {
+			if k.key != "" {
+				seen[k.reason][k.key] = i
+			}
+		}
+	}
+
+	return findings
+}
+
+// ParseReasons parses a comma separated list of reasons.
+func ParseReasons(s string) ([]Reason, error) {
+	var reasons []Reason
+
+	for _, field := range strings.Split(s, ",") {
+		r := Reason(strings.TrimSpace(field))
+
+		if !slices.Contains(Reasons, r) {
+			return nil, fmt.Errorf("unknown reason %q, use uri, isrc, title, unplayable or unavailable", field)
+		}
+
+		reasons = append(reasons, r)
+	}
+
+	return reasons, nil
+}
+
+// WithoutPositions returns uris without the items at the given positions, the
+// result can be passed to Plan to remove them in one batch.
+func WithoutPositions(uris []string, positions []int) []string {
+	kept := make([]string, 0, len(uris))
+
+	for i, uri := range uris {
+		if !slices.Contains(positions, i) {
+			kept = append(kept, uri)
+		}
+	}
+
+	return kept
+}
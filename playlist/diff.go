@@ -0,0 +1,138 @@
+package playlist
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// index answers whether an entry is in a list, by uri or by isrc so the same
+// recording released on different albums counts as the same track.
+type index struct {
+	uris map[string]bool
+	isrcs map[string]bool
+}
+
+func newIndex(entries []Entry) index {
+	idx := index{
+		uris: make(map[string]bool),
+		isrcs: make(map[string]bool),
+	}
+
+	for _, e := range entries {
+		idx.add(e)
+	}
+
+	return idx
+}
+
+func (idx index) add(e Entry) {
+	idx.uris[e.Uri] = true
+
+	if e.Isrc != "" {
+		idx.isrcs[strings.ToUpper(e.Isrc)] = true
+	}
+}
+
+func (idx index) contains(e Entry) bool {
+	return idx.uris[e.Uri] || (e.Isrc != "" && idx.isrcs[strings.ToUpper(e.Isrc)])
+}
+
+type Diff struct {
+	OnlyA []Entry
+	OnlyB []Entry
+	// Common holds the entries of a that are also in b
+	Common []Entry
+}
+
+func Compare(a, b []Entry) Diff {
+	var diff Diff
+
+	inA, inB := newIndex(a), newIndex(b)
+
+	for _, e := range a {
+		if inB.contains(e) {
+			diff.Common = append(diff.Common, e)
+		} else {
+			diff.OnlyA = append(diff.OnlyA, e)
+		}
+	}
+
+	for _, e := range b {
+		if !inA.contains(e) {
+			diff.OnlyB = append(diff.OnlyB, e)
+		}
+	}
+
+	return diff
+}
+
+type MergeMode string
+
+const (
+	MergeUnion MergeMode = "union"
+	MergeIntersection MergeMode = "intersection"
+	MergeDifference MergeMode = "difference"
+)
+
+var MergeModes = []MergeMode{ MergeUnion, MergeIntersection, MergeDifference }
+
+func ParseMergeMode(s string) (MergeMode, error) {
+	mode := MergeMode(strings.ToLower(s))
+
+	if !slices.Contains(MergeModes, mode) {
+		return "", fmt.Errorf("unknown merge mode %q, use union, intersection or difference", s)
+	}
+
+	return mode, nil
+}
+
+// Merge combines a and b. A union is a followed by the tracks of b that are
+// not in a, an intersection the tracks of a that are in b and a difference
+// the tracks of a that are not in b.
+func Merge(a, b []Entry, mode MergeMode) []Entry {
+	diff := Compare(a, b)
+
+	switch mode {
+	case MergeIntersection:
+		return diff.Common
+	case MergeDifference:
+		return diff.OnlyA
+	}
+
+	return append(slices.Clone(a), diff.OnlyB...)
+}
+
+// Dedupe drops entries that repeat an earlier one or one in existing, by uri,
+// isrc or normalized artist and title.
+func Dedupe(entries []Entry, existing []Entry) []Entry {
+	idx := newIndex(existing)
+	titles := make(map[string]bool)
+
+	title := func(e Entry) string {
+		if len(e.Artists) == 0 {
+			return ""
+		}
+		return Normalize(e.Artists[0]) + "\x00" + Normalize(e.Name)
+	}
+
+	for _, e := range existing {
+		titles[title(e)] = true
+	}
+
+	var kept []Entry
+
+	for _, e := range entries {
+		key := title(e)
+
+		if idx.contains(e) || (key != "" && titles[key]) {
+			continue
+		}
+
+		idx.add(e)
+		titles[key] = true
+		kept = append(kept, e)
+	}
+
+	return kept
+}
@@ -0,0 +1,389 @@
+package playlist
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// ErrConflict is returned by ApplyEdit when the playlist changed on spotify
+// while it was being edited.
+var ErrConflict = errors.New("the playlist was changed while it was being edited")
+
+// unavailablePattern matches the placeholder written for items whose track is
+// gone. They keep their line so the positions of the other items stay right.
+var unavailablePattern = regexp.MustCompile(`^unavailable:\d+$`)
+
+// EditUris returns the uri of every item in playlist order, with a
+// placeholder for items that are no longer available.
+func EditUris(items []types.PlaylistItemUnion) []string {
+	uris := make([]string, 0, len(items))
+
+	for i, item := range items {
+		switch {
+		case item.Track.Type == "track" && item.Track.Track != nil:
+			uris = append(uris, item.Track.Track.Uri)
+		case item.Track.Type == "episode" && item.Track.Episode != nil:
+			uris = append(uris, item.Track.Episode.Uri)
+		default:
+			uris = append(uris, fmt.Sprintf("unavailable:%d", i))
+		}
+	}
+
+	return uris
+}
+
+const editHelp = `#
+# Move lines to reorder, delete lines to remove and add track or episode
+# uris or open.spotify.com links to insert. Everything after a # is ignored.
+# Removing one copy of a duplicated item removes them all and adds the
+# remaining copies again. Leave the file unchanged to cancel.
+`
+
+// WriteEditFile writes one line per item, the uri followed by a comment
+// naming the item.
+func WriteEditFile(w io.Writer, p types.SimplifiedPlaylistObject, items []types.PlaylistItemUnion) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s (%d items, snapshot %s)\n", oneLine(p.Name), len(items), p.SnapshotId)
+	b.WriteString(editHelp)
+
+	for i, uri := range EditUris(items) {
+		item := items[i]
+		comment := "no longer available"
+
+		switch {
+		case item.Track.Type == "track" && item.Track.Track != nil:
+			track := item.Track.Track
+			artists := make([]string, 0, len(track.Artists))
+
+			for _, artist := range track.Artists {
+				artists = append(artists, artist.Name)
+			}
+
+			comment = oneLine(strings.Join(artists, ", ") + " - " + track.Name)
+		case item.Track.Type == "episode" && item.Track.Episode != nil:
+			comment = oneLine(item.Track.Episode.Name)
+		}
+
+		fmt.Fprintf(&b, "%s  # %s\n", uri, comment)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// ParseEditFile reads the uris back from an edited file.
+func ParseEditFile(r io.Reader) ([]string, error) {
+	var uris []string
+
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) > 1 {
+			return nil, fmt.Errorf("line %d: expected a single uri, got %q", line, strings.TrimSpace(text))
+		}
+
+		ref := fields[0]
+
+		switch {
+		case unavailablePattern.MatchString(ref), strings.HasPrefix(ref, "spotify:local:"):
+			uris = append(uris, ref)
+		default:
+			if id, ok := parseRef("track", ref); ok {
+				uris = append(uris, "spotify:track:"+id)
+			} else if id, ok := parseRef("episode", ref); ok {
+				uris = append(uris, "spotify:episode:"+id)
+			} else {
+				return nil, fmt.Errorf("line %d: %q is not a track or episode uri", line, ref)
+			}
+		}
+	}
+
+	return uris, scanner.Err()
+}
+
+type OpKind string
+
+const (
+	OpRemove OpKind = "remove"
+	OpMove OpKind = "move"
+	OpInsert OpKind = "insert"
+)
+
+// Op is a single change to a playlist. Remove uses Uris, move uses Start,
+// Length and Before and insert uses Uris and Position.
+type Op struct {
+	Kind OpKind
+	Uris []string
+	Start int
+	Length int
+	Before int
+	Position int
+}
+
+func (o Op) String() string {
+	switch o.Kind {
+	case OpRemove:
+		return fmt.Sprintf("remove %d items", len(o.Uris))
+	case OpMove:
+		return fmt.Sprintf("move %d items from %d to before %d", o.Length, o.Start+1, o.Before+1)
+	}
+
+	return fmt.Sprintf("insert %d items at %d", len(o.Uris), o.Position+1)
+}
+
+// Plan computes the operations that turn the playlist old into new. Items that
+// keep their relative order stay in place and only the others are moved, so
+// the added dates of moved items are kept.
+func Plan(old, new []string) ([]Op, error) {
+	oldCount := make(map[string]int)
+	newCount := make(map[string]int)
+
+	for _, uri := range old {
+		oldCount[uri]++
+	}
+
+	for _, uri := range new {
+		newCount[uri]++
+	}
+
+	var ops []Op
+	var removed []string
+
+	// the api removes every copy of a uri, so fewer copies means removing
+	// all of them and inserting the ones that remain
+	for _, uri := range old {
+		if newCount[uri] >= oldCount[uri] {
+			continue
+		}
+
+		if unavailablePattern.MatchString(uri) {
+			return nil, fmt.Errorf("items that are no longer available cannot be removed")
+		}
+
+		if strings.HasPrefix(uri, "spotify:local:") && newCount[uri] > 0 {
+			return nil, fmt.Errorf("copies of the local file %s cannot be removed one at a time", uri)
+		}
+
+		removed = append(removed, uri)
+		oldCount[uri] = 0
+	}
+
+	for start := 0; start < len(removed); start += client.MaxAddItems {
+		ops = append(ops, Op{ Kind: OpRemove, Uris: removed[start:min(start+client.MaxAddItems, len(removed))] })
+	}
+
+	// cur holds the ids of the items left after removing, in playlist order
+	positions := make(map[string][]int)
+	var cur []int
+
+	for _, uri := range old {
+		if oldCount[uri] == 0 {
+			continue
+		}
+
+		positions[uri] = append(positions[uri], len(cur))
+		cur = append(cur, len(cur))
+	}
+
+	// target lists the ids of the kept items in their new order, ids is the
+	// id of each line of new or -1 for lines to insert
+	target := make([]int, 0, len(cur))
+	ids := make([]int, len(new))
+
+	for i, uri := range new {
+		if len(positions[uri]) == 0 {
+			if strings.HasPrefix(uri, "spotify:local:") || unavailablePattern.MatchString(uri) {
+				return nil, fmt.Errorf("%s cannot be added to a playlist", uri)
+			}
+
+			ids[i] = -1
+			continue
+		}
+
+		ids[i] = positions[uri][0]
+		positions[uri] = positions[uri][1:]
+		target = append(target, ids[i])
+	}
+
+	stay := longestIncreasing(target)
+
+	indexOf := func(id int) int {
+		for i, v := range cur {
+			if v == id {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for k := 0; k < len(target); k++ {
+		if stay[target[k]] {
+			continue
+		}
+
+		from := indexOf(target[k])
+		length := 1
+
+		// move runs that are already together in a single request
+		for k+length < len(target) && !stay[target[k+length]] && from+length < len(cur) && cur[from+length] == target[k+length] {
+			length++
+		}
+
+		before := 0
+
+		if k > 0 {
+			before = indexOf(target[k-1]) + 1
+		}
+
+		if before != from && before != from+length {
+			ops = append(ops, Op{ Kind: OpMove, Start: from, Length: length, Before: before })
+
+			run := append([]int(nil), cur[from:from+length]...)
+			rest := append(append([]int(nil), cur[:from]...), cur[from+length:]...)
+
+			at := before
+
+			if before > from {
+				at -= length
+			}
+
+			cur = append(append(append([]int(nil), rest[:at]...), run...), rest[at:]...)
+		}
+
+		k += length - 1
+	}
+
+	for i := 0; i < len(new); {
+		if ids[i] != -1 {
+			i++
+			continue
+		}
+
+		end := i
+
+		for end < len(new) && ids[end] == -1 && end-i < client.MaxAddItems {
+			end++
+		}
+
+		ops = append(ops, Op{ Kind: OpInsert, Uris: new[i:end], Position: i })
+		i = end
+	}
+
+	return ops, nil
+}
+
+// longestIncreasing marks the values of a longest increasing subsequence of
+// ids, which are the items that do not have to move.
+func longestIncreasing(ids []int) map[int]bool {
+	// tails[n] is the index in ids ending the best subsequence of length n+1
+	var tails []int
+	prev := make([]int, len(ids))
+
+	for i, id := range ids {
+		lo, hi := 0, len(tails)
+
+		for lo < hi {
+			mid := (lo + hi) / 2
+
+			if ids[tails[mid]] < id {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+
+		prev[i] = -1
+
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		}
+
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+
+	stay := make(map[int]bool)
+
+	if len(tails) == 0 {
+		return stay
+	}
+
+	for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+		stay[ids[i]] = true
+	}
+
+	return stay
+}
+
+// ApplyEdit checks that the playlist is still at snapshot and applies the
+// operations in order. It returns the snapshot id after the last change.
+func ApplyEdit(ctx context.Context, c *client.Client, id, snapshot string, ops []Op) (string, error) {
+	current, err := c.GetPlaylist(ctx, client.GetPlaylistParams{
+		Id: id,
+		Fields: []string{ "snapshot_id" },
+	})
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	if current.SnapshotId != snapshot {
+		return snapshot, ErrConflict
+	}
+
+	for _, op := range ops {
+		var result types.PlaylistSnapshot
+		var err error
+
+		switch op.Kind {
+		case OpRemove:
+			result, err = c.RemovePlaylistItems(ctx, client.RemovePlaylistItemsParams{
+				Id: id,
+				Uris: op.Uris,
+				SnapshotId: snapshot,
+			})
+		case OpMove:
+			result, err = c.ReorderPlaylistItems(ctx, client.ReorderPlaylistItemsParams{
+				Id: id,
+				RangeStart: op.Start,
+				RangeLength: op.Length,
+				InsertBefore: op.Before,
+				SnapshotId: snapshot,
+			})
+		case OpInsert:
+			result, err = c.AddItemsToPlaylist(ctx, client.AddItemsToPlaylistParams{
+				Id: id,
+				Uris: op.Uris,
+				Position: types.Optional[int]{ Value: op.Position, Valid: true },
+			})
+		}
+
+		if err != nil {
+			return snapshot, fmt.Errorf("%s: %w", op, err)
+		}
+
+		snapshot = result.SnapshotId
+	}
+
+	return snapshot, nil
+}
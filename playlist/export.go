@@ -0,0 +1,293 @@
+package playlist
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+type Format string
+
+const (
+	FormatCSV Format = "csv"
+	FormatJSON Format = "json"
+	FormatM3U Format = "m3u"
+	FormatXSPF Format = "xspf"
+)
+
+var Formats = []Format{ FormatCSV, FormatJSON, FormatM3U, FormatXSPF }
+
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(s))
+
+	if f == "m3u8" {
+		return FormatM3U, nil
+	}
+
+	if !slices.Contains(Formats, f) {
+		return "", fmt.Errorf("unknown format %q, use one of csv, json, m3u or xspf", s)
+	}
+
+	return f, nil
+}
+
+// FormatFromPath guesses the format from the extension of path.
+func FormatFromPath(path string) (Format, bool) {
+	f, err := ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+	return f, err == nil
+}
+
+// Entry is a single playlist item flattened for export.
+type Entry struct {
+	Uri string `json:"uri"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Artists []string `json:"artists"`
+	Album string `json:"album,omitempty"`
+	DurationMs int `json:"duration_ms"`
+	Isrc string `json:"isrc,omitempty"`
+	AddedAt string `json:"added_at,omitempty"`
+	AddedBy string `json:"added_by,omitempty"`
+}
+
+func (e Entry) ArtistNames() string {
+	return strings.Join(e.Artists, ", ")
+}
+
+// Entries flattens playlist items, dropping items whose track is no longer
+// available.
+func Entries(items []types.PlaylistItemUnion) []Entry {
+	entries := make([]Entry, 0, len(items))
+
+	for _, item := range items {
+		entry := Entry{
+			AddedAt: item.AddedAt.Value,
+			AddedBy: item.AddedBy.Value.Id,
+		}
+
+		switch {
+		case item.Track.Type == "track" && item.Track.Track != nil:
+			track := item.Track.Track
+			entry.Uri = track.Uri
+			entry.Type = "track"
+			entry.Name = track.Name
+			entry.Album = track.Album.Name
+			entry.DurationMs = track.DurationMs
+			entry.Isrc = track.ExternalIds.Isrc
+
+			for _, artist := range track.Artists {
+				entry.Artists = append(entry.Artists, artist.Name)
+			}
+		case item.Track.Type == "episode" && item.Track.Episode != nil:
+			episode := item.Track.Episode
+			entry.Uri = episode.Uri
+			entry.Type = "episode"
+			entry.Name = episode.Name
+			entry.DurationMs = episode.DurationMs
+		default:
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// Export writes the entries of p to w in the given format.
+func Export(w io.Writer, format Format, p types.SimplifiedPlaylistObject, entries []Entry) error {
+	switch format {
+	case FormatCSV:
+		return exportCSV(w, entries)
+	case FormatJSON:
+		return exportJSON(w, p, entries)
+	case FormatM3U:
+		return exportM3U(w, p, entries)
+	case FormatXSPF:
+		return exportXSPF(w, p, entries)
+	}
+
+	return fmt.Errorf("unknown format %q", format)
+}
+
+var csvHeader = []string{ "uri", "type", "name", "artists", "album", "duration_ms", "isrc", "added_at", "added_by" }
+
+func exportCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		record := []string{
+			e.Uri,
+			e.Type,
+			e.Name,
+			e.ArtistNames(),
+			e.Album,
+			strconv.Itoa(e.DurationMs),
+			e.Isrc,
+			e.AddedAt,
+			e.AddedBy,
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+type jsonExport struct {
+	Id string `json:"id"`
+	Name string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Owner string `json:"owner"`
+	SnapshotId string `json:"snapshot_id"`
+	Uri string `json:"uri"`
+	ExportedAt string `json:"exported_at"`
+	Items []Entry `json:"items"`
+}
+
+func exportJSON(w io.Writer, p types.SimplifiedPlaylistObject, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(jsonExport{
+		Id: p.Id,
+		Name: p.Name,
+		Description: p.Description,
+		Owner: p.Owner.Id,
+		SnapshotId: p.SnapshotId,
+		Uri: p.Uri,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Items: entries,
+	})
+}
+
+// exportM3U writes an extended m3u playlist. The locations are spotify uris
+// which players with spotify support can resolve.
+func exportM3U(w io.Writer, p types.SimplifiedPlaylistObject, entries []Entry) error {
+	var b strings.Builder
+
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#PLAYLIST:%s\n", oneLine(p.Name))
+
+	for _, e := range entries {
+		title := oneLine(e.Name)
+
+		if artists := e.ArtistNames(); artists != "" {
+			title = oneLine(artists) + " - " + title
+		}
+
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n", e.DurationMs/1000, title)
+
+		if e.Album != "" {
+			fmt.Fprintf(&b, "#EXTALB:%s\n", oneLine(e.Album))
+		}
+
+		fmt.Fprintf(&b, "%s\n", e.Uri)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name `xml:"playlist"`
+	Version string `xml:"version,attr"`
+	Xmlns string `xml:"xmlns,attr"`
+	Title string `xml:"title"`
+	Creator string `xml:"creator,omitempty"`
+	Annotation string `xml:"annotation,omitempty"`
+	Location string `xml:"location,omitempty"`
+	Date string `xml:"date"`
+	Tracks []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Identifier string `xml:"identifier,omitempty"`
+	Title string `xml:"title"`
+	Creator string `xml:"creator,omitempty"`
+	Album string `xml:"album,omitempty"`
+	Duration int `xml:"duration,omitempty"`
+	Meta []xspfMeta `xml:"meta,omitempty"`
+}
+
+type xspfMeta struct {
+	Rel string `xml:"rel,attr"`
+	Value string `xml:",chardata"`
+}
+
+const xspfMetaRel = "https://github.com/arjunmoola/go-spotify/xspf/"
+
+func exportXSPF(w io.Writer, p types.SimplifiedPlaylistObject, entries []Entry) error {
+	doc := xspfPlaylist{
+		Version: "1",
+		Xmlns: "http://xspf.org/ns/0/",
+		Title: p.Name,
+		Creator: p.Owner.DisplayName.Value,
+		Annotation: p.Description,
+		Location: p.Uri,
+		Date: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	for _, e := range entries {
+		track := xspfTrack{
+			Location: e.Uri,
+			Title: e.Name,
+			Creator: e.ArtistNames(),
+			Album: e.Album,
+			Duration: e.DurationMs,
+		}
+
+		if e.Isrc != "" {
+			track.Identifier = "urn:isrc:" + e.Isrc
+		}
+
+		if e.AddedAt != "" {
+			track.Meta = append(track.Meta, xspfMeta{ Rel: xspfMetaRel + "added_at", Value: e.AddedAt })
+		}
+
+		if e.AddedBy != "" {
+			track.Meta = append(track.Meta, xspfMeta{ Rel: xspfMetaRel + "added_by", Value: e.AddedBy })
+		}
+
+		doc.Tracks = append(doc.Tracks, track)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,346 @@
+package playlist
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+type Method string
+
+const (
+	MethodUri Method = "uri"
+	MethodIsrc Method = "isrc"
+	MethodSearch Method = "search"
+	MethodNone Method = "none"
+)
+
+// DefaultMinConfidence is the lowest search score accepted as a match.
+const DefaultMinConfidence = 0.7
+
+// Match is the outcome of looking up a single row. Track is the best
+// candidate found even when its confidence is too low to be accepted, so the
+// review and the report can show what was considered.
+type Match struct {
+	Row Row
+	Method Method
+	Confidence float64
+	Track types.Track
+	Accepted bool
+}
+
+func (m Match) Uri() string {
+	if !m.Accepted {
+		return ""
+	}
+
+	return m.Track.Uri
+}
+
+// TrackName is the matched track as "artists - title".
+func (m Match) TrackName() string {
+	if m.Track.Uri == "" {
+		return ""
+	}
+
+	artists := make([]string, 0, len(m.Track.Artists))
+
+	for _, artist := range m.Track.Artists {
+		artists = append(artists, artist.Name)
+	}
+
+	return strings.Join(artists, ", ") + " - " + m.Track.Name
+}
+
+type Matcher struct {
+	client *client.Client
+	market string
+	minConfidence float64
+	progress func(done, total int)
+}
+
+func NewMatcher(c *client.Client, market string, minConfidence float64) *Matcher {
+	return &Matcher{
+		client: c,
+		market: market,
+		minConfidence: minConfidence,
+	}
+}
+
+// OnProgress sets a function called after every looked up row.
+func (m *Matcher) OnProgress(f func(done, total int)) {
+	m.progress = f
+}
+
+// Match looks up every row, first by its spotify uri, then by isrc and last
+// by searching for its title and artists. ctx has to carry an access token.
+func (m *Matcher) Match(ctx context.Context, rows []Row) ([]Match, error) {
+	matches := make([]Match, len(rows))
+
+	for i, row := range rows {
+		matches[i] = Match{ Row: row, Method: MethodNone }
+	}
+
+	if err := m.matchUris(ctx, matches); err != nil {
+		return nil, err
+	}
+
+	for i := range matches {
+		if !matches[i].Accepted {
+			if err := m.matchIsrc(ctx, &matches[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		if !matches[i].Accepted {
+			if err := m.matchSearch(ctx, &matches[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		if m.progress != nil {
+			m.progress(i+1, len(matches))
+		}
+	}
+
+	return matches, nil
+}
+
+// matchUris checks the rows that carry a track uri in batches, a uri from an
+// old export can point to a track that was removed.
+func (m *Matcher) matchUris(ctx context.Context, matches []Match) error {
+	var ids []string
+	var pending []*Match
+
+	flush := func() error {
+		if len(ids) == 0 {
+			return nil
+		}
+
+		tracks, err := m.client.GetSeveralTracks(ctx, client.GetSeveralTracksParams{
+			Ids: ids,
+			Market: m.market,
+		})
+
+		if err != nil {
+			return fmt.Errorf("looking up tracks: %w", err)
+		}
+
+		for i, track := range tracks {
+			if i < len(pending) && track.Uri != "" {
+				pending[i].Track = track
+				pending[i].Method = MethodUri
+				pending[i].Confidence = 1
+				pending[i].Accepted = true
+			}
+		}
+
+		ids, pending = ids[:0], pending[:0]
+
+		return nil
+	}
+
+	for i := range matches {
+		id, ok := ParseTrackId(matches[i].Row.Uri)
+
+		if !ok {
+			continue
+		}
+
+		ids = append(ids, id)
+		pending = append(pending, &matches[i])
+
+		if len(ids) == client.MaxSeveralTracks {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+func (m *Matcher) search(ctx context.Context, q string, limit int) ([]types.Track, error) {
+	result, err := m.client.GetSearchResults(ctx, client.GetSearchResultsParams{
+		Q: q,
+		Type: []string{ "track" },
+		Market: m.market,
+		Limit: limit,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("searching for %q: %w", q, err)
+	}
+
+	return result.Tracks.Items, nil
+}
+
+func (m *Matcher) matchIsrc(ctx context.Context, match *Match) error {
+	isrc := strings.ToUpper(strings.TrimSpace(match.Row.Isrc))
+
+	if isrc == "" {
+		return nil
+	}
+
+	tracks, err := m.search(ctx, "isrc:"+isrc, 1)
+
+	if err != nil || len(tracks) == 0 {
+		return err
+	}
+
+	match.Track = tracks[0]
+	match.Method = MethodIsrc
+	match.Confidence = 1
+	match.Accepted = true
+
+	return nil
+}
+
+func quote(s string) string {
+	return strings.ReplaceAll(s, `"`, "")
+}
+
+// matchSearch searches with field filters first and falls back to a plain
+// query, which copes better with typos and differently written artists.
+func (m *Matcher) matchSearch(ctx context.Context, match *Match) error {
+	row := match.Row
+
+	if strings.TrimSpace(row.Name) == "" {
+		return nil
+	}
+
+	artist := ""
+
+	if len(row.Artists) > 0 {
+		artist = row.Artists[0]
+	}
+
+	queries := []string{ fmt.Sprintf(`track:"%s"`, quote(row.Name)) }
+
+	if artist != "" {
+		queries[0] += fmt.Sprintf(` artist:"%s"`, quote(artist))
+	}
+
+	queries = append(queries, strings.TrimSpace(row.Name+" "+artist))
+
+	for _, q := range queries {
+		tracks, err := m.search(ctx, q, 10)
+
+		if err != nil {
+			return err
+		}
+
+		for _, track := range tracks {
+			if score := Score(row.Entry, track); score > match.Confidence {
+				match.Track = track
+				match.Method = MethodSearch
+				match.Confidence = score
+			}
+		}
+
+		if match.Confidence >= m.minConfidence {
+			match.Accepted = true
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Score rates how likely track is the recording described by e, from 0 to 1.
+// Titles weigh the most, the duration only counts when e has one.
+func Score(e Entry, track types.Track) float64 {
+	total := 0.6 * similarity(Normalize(e.Name), Normalize(track.Name))
+	weight := 0.6
+
+	if len(e.Artists) > 0 {
+		best := 0.0
+
+		for _, want := range e.Artists {
+			for _, got := range track.Artists {
+				best = max(best, similarity(Normalize(want), Normalize(got.Name)))
+			}
+		}
+
+		total += 0.3 * best
+		weight += 0.3
+	}
+
+	if e.DurationMs > 0 && track.DurationMs > 0 {
+		// within 2s is a perfect match, 20s apart is likely another version
+		diff := math.Abs(float64(e.DurationMs-track.DurationMs)) / 1000
+		total += 0.1 * math.Max(0, math.Min(1, (20-diff)/18))
+		weight += 0.1
+	}
+
+	return total / weight
+}
+
+// AddUris appends uris to the playlist in batches of client.MaxAddItems and
+// returns the snapshot id after the last batch.
+func AddUris(ctx context.Context, c *client.Client, id string, uris []string) (string, error) {
+	var snapshot string
+
+	for start := 0; start < len(uris); start += client.MaxAddItems {
+		end := min(start+client.MaxAddItems, len(uris))
+
+		s, err := c.AddItemsToPlaylist(ctx, client.AddItemsToPlaylistParams{
+			Id: id,
+			Uris: uris[start:end],
+		})
+
+		if err != nil {
+			return snapshot, fmt.Errorf("adding items %d to %d: %w", start+1, end, err)
+		}
+
+		snapshot = s.SnapshotId
+	}
+
+	return snapshot, nil
+}
+
+// WriteReport writes the rows that were not matched as csv, with the best
+// candidate so they can be fixed by hand and imported again.
+func WriteReport(w io.Writer, matches []Match) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{ "line", "uri", "name", "artists", "album", "isrc", "duration_ms", "candidate", "candidate_uri", "confidence" }
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, m := range matches {
+		if m.Accepted {
+			continue
+		}
+
+		record := []string{
+			strconv.Itoa(m.Row.Line),
+			m.Row.Uri,
+			m.Row.Name,
+			m.Row.ArtistNames(),
+			m.Row.Album,
+			m.Row.Isrc,
+			strconv.Itoa(m.Row.DurationMs),
+			m.TrackName(),
+			m.Track.Uri,
+			strconv.FormatFloat(m.Confidence, 'f', 2, 64),
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
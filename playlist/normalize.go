@@ -0,0 +1,77 @@
+package playlist
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// featuring credits and bracketed versions, "Song (feat. X)" or "Song [Live]"
+	bracketPattern = regexp.MustCompile(`\s*[\(\[][^\)\]]*[\)\]]`)
+	// suffixes like "Song - Remastered 2011" or "Song - Radio Edit"
+	suffixPattern = regexp.MustCompile(`\s+-\s+.*\b(remaster(ed)?|version|edit|mix|live|mono|stereo|acoustic)\b.*$`)
+	featPattern = regexp.MustCompile(`\s+(feat\.?|ft\.?|featuring)\s+.*$`)
+)
+
+// Normalize reduces a title or artist name to the part that identifies the
+// recording, so the same song from a single, an album and a remaster compare
+// equal.
+func Normalize(s string) string {
+	s = strings.ToLower(s)
+	s = bracketPattern.ReplaceAllString(s, "")
+	s = suffixPattern.ReplaceAllString(s, "")
+	s = featPattern.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "&", " and ")
+
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\'' || r == '\u2019':
+			return -1
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			return r
+		}
+		return ' '
+	}, s)
+
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// similarity is the levenshtein distance of a and b scaled to 0 for nothing in
+// common and 1 for equal strings.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
@@ -0,0 +1,274 @@
+package playlist
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Row is an entry read from a track list together with its position in the
+// file, used when reporting rows that could not be matched.
+type Row struct {
+	Line int
+	Entry
+}
+
+// ReadFile reads a track list in any of the export formats. Files written by
+// other tools are accepted as long as they have recognizable columns.
+func ReadFile(path string) ([]Row, error) {
+	format, ok := FormatFromPath(path)
+
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown file type, use a .csv, .json, .m3u or .xspf file", path)
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	rows, err := Read(file, format)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	return rows, nil
+}
+
+func Read(r io.Reader, format Format) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatJSON:
+		return readJSON(r)
+	case FormatM3U:
+		return readM3U(r)
+	case FormatXSPF:
+		return readXSPF(r)
+	}
+
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// csvColumns maps the header names used by our export and by common
+// exporters to entry fields.
+var csvColumns = map[string][]string{
+	"uri": { "uri", "spotify_uri", "track_uri", "track uri", "spotify uri" },
+	"name": { "name", "title", "track", "track_name", "track name" },
+	"artists": { "artists", "artist", "artist_name", "artist name", "artist name(s)", "artist_names" },
+	"album": { "album", "album_name", "album name" },
+	"isrc": { "isrc" },
+	"duration_ms": { "duration_ms", "duration (ms)", "track duration (ms)" },
+}
+
+func readCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+
+		for field, names := range csvColumns {
+			for _, name := range names {
+				if _, ok := index[field]; !ok && column == name {
+					index[field] = i
+				}
+			}
+		}
+	}
+
+	_, hasUri := index["uri"]
+	_, hasName := index["name"]
+
+	if !hasUri && !hasName {
+		return nil, fmt.Errorf("no uri or name column in the header %q", strings.Join(header, ","))
+	}
+
+	var rows []Row
+
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		get := func(field string) string {
+			if i, ok := index[field]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		entry := Entry{
+			Uri: get("uri"),
+			Name: get("name"),
+			Album: get("album"),
+			Isrc: get("isrc"),
+			Artists: splitArtists(get("artists")),
+		}
+
+		entry.DurationMs, _ = strconv.Atoi(get("duration_ms"))
+
+		if entry.Uri == "" && entry.Name == "" {
+			continue
+		}
+
+		rows = append(rows, Row{ Line: line, Entry: entry })
+	}
+
+	return rows, nil
+}
+
+func splitArtists(s string) []string {
+	var artists []string
+
+	for _, artist := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if artist = strings.TrimSpace(artist); artist != "" {
+			artists = append(artists, artist)
+		}
+	}
+
+	return artists
+}
+
+func readJSON(r io.Reader) ([]Row, error) {
+	data, err := io.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+
+	// either our own export or a bare list of entries
+	var export jsonExport
+
+	if err := json.Unmarshal(data, &export); err == nil {
+		entries = export.Items
+	} else if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("expected an export or a list of tracks: %w", err)
+	}
+
+	rows := make([]Row, 0, len(entries))
+
+	for i, entry := range entries {
+		rows = append(rows, Row{ Line: i+1, Entry: entry })
+	}
+
+	return rows, nil
+}
+
+func readM3U(r io.Reader) ([]Row, error) {
+	data, err := io.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	var pending Entry
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			pending = Entry{}
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			seconds, title, _ := strings.Cut(info, ",")
+
+			if n, err := strconv.Atoi(strings.TrimSpace(seconds)); err == nil && n > 0 {
+				pending.DurationMs = n*1000
+			}
+
+			if artists, name, ok := strings.Cut(title, " - "); ok {
+				pending.Artists = splitArtists(artists)
+				pending.Name = strings.TrimSpace(name)
+			} else {
+				pending.Name = strings.TrimSpace(title)
+			}
+		case strings.HasPrefix(line, "#EXTALB:"):
+			pending.Album = strings.TrimSpace(strings.TrimPrefix(line, "#EXTALB:"))
+		case strings.HasPrefix(line, "#"):
+		default:
+			entry := pending
+			pending = Entry{}
+
+			if strings.HasPrefix(line, "spotify:") || strings.Contains(line, "open.spotify.com/") {
+				entry.Uri = line
+			} else if entry.Name == "" {
+				// a plain file without #EXTINF, guess from the file name
+				name := strings.TrimSuffix(filepath.Base(line), filepath.Ext(line))
+
+				if artists, title, ok := strings.Cut(name, " - "); ok {
+					entry.Artists = splitArtists(artists)
+					entry.Name = title
+				} else {
+					entry.Name = name
+				}
+			}
+
+			rows = append(rows, Row{ Line: i+1, Entry: entry })
+		}
+	}
+
+	return rows, nil
+}
+
+func readXSPF(r io.Reader) ([]Row, error) {
+	var doc xspfPlaylist
+
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, 0, len(doc.Tracks))
+
+	for i, track := range doc.Tracks {
+		entry := Entry{
+			Uri: track.Location,
+			Name: track.Title,
+			Artists: splitArtists(track.Creator),
+			Album: track.Album,
+			DurationMs: track.Duration,
+			Isrc: strings.TrimPrefix(track.Identifier, "urn:isrc:"),
+		}
+
+		for _, meta := range track.Meta {
+			switch strings.TrimPrefix(meta.Rel, xspfMetaRel) {
+			case "added_at":
+				entry.AddedAt = meta.Value
+			case "added_by":
+				entry.AddedBy = meta.Value
+			}
+		}
+
+		rows = append(rows, Row{ Line: i+1, Entry: entry })
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,142 @@
+package playlist
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/library"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+var idPattern = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+
+// NotFoundError is returned by Resolve when no playlist has the given name.
+type NotFoundError struct {
+	Ref string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("no playlist named %q", e.Ref)
+}
+
+// ParseId extracts the playlist id from a spotify uri like
+// spotify:playlist:<id> or a link like https://open.spotify.com/playlist/<id>.
+func ParseId(ref string) (string, bool) {
+	return parseRef("playlist", ref)
+}
+
+// ParseTrackId is ParseId for track uris and links.
+func ParseTrackId(ref string) (string, bool) {
+	return parseRef("track", ref)
+}
+
+func parseRef(kind, ref string) (string, bool) {
+	if id, ok := strings.CutPrefix(ref, "spotify:"+kind+":"); ok && idPattern.MatchString(id) {
+		return id, true
+	}
+
+	u, err := url.Parse(ref)
+
+	if err != nil || u.Host != "open.spotify.com" {
+		return "", false
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	if len(parts) >= 2 && parts[len(parts)-2] == kind && idPattern.MatchString(parts[len(parts)-1]) {
+		return parts[len(parts)-1], true
+	}
+
+	return "", false
+}
+
+func match(playlists []types.SimplifiedPlaylistObject, ref string) (types.SimplifiedPlaylistObject, bool, error) {
+	var found []types.SimplifiedPlaylistObject
+
+	for _, p := range playlists {
+		if p.Id == ref {
+			return p, true, nil
+		}
+
+		if strings.EqualFold(p.Name, ref) {
+			found = append(found, p)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return types.SimplifiedPlaylistObject{}, false, nil
+	case 1:
+		return found[0], true, nil
+	}
+
+	ids := make([]string, 0, len(found))
+
+	for _, p := range found {
+		ids = append(ids, p.Id)
+	}
+
+	return types.SimplifiedPlaylistObject{}, false, fmt.Errorf("%d playlists are named %q, use one of the ids %s", len(found), ref, strings.Join(ids, ", "))
+}
+
+// Resolve finds a playlist by id, uri, link or name. Names are looked up in
+// the local mirror first and in the user's playlists on spotify second.
+// ctx has to carry an access token, see client.WithAccessToken.
+func Resolve(ctx context.Context, db *sql.DB, c *client.Client, ref string) (types.SimplifiedPlaylistObject, error) {
+	id, ok := ParseId(ref)
+
+	if !ok {
+		local, err := library.Playlists(ctx, database.New(db))
+
+		if err != nil {
+			return types.SimplifiedPlaylistObject{}, err
+		}
+
+		if p, ok, err := match(local, ref); ok || err != nil {
+			return p, err
+		}
+
+		remote, err := c.GetAllCurrentUsersPlaylists(ctx)
+
+		if err != nil {
+			return types.SimplifiedPlaylistObject{}, err
+		}
+
+		if p, ok, err := match(remote, ref); ok || err != nil {
+			return p, err
+		}
+
+		if !idPattern.MatchString(ref) {
+			return types.SimplifiedPlaylistObject{}, NotFoundError{ Ref: ref }
+		}
+
+		id = ref
+	}
+
+	p, err := c.GetPlaylist(ctx, client.GetPlaylistParams{ Id: id })
+
+	if err != nil {
+		return types.SimplifiedPlaylistObject{}, err
+	}
+
+	return types.SimplifiedPlaylistObject{
+		Id: p.Id,
+		Name: p.Name,
+		Description: p.Description,
+		Collaborative: p.Collaborative,
+		SnapshotId: p.SnapshotId,
+		Owner: p.Owner,
+		Public: p.Public,
+		Type: p.Type,
+		Uri: p.Uri,
+		Tracks: types.SimplifiedPlaylistTrack{
+			Total: p.Tracks.Total,
+		},
+	}, nil
+}
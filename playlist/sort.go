@@ -0,0 +1,288 @@
+package playlist
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+type SortKey string
+
+const (
+	SortArtist SortKey = "artist"
+	SortAlbum SortKey = "album"
+	SortRelease SortKey = "release"
+	SortAdded SortKey = "added"
+	SortPopularity SortKey = "popularity"
+	SortDuration SortKey = "duration"
+	SortRandom SortKey = "random"
+)
+
+var SortKeys = []SortKey{ SortArtist, SortAlbum, SortRelease, SortAdded, SortPopularity, SortDuration, SortRandom }
+
+func ParseSortKey(s string) (SortKey, error) {
+	key := SortKey(strings.ToLower(s))
+
+	if !slices.Contains(SortKeys, key) {
+		names := make([]string, len(SortKeys))
+
+		for i, k := range SortKeys {
+			names[i] = string(k)
+		}
+
+		return "", fmt.Errorf("unknown sort key %q, use one of %s", s, strings.Join(names, ", "))
+	}
+
+	return key, nil
+}
+
+// sortItem holds what an item is sorted by. Items that are no longer
+// available have no fields and always sort last.
+type sortItem struct {
+	uri string
+	available bool
+	artist string
+	album string
+	release string
+	disc int
+	track int
+	name string
+	added string
+	popularity int
+	duration int
+}
+
+func newSortItem(uri string, item types.PlaylistItemUnion) sortItem {
+	s := sortItem{ uri: uri, added: item.AddedAt.Value }
+
+	switch {
+	case item.Track.Type == "track" && item.Track.Track != nil:
+		track := item.Track.Track
+		s.available = true
+		s.album = Normalize(track.Album.Name)
+		s.release = track.Album.ReleaseDate
+		s.disc = track.DiscNumber
+		s.track = track.TrackNumber
+		s.name = Normalize(track.Name)
+		s.popularity = track.Popularity
+		s.duration = track.DurationMs
+
+		if len(track.Artists) > 0 {
+			s.artist = Normalize(track.Artists[0].Name)
+		}
+	case item.Track.Type == "episode" && item.Track.Episode != nil:
+		episode := item.Track.Episode
+		s.available = true
+		s.release = episode.ReleaseDate
+		s.name = Normalize(episode.Name)
+		s.duration = episode.DurationMs
+	}
+
+	return s
+}
+
+// albumOrder keeps the tracks of an album in their order on the album.
+func albumOrder(a, b sortItem) int {
+	return cmp.Or(
+		strings.Compare(a.album, b.album),
+		cmp.Compare(a.disc, b.disc),
+		cmp.Compare(a.track, b.track),
+		strings.Compare(a.name, b.name),
+	)
+}
+
+func compareBy(key SortKey) func(a, b sortItem) int {
+	switch key {
+	case SortArtist:
+		// an artist's albums in release order
+		return func(a, b sortItem) int {
+			return cmp.Or(strings.Compare(a.artist, b.artist), strings.Compare(a.release, b.release), albumOrder(a, b))
+		}
+	case SortAlbum:
+		return albumOrder
+	case SortRelease:
+		return func(a, b sortItem) int {
+			return cmp.Or(strings.Compare(a.release, b.release), albumOrder(a, b))
+		}
+	case SortAdded:
+		return func(a, b sortItem) int { return strings.Compare(a.added, b.added) }
+	case SortPopularity:
+		// most popular first
+		return func(a, b sortItem) int { return cmp.Compare(b.popularity, a.popularity) }
+	case SortDuration:
+		return func(a, b sortItem) int { return cmp.Compare(a.duration, b.duration) }
+	}
+
+	return nil
+}
+
+// Sort returns the uris of items in a new order, as returned by EditUris so
+// the result can be passed to Plan. The sort is stable and reverse flips the
+// order of the available items. SortRandom shuffles with seed, so the same
+// seed gives the same order.
+func Sort(items []types.PlaylistItemUnion, key SortKey, reverse bool, seed uint64) []string {
+	uris := EditUris(items)
+	sorted := make([]sortItem, 0, len(items))
+	var unavailable []string
+
+	for i, item := range items {
+		s := newSortItem(uris[i], item)
+
+		if !s.available {
+			unavailable = append(unavailable, s.uri)
+			continue
+		}
+
+		sorted = append(sorted, s)
+	}
+
+	if key == SortRandom {
+		r := rand.New(rand.NewPCG(seed, seed))
+		r.Shuffle(len(sorted), func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+	} else {
+		slices.SortStableFunc(sorted, compareBy(key))
+	}
+
+	if reverse {
+		slices.Reverse(sorted)
+	}
+
+	result := make([]string, 0, len(uris))
+
+	for _, s := range sorted {
+		result = append(result, s.uri)
+	}
+
+	return append(result, unavailable...)
+}
+
+// SpreadShuffle shuffles entries so the same artist does not play twice in a
+// row. Each pick is random, weighted by how many tracks an artist has left,
+// except that an artist with more than half of the remaining tracks is picked
+// first so the rest can still be spread. When one artist has most of the
+// tracks some of them end up next to each other.
+func SpreadShuffle(entries []Entry, seed uint64) []Entry {
+	r := rand.New(rand.NewPCG(seed, seed))
+
+	groups := make(map[string][]Entry)
+	var artists []string
+
+	for _, e := range entries {
+		artist := ""
+
+		if len(e.Artists) > 0 {
+			artist = Normalize(e.Artists[0])
+		}
+
+		if _, ok := groups[artist]; !ok {
+			artists = append(artists, artist)
+		}
+
+		groups[artist] = append(groups[artist], e)
+	}
+
+	for _, artist := range artists {
+		group := groups[artist]
+		r.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+	}
+
+	shuffled := make([]Entry, 0, len(entries))
+	last := ""
+	first := true
+
+	for remaining := len(entries); remaining > 0; remaining-- {
+		pick := ""
+		found := false
+		total := 0
+
+		for _, artist := range artists {
+			n := len(groups[artist])
+
+			if n == 0 || (!first && artist == last) {
+				continue
+			}
+
+			if 2*n > remaining {
+				pick, found = artist, true
+				break
+			}
+
+			total += n
+		}
+
+		if !found && total > 0 {
+			target := r.IntN(total)
+
+			for _, artist := range artists {
+				n := len(groups[artist])
+
+				if n == 0 || (!first && artist == last) {
+					continue
+				}
+
+				if target < n {
+					pick, found = artist, true
+					break
+				}
+
+				target -= n
+			}
+		}
+
+		if !found {
+			// only the last artist has tracks left
+			pick = last
+		}
+
+		shuffled = append(shuffled, groups[pick][0])
+		groups[pick] = groups[pick][1:]
+		last, first = pick, false
+	}
+
+	return shuffled
+}
+
+// Rewrite replaces the items of the playlist with uris in one go. It is much
+// faster than moving items for a large playlist but resets their added dates.
+func Rewrite(ctx context.Context, c *client.Client, id, snapshot string, uris []string) (string, error) {
+	for _, uri := range uris {
+		if strings.HasPrefix(uri, "spotify:local:") || unavailablePattern.MatchString(uri) {
+			return snapshot, fmt.Errorf("%s cannot be added to a playlist, rewriting would drop it", uri)
+		}
+	}
+
+	current, err := c.GetPlaylist(ctx, client.GetPlaylistParams{
+		Id: id,
+		Fields: []string{ "snapshot_id" },
+	})
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	if current.SnapshotId != snapshot {
+		return snapshot, ErrConflict
+	}
+
+	first := uris[:min(len(uris), client.MaxAddItems)]
+
+	result, err := c.ReplacePlaylistItems(ctx, client.ReplacePlaylistItemsParams{ Id: id, Uris: first })
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	snapshot = result.SnapshotId
+
+	if len(uris) > len(first) {
+		return AddUris(ctx, c, id, uris[len(first):])
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,312 @@
+package playlist
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// Change is what happened to a watched playlist between two checks.
+type Change struct {
+	PlaylistId string `json:"playlist_id"`
+	PlaylistName string `json:"playlist_name"`
+	SnapshotId string `json:"snapshot_id"`
+	DetectedAt string `json:"detected_at"`
+	Added []Entry `json:"added"`
+	Removed []Entry `json:"removed"`
+}
+
+// AddedBy lists the users who added the new tracks.
+func (c Change) AddedBy() []string {
+	var users []string
+
+	for _, e := range c.Added {
+		if e.AddedBy != "" && !slices.Contains(users, e.AddedBy) {
+			users = append(users, e.AddedBy)
+		}
+	}
+
+	return users
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s: %d added, %d removed", c.PlaylistName, len(c.Added), len(c.Removed))
+
+	if users := c.AddedBy(); len(users) > 0 {
+		s += " (added by " + strings.Join(users, ", ") + ")"
+	}
+
+	return s
+}
+
+// changedEntries compares two versions of a playlist by uri, counting copies
+// so adding a second copy of a track is reported too.
+func changedEntries(old, new []Entry) (added, removed []Entry) {
+	oldCount := make(map[string]int)
+	newCount := make(map[string]int)
+
+	for _, e := range old {
+		oldCount[e.Uri]++
+	}
+
+	for _, e := range new {
+		newCount[e.Uri]++
+	}
+
+	seen := make(map[string]int)
+
+	for _, e := range new {
+		if seen[e.Uri]++; seen[e.Uri] > oldCount[e.Uri] {
+			added = append(added, e)
+		}
+	}
+
+	clear(seen)
+
+	for _, e := range old {
+		if seen[e.Uri]++; seen[e.Uri] > newCount[e.Uri] {
+			removed = append(removed, e)
+		}
+	}
+
+	return added, removed
+}
+
+func Watch(ctx context.Context, db *sql.DB, c *client.Client, market string, p types.SimplifiedPlaylistObject) error {
+	q := database.New(db)
+
+	err := q.UpsertPlaylistWatch(ctx, database.UpsertPlaylistWatchParams{
+		PlaylistID: p.Id,
+		Name: p.Name,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	watches, err := q.ListPlaylistWatches(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// the first check records the current items to compare against, the
+	// other watched playlists keep their changes for the next check
+	for _, w := range watches {
+		if w.PlaylistID == p.Id {
+			_, _, err := checkWatch(ctx, q, c, market, w)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Unwatch(ctx context.Context, db *sql.DB, id string) (bool, error) {
+	n, err := database.New(db).DeletePlaylistWatch(ctx, id)
+	return n > 0, err
+}
+
+// CheckWatched looks at the snapshot of every watched playlist and fetches the
+// items of the ones that changed, logging and returning what was added and
+// removed. Changes that only reorder items or edit the details are not
+// reported. A playlist that cannot be checked does not stop the others.
+func CheckWatched(ctx context.Context, db *sql.DB, c *client.Client, market string) ([]Change, error) {
+	q := database.New(db)
+
+	watches, err := q.ListPlaylistWatches(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	var errs []error
+
+	for _, w := range watches {
+		change, changed, err := checkWatch(ctx, q, c, market, w)
+
+		// a logged change is reported even if saving the new items failed
+		if changed {
+			changes = append(changes, change)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", w.Name, err))
+		}
+	}
+
+	return changes, errors.Join(errs...)
+}
+
+// checkWatch checks a single watched playlist, changed is false when nothing
+// was added or removed or it is the first check.
+func checkWatch(ctx context.Context, q *database.Queries, c *client.Client, market string, w database.PlaylistWatch) (change Change, changed bool, err error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	current, err := c.GetPlaylist(ctx, client.GetPlaylistParams{
+		Id: w.PlaylistID,
+		Fields: []string{ "snapshot_id", "name" },
+	})
+
+	if err != nil {
+		return change, false, err
+	}
+
+	if current.SnapshotId == w.SnapshotID {
+		err := q.SetPlaylistWatchChecked(ctx, database.SetPlaylistWatchCheckedParams{ CheckedAt: now, PlaylistID: w.PlaylistID })
+		return change, false, err
+	}
+
+	items, err := c.GetAllPlaylistItems(ctx, w.PlaylistID, market)
+
+	if err != nil {
+		return change, false, err
+	}
+
+	entries := Entries(items)
+
+	if w.SnapshotID != "" {
+		var old []Entry
+
+		if err := json.Unmarshal([]byte(w.Entries), &old); err != nil {
+			return change, false, err
+		}
+
+		change = Change{
+			PlaylistId: w.PlaylistID,
+			PlaylistName: current.Name,
+			SnapshotId: current.SnapshotId,
+			DetectedAt: now,
+		}
+
+		change.Added, change.Removed = changedEntries(old, entries)
+
+		if len(change.Added) > 0 || len(change.Removed) > 0 {
+			if err := logChange(ctx, q, change); err != nil {
+				return change, false, err
+			}
+
+			changed = true
+		}
+	}
+
+	data, err := json.Marshal(entries)
+
+	if err != nil {
+		return change, false, err
+	}
+
+	err = q.UpdatePlaylistWatch(ctx, database.UpdatePlaylistWatchParams{
+		Name: current.Name,
+		SnapshotID: current.SnapshotId,
+		Entries: string(data),
+		CheckedAt: now,
+		PlaylistID: w.PlaylistID,
+	})
+
+	return change, changed, err
+}
+
+func logChange(ctx context.Context, q *database.Queries, change Change) error {
+	log := func(kind string, e Entry) error {
+		return q.InsertPlaylistChange(ctx, database.InsertPlaylistChangeParams{
+			PlaylistID: change.PlaylistId,
+			PlaylistName: change.PlaylistName,
+			SnapshotID: change.SnapshotId,
+			DetectedAt: change.DetectedAt,
+			Change: kind,
+			TrackUri: e.Uri,
+			TrackName: e.Name,
+			ArtistNames: e.ArtistNames(),
+			AddedBy: e.AddedBy,
+		})
+	}
+
+	for _, e := range change.Added {
+		if err := log("added", e); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range change.Removed {
+		if err := log("removed", e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ChangesAfter reads the changes logged after the row with id back from the
+// change log, e.g. the ones a daemon found, and returns the id of the last
+// row to continue from.
+func ChangesAfter(ctx context.Context, db *sql.DB, id int64) ([]Change, int64, error) {
+	rows, err := database.New(db).ListPlaylistChangesAfter(ctx, id)
+
+	if err != nil {
+		return nil, id, err
+	}
+
+	var changes []Change
+
+	for _, row := range rows {
+		id = row.ID
+
+		// the rows of a change are logged together and share its snapshot
+		if n := len(changes); n == 0 || changes[n-1].PlaylistId != row.PlaylistID || changes[n-1].SnapshotId != row.SnapshotID {
+			changes = append(changes, Change{
+				PlaylistId: row.PlaylistID,
+				PlaylistName: row.PlaylistName,
+				SnapshotId: row.SnapshotID,
+				DetectedAt: row.DetectedAt,
+			})
+		}
+
+		change := &changes[len(changes)-1]
+
+		e := Entry{
+			Uri: row.TrackUri,
+			Name: row.TrackName,
+			Artists: []string{},
+			AddedBy: row.AddedBy,
+		}
+
+		if row.ArtistNames != "" {
+			e.Artists = strings.Split(row.ArtistNames, ", ")
+		}
+
+		if row.Change == "removed" {
+			change.Removed = append(change.Removed, e)
+		} else {
+			change.Added = append(change.Added, e)
+		}
+	}
+
+	return changes, id, nil
+}
+
+// LastChangeId is the id of the newest row of the change log, 0 when it is
+// empty.
+func LastChangeId(ctx context.Context, db *sql.DB) (int64, error) {
+	rows, err := database.New(db).ListPlaylistChanges(ctx, database.ListPlaylistChangesParams{
+		AllPlaylists: true,
+		Limit: 1,
+	})
+
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+
+	return rows[0].ID, nil
+}
@@ -0,0 +1,154 @@
+package poller
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/output"
+)
+
+type Kind string
+
+const (
+	// State is the first state after the poller starts, so a consumer
+	// knows where things stand before the first change.
+	State Kind = "state"
+	TrackChanged Kind = "track_changed"
+	Paused Kind = "paused"
+	Resumed Kind = "resumed"
+	Seeked Kind = "seeked"
+	DeviceChanged Kind = "device_changed"
+	VolumeChanged Kind = "volume_changed"
+	ShuffleChanged Kind = "shuffle_changed"
+	RepeatChanged Kind = "repeat_changed"
+	ContextChanged Kind = "context_changed"
+)
+
+var Kinds = []Kind{
+	State,
+	TrackChanged,
+	Paused,
+	Resumed,
+	Seeked,
+	DeviceChanged,
+	VolumeChanged,
+	ShuffleChanged,
+	RepeatChanged,
+	ContextChanged,
+}
+
+// ParseKinds parses a comma separated list of event kinds.
+func ParseKinds(s string) ([]Kind, error) {
+	var kinds []Kind
+
+	for _, name := range strings.Split(s, ",") {
+		kind := Kind(strings.TrimSpace(name))
+
+		if !slices.Contains(Kinds, kind) {
+			names := make([]string, 0, len(Kinds))
+
+			for _, k := range Kinds {
+				names = append(names, string(k))
+			}
+
+			return nil, fmt.Errorf("unknown event %q, use one of %s", name, strings.Join(names, ", "))
+		}
+
+		kinds = append(kinds, kind)
+	}
+
+	return kinds, nil
+}
+
+// Event is a change of the playback state. State is the state after the
+// change and Previous the one before it, which the state event has none of.
+type Event struct {
+	Kind Kind `json:"event"`
+	Time time.Time `json:"time"`
+	State output.NowPlaying `json:"state"`
+	Previous output.NowPlaying `json:"previous,omitzero"`
+}
+
+func (e Event) Fields() []string {
+	return append([]string{ string(e.Kind), e.Time.Format(time.RFC3339) }, e.State.Fields()...)
+}
+
+// seekTolerance is how far the progress may drift from where the last poll
+// predicts before it counts as a seek. Polls are not exact, a track does
+// not play at precisely the rate the clock runs.
+const seekTolerance = 3*time.Second
+
+// Diff returns the events that lead from prev to next, elapsed apart.
+func Diff(prev, next output.NowPlaying, elapsed time.Duration, at time.Time) []Event {
+	var kinds []Kind
+
+	sameTrack := prev.Uri == next.Uri
+
+	if !sameTrack {
+		kinds = append(kinds, TrackChanged)
+	}
+
+	if prev.Playing != next.Playing && next.Uri != "" {
+		if next.Playing {
+			kinds = append(kinds, Resumed)
+		} else {
+			kinds = append(kinds, Paused)
+		}
+	}
+
+	if sameTrack && next.Uri != "" {
+		expected := time.Duration(prev.ProgressMs)*time.Millisecond
+
+		if prev.Playing {
+			expected += elapsed
+		}
+
+		drift := time.Duration(next.ProgressMs)*time.Millisecond - expected
+
+		if drift < -seekTolerance || drift > seekTolerance {
+			kinds = append(kinds, Seeked)
+		}
+	}
+
+	if prev.Device.Id != next.Device.Id {
+		kinds = append(kinds, DeviceChanged)
+	} else if volume(prev.Device) != volume(next.Device) {
+		kinds = append(kinds, VolumeChanged)
+	}
+
+	if prev.Shuffle != next.Shuffle {
+		kinds = append(kinds, ShuffleChanged)
+	}
+
+	if prev.Repeat != next.Repeat {
+		kinds = append(kinds, RepeatChanged)
+	}
+
+	if prev.Context != next.Context {
+		kinds = append(kinds, ContextChanged)
+	}
+
+	events := make([]Event, 0, len(kinds))
+
+	for _, kind := range kinds {
+		events = append(events, Event{
+			Kind: kind,
+			Time: at,
+			State: next,
+			Previous: prev,
+		})
+	}
+
+	return events
+}
+
+func volume(d output.Device) string {
+	if d.Volume == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*d.Volume)
+}
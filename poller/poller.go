@@ -0,0 +1,167 @@
+// Package poller asks spotify for the playback state and turns the
+// difference between two polls into events. It polls faster near the end
+// of a track, so the next one is noticed right away, and slower while
+// paused or after errors.
+package poller
+
+import (
+	"context"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// FetchFunc returns the playback state, empty when nothing is playing.
+type FetchFunc func(ctx context.Context) (types.CurrentlyPlaying, error)
+
+type Options struct {
+	// Interval is how often the state is polled while playing.
+	Interval time.Duration
+	// Paused is how often it is polled while paused or nothing plays.
+	Paused time.Duration
+	// Max is the longest wait between polls after errors.
+	Max time.Duration
+}
+
+const (
+	defaultInterval = 2*time.Second
+	defaultPaused = 10*time.Second
+	defaultMax = time.Minute
+	// minInterval keeps the poll at the end of a track from running
+	// before spotify moved on.
+	minInterval = 500*time.Millisecond
+	// endSlack is waited past the end of a track before polling for the
+	// next one.
+	endSlack = 300*time.Millisecond
+)
+
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = defaultInterval
+	}
+
+	if o.Paused <= 0 {
+		o.Paused = defaultPaused
+	}
+
+	if o.Paused < o.Interval {
+		o.Paused = o.Interval
+	}
+
+	if o.Max <= 0 {
+		o.Max = defaultMax
+	}
+
+	return o
+}
+
+// Result is the outcome of a poll. Raw is the state as spotify sent it for
+// consumers that need more than State.
+type Result struct {
+	Time time.Time
+	Raw types.CurrentlyPlaying
+	State output.NowPlaying
+	Events []Event
+	Err error
+}
+
+type Poller struct {
+	fetch FetchFunc
+	opts Options
+	refresh chan struct{}
+}
+
+func New(fetch FetchFunc, opts Options) *Poller {
+	return &Poller{
+		fetch: fetch,
+		opts: opts.withDefaults(),
+		refresh: make(chan struct{}, 1),
+	}
+}
+
+// Refresh polls right away, e.g. after playback was changed.
+func (p *Poller) Refresh() {
+	select {
+	case p.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Run polls until ctx is done and sends the result of every poll to
+// results, which it closes when it returns.
+func (p *Poller) Run(ctx context.Context, results chan<- Result) {
+	defer close(results)
+
+	var last output.NowPlaying
+	var lastAt time.Time
+	failures := 0
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-p.refresh:
+		}
+
+		raw, err := p.fetch(ctx)
+		now := time.Now()
+
+		result := Result{ Time: now, Raw: raw, Err: err }
+
+		if err != nil {
+			failures++
+		} else {
+			failures = 0
+			result.State = output.NewNowPlaying(raw)
+
+			if lastAt.IsZero() {
+				result.Events = []Event{{ Kind: State, Time: now, State: result.State }}
+			} else {
+				result.Events = Diff(last, result.State, now.Sub(lastAt), now)
+			}
+
+			last = result.State
+			lastAt = now
+		}
+
+		select {
+		case results <- result:
+		case <-ctx.Done():
+			return
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+
+		timer.Reset(p.next(last, failures))
+	}
+}
+
+// next is how long to wait for the next poll.
+func (p *Poller) next(n output.NowPlaying, failures int) time.Duration {
+	if failures > 0 {
+		wait := p.opts.Interval << min(failures, 10)
+		return min(wait, p.opts.Max)
+	}
+
+	if !n.Playing || n.Uri == "" {
+		return p.opts.Paused
+	}
+
+	remaining := time.Duration(n.DurationMs - n.ProgressMs)*time.Millisecond + endSlack
+
+	if remaining < p.opts.Interval {
+		return max(remaining, minInterval)
+	}
+
+	return p.opts.Interval
+}
@@ -0,0 +1,143 @@
+package smart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	SourceLiked = "liked"
+	SourceAll = "all"
+)
+
+const (
+	SortAdded = "added"
+	SortRandom = "random"
+	SortPopularity = "popularity"
+	SortPlays = "plays"
+	SortLeastPlayed = "least_played"
+)
+
+var Sorts = []string{ SortAdded, SortRandom, SortPopularity, SortPlays, SortLeastPlayed }
+
+// MaxLimit keeps a smart playlist well below the size spotify allows.
+const MaxLimit = 5000
+
+// Rules select tracks from the local mirror. Zero values disable a rule, so
+// an empty Rules matches every liked song.
+type Rules struct {
+	// Source is liked, all for liked songs and every mirrored playlist, or
+	// the id of a single playlist
+	Source string `json:"source"`
+	// SourceName is the name of the source playlist, for display only
+	SourceName string `json:"source_name,omitempty"`
+	// Genres match when any artist of the track has a genre containing one
+	// of them, so "indie" matches "indie rock"
+	Genres []string `json:"genres,omitempty"`
+	Artists []string `json:"artists,omitempty"`
+	AddedWithinDays int `json:"added_within_days,omitempty"`
+	NotPlayedWithinDays int `json:"not_played_within_days,omitempty"`
+	MinPopularity int `json:"min_popularity,omitempty"`
+	ExcludeExplicit bool `json:"exclude_explicit,omitempty"`
+	Sort string `json:"sort"`
+	Limit int `json:"limit"`
+}
+
+func DefaultRules() Rules {
+	return Rules{
+		Source: SourceLiked,
+		Sort: SortAdded,
+		Limit: 100,
+	}
+}
+
+func (r Rules) Validate() error {
+	var errs []error
+
+	if r.Source == "" {
+		errs = append(errs, fmt.Errorf("source: use liked, all or a playlist"))
+	}
+
+	if r.AddedWithinDays < 0 || r.NotPlayedWithinDays < 0 {
+		errs = append(errs, fmt.Errorf("days must not be negative"))
+	}
+
+	if r.MinPopularity < 0 || r.MinPopularity > 100 {
+		errs = append(errs, fmt.Errorf("min popularity: must be between 0 and 100, got %d", r.MinPopularity))
+	}
+
+	if !slices.Contains(Sorts, r.Sort) {
+		errs = append(errs, fmt.Errorf("sort: %q must be one of %s", r.Sort, strings.Join(Sorts, ", ")))
+	}
+
+	if r.Limit < 1 || r.Limit > MaxLimit {
+		errs = append(errs, fmt.Errorf("limit: must be between 1 and %d, got %d", MaxLimit, r.Limit))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r Rules) Marshal() (string, error) {
+	data, err := json.Marshal(r)
+	return string(data), err
+}
+
+func ParseRules(s string) (Rules, error) {
+	r := DefaultRules()
+	err := json.Unmarshal([]byte(s), &r)
+	return r, err
+}
+
+// String describes the rules in a sentence, e.g. "liked songs by artists in
+// indie added in the last 90 days, not played in 30 days, max 100".
+func (r Rules) String() string {
+	var b strings.Builder
+
+	switch r.Source {
+	case SourceLiked:
+		b.WriteString("liked songs")
+	case SourceAll:
+		b.WriteString("liked songs and playlists")
+	default:
+		name := r.SourceName
+
+		if name == "" {
+			name = r.Source
+		}
+
+		fmt.Fprintf(&b, "tracks from %s", name)
+	}
+
+	if len(r.Artists) > 0 {
+		fmt.Fprintf(&b, " by %s", strings.Join(r.Artists, " or "))
+	}
+
+	if len(r.Genres) > 0 {
+		fmt.Fprintf(&b, " by artists in %s", strings.Join(r.Genres, " or "))
+	}
+
+	if r.AddedWithinDays > 0 {
+		fmt.Fprintf(&b, " added in the last %d days", r.AddedWithinDays)
+	}
+
+	var extra []string
+
+	if r.NotPlayedWithinDays > 0 {
+		extra = append(extra, fmt.Sprintf("not played in %d days", r.NotPlayedWithinDays))
+	}
+
+	if r.MinPopularity > 0 {
+		extra = append(extra, fmt.Sprintf("popularity at least %d", r.MinPopularity))
+	}
+
+	if r.ExcludeExplicit {
+		extra = append(extra, "no explicit tracks")
+	}
+
+	extra = append(extra, "sorted by "+strings.ReplaceAll(r.Sort, "_", " "), fmt.Sprintf("max %d", r.Limit))
+
+	return b.String() + ", " + strings.Join(extra, ", ")
+}
@@ -0,0 +1,413 @@
+package smart
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/database"
+	"github.com/arjunmoola/go-spotify/playlist"
+	"github.com/arjunmoola/go-spotify/types"
+)
+
+// Playlist is a smart playlist as stored in the database. PlaylistId is the
+// spotify playlist it is written to, empty until the first refresh.
+type Playlist struct {
+	Name string
+	Rules Rules
+	PlaylistId string
+	// RefreshInterval is how often the playlist is refreshed in the
+	// background, zero for only on request
+	RefreshInterval time.Duration
+	RefreshedAt time.Time
+}
+
+func fromRow(row database.SmartPlaylist) (Playlist, error) {
+	rules, err := ParseRules(row.Rules)
+
+	if err != nil {
+		return Playlist{}, fmt.Errorf("smart playlist %s: %w", row.Name, err)
+	}
+
+	p := Playlist{
+		Name: row.Name,
+		Rules: rules,
+		PlaylistId: row.PlaylistID,
+	}
+
+	if row.RefreshInterval != "" {
+		p.RefreshInterval, _ = time.ParseDuration(row.RefreshInterval)
+	}
+
+	if row.RefreshedAt != "" {
+		p.RefreshedAt, _ = time.Parse(time.RFC3339, row.RefreshedAt)
+	}
+
+	return p, nil
+}
+
+// Due reports whether the playlist should be refreshed in the background.
+func (p Playlist) Due(now time.Time) bool {
+	return p.RefreshInterval > 0 && now.Sub(p.RefreshedAt) >= p.RefreshInterval
+}
+
+func Save(ctx context.Context, q *database.Queries, name string, rules Rules, interval time.Duration) error {
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+
+	data, err := rules.Marshal()
+
+	if err != nil {
+		return err
+	}
+
+	var every string
+
+	if interval > 0 {
+		every = interval.String()
+	}
+
+	return q.UpsertSmartPlaylist(ctx, database.UpsertSmartPlaylistParams{
+		Name: name,
+		Rules: data,
+		RefreshInterval: every,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func Get(ctx context.Context, q *database.Queries, name string) (Playlist, error) {
+	row, err := q.GetSmartPlaylist(ctx, name)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Playlist{}, fmt.Errorf("no smart playlist named %q, see gsp smart list", name)
+	}
+
+	if err != nil {
+		return Playlist{}, err
+	}
+
+	return fromRow(row)
+}
+
+func List(ctx context.Context, q *database.Queries) ([]Playlist, error) {
+	rows, err := q.ListSmartPlaylists(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]Playlist, 0, len(rows))
+
+	for _, row := range rows {
+		p, err := fromRow(row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, p)
+	}
+
+	return playlists, nil
+}
+
+// Track is a track that matches the rules of a smart playlist.
+type Track struct {
+	Uri string
+	Name string
+	Artists string
+	Album string
+	AddedAt string
+	LastPlayedAt string
+	Plays int
+	Popularity int
+}
+
+func containsFold(values []string, s string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(strings.TrimSpace(v), s)
+	})
+}
+
+func matchesGenre(genres string, wanted []string) bool {
+	genres = strings.ToLower(genres)
+
+	for _, genre := range wanted {
+		if strings.Contains(genres, strings.ToLower(genre)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Evaluate selects the tracks matching the rules from the mirror and the play
+// history. exclude is the playlist the result is written to, so it does not
+// feed on itself when the source is every playlist.
+func Evaluate(ctx context.Context, q *database.Queries, rules Rules, exclude string, now time.Time) ([]Track, error) {
+	params := database.ListSmartCandidatesParams{
+		Saved: rules.Source == SourceLiked || rules.Source == SourceAll,
+		AllPlaylists: rules.Source == SourceAll,
+		ExcludePlaylistID: exclude,
+	}
+
+	if !params.Saved {
+		params.PlaylistID = rules.Source
+	}
+
+	rows, err := q.ListSmartCandidates(ctx, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var addedAfter, playedBefore string
+
+	if rules.AddedWithinDays > 0 {
+		addedAfter = now.AddDate(0, 0, -rules.AddedWithinDays).UTC().Format(time.RFC3339)
+	}
+
+	if rules.NotPlayedWithinDays > 0 {
+		playedBefore = now.AddDate(0, 0, -rules.NotPlayedWithinDays).UTC().Format(time.RFC3339)
+	}
+
+	var tracks []Track
+
+	for _, row := range rows {
+		// the timestamps are utc RFC 3339 and compare as strings
+		if addedAfter != "" && row.AddedAt < addedAfter {
+			continue
+		}
+
+		if playedBefore != "" && row.LastPlayedAt >= playedBefore {
+			continue
+		}
+
+		if rules.ExcludeExplicit && row.Explicit {
+			continue
+		}
+
+		if int(row.Popularity) < rules.MinPopularity {
+			continue
+		}
+
+		if len(rules.Artists) > 0 && !slices.ContainsFunc(strings.Split(row.ArtistNames, ", "), func(artist string) bool {
+			return containsFold(rules.Artists, artist)
+		}) {
+			continue
+		}
+
+		if len(rules.Genres) > 0 && !matchesGenre(row.Genres, rules.Genres) {
+			continue
+		}
+
+		tracks = append(tracks, Track{
+			Uri: row.Uri,
+			Name: row.Name,
+			Artists: row.ArtistNames,
+			Album: row.AlbumName,
+			AddedAt: row.AddedAt,
+			LastPlayedAt: row.LastPlayedAt,
+			Plays: int(row.Plays),
+			Popularity: int(row.Popularity),
+		})
+	}
+
+	switch rules.Sort {
+	case SortRandom:
+		rand.Shuffle(len(tracks), func(i, j int) { tracks[i], tracks[j] = tracks[j], tracks[i] })
+	case SortPopularity:
+		slices.SortStableFunc(tracks, func(a, b Track) int { return cmp.Compare(b.Popularity, a.Popularity) })
+	case SortPlays:
+		slices.SortStableFunc(tracks, func(a, b Track) int { return cmp.Compare(b.Plays, a.Plays) })
+	case SortLeastPlayed:
+		slices.SortStableFunc(tracks, func(a, b Track) int {
+			return cmp.Or(cmp.Compare(a.Plays, b.Plays), strings.Compare(a.LastPlayedAt, b.LastPlayedAt))
+		})
+	default:
+		slices.SortStableFunc(tracks, func(a, b Track) int { return strings.Compare(b.AddedAt, a.AddedAt) })
+	}
+
+	if len(tracks) > rules.Limit {
+		tracks = tracks[:rules.Limit]
+	}
+
+	return tracks, nil
+}
+
+// FetchGenres looks up the genres of mirrored artists that have none yet.
+// Only followed artists come with genres, the rest are fetched once and
+// cached.
+func FetchGenres(ctx context.Context, db *sql.DB, c *client.Client) (int, error) {
+	q := database.New(db)
+
+	ids, err := q.ListArtistsMissingGenres(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	fetchedAt := time.Now().UTC().Format(time.RFC3339)
+
+	for start := 0; start < len(ids); start += client.MaxSeveralArtists {
+		batch := ids[start:min(start+client.MaxSeveralArtists, len(ids))]
+
+		artists, err := c.GetSeveralArtists(ctx, client.GetSeveralArtistsParams{ Ids: batch })
+
+		if err != nil {
+			return start, err
+		}
+
+		for i, artist := range artists {
+			// unknown ids come back as null, cache them as having no genres
+			id := batch[i]
+
+			err := q.UpsertArtistGenres(ctx, database.UpsertArtistGenresParams{
+				ArtistID: id,
+				Genres: strings.Join(artist.Genres, ","),
+				FetchedAt: fetchedAt,
+			})
+
+			if err != nil {
+				return start, err
+			}
+		}
+	}
+
+	return len(ids), nil
+}
+
+type RefreshResult struct {
+	Name string
+	PlaylistId string
+	Tracks int
+	Created bool
+}
+
+func (r RefreshResult) String() string {
+	if r.Created {
+		return fmt.Sprintf("%s: created with %d tracks", r.Name, r.Tracks)
+	}
+
+	return fmt.Sprintf("%s: %d tracks", r.Name, r.Tracks)
+}
+
+// Refresh evaluates the rules and replaces the items of the spotify playlist
+// with the result. The playlist is created on the first refresh and again if
+// it was deleted on spotify.
+func Refresh(ctx context.Context, db *sql.DB, c *client.Client, p Playlist) (RefreshResult, error) {
+	q := database.New(db)
+	result := RefreshResult{ Name: p.Name, PlaylistId: p.PlaylistId }
+
+	if len(p.Rules.Genres) > 0 {
+		if _, err := FetchGenres(ctx, db, c); err != nil {
+			return result, fmt.Errorf("fetching genres: %w", err)
+		}
+	}
+
+	now := time.Now()
+
+	tracks, err := Evaluate(ctx, q, p.Rules, p.PlaylistId, now)
+
+	if err != nil {
+		return result, err
+	}
+
+	uris := make([]string, 0, len(tracks))
+
+	for _, track := range tracks {
+		uris = append(uris, track.Uri)
+	}
+
+	first := uris[:min(len(uris), client.MaxAddItems)]
+
+	if p.PlaylistId != "" {
+		_, err = c.ReplacePlaylistItems(ctx, client.ReplacePlaylistItemsParams{ Id: p.PlaylistId, Uris: first })
+	}
+
+	var spotifyErr client.SpotifyError
+
+	if p.PlaylistId == "" || (errors.As(err, &spotifyErr) && spotifyErr.Status == http.StatusNotFound) {
+		var user types.User
+		var created types.Playlist
+
+		user, err = c.GetCurrentUserProfile(ctx)
+
+		if err != nil {
+			return result, err
+		}
+
+		created, err = c.CreatePlaylist(ctx, client.CreatePlaylistParams{
+			UserId: user.Id,
+			Name: p.Name,
+			Description: "smart playlist: " + p.Rules.String(),
+		})
+
+		if err != nil {
+			return result, err
+		}
+
+		result.PlaylistId = created.Id
+		result.Created = true
+
+		_, err = c.ReplacePlaylistItems(ctx, client.ReplacePlaylistItemsParams{ Id: created.Id, Uris: first })
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	if len(uris) > len(first) {
+		if _, err := playlist.AddUris(ctx, c, result.PlaylistId, uris[len(first):]); err != nil {
+			return result, err
+		}
+	}
+
+	result.Tracks = len(uris)
+
+	err = q.SetSmartPlaylistRefreshed(ctx, database.SetSmartPlaylistRefreshedParams{
+		PlaylistID: result.PlaylistId,
+		RefreshedAt: now.UTC().Format(time.RFC3339),
+		Name: p.Name,
+	})
+
+	return result, err
+}
+
+// RefreshDue refreshes every smart playlist whose interval has passed.
+func RefreshDue(ctx context.Context, db *sql.DB, c *client.Client, now time.Time) ([]RefreshResult, error) {
+	playlists, err := List(ctx, database.New(db))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var results []RefreshResult
+	var errs []error
+
+	for _, p := range playlists {
+		if !p.Due(now) {
+			continue
+		}
+
+		result, err := Refresh(ctx, db, c, p)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,126 @@
+package smart
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/arjunmoola/go-spotify/client"
+	"github.com/arjunmoola/go-spotify/database"
+	schema "github.com/arjunmoola/go-spotify/sql"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+func openDb(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("libsql", "file:" + filepath.Join(t.TempDir(), "test.db"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range schema.Statements() {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+// fakeSpotify sends the requests of every client to handler instead of
+// the spotify api.
+func fakeSpotify(t *testing.T, handler http.Handler) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	transport := http.DefaultTransport
+
+	http.DefaultTransport = roundTripper(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+		return transport.RoundTrip(req)
+	})
+
+	t.Cleanup(func() { http.DefaultTransport = transport })
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRefreshSavesCreatedPlaylist(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+
+	fakeSpotify(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method + " " + r.URL.Path)
+		mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/me":
+			json.NewEncoder(w).Encode(map[string]any{ "id": "user" })
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users/user/playlists":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]any{ "id": "created" })
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/playlists/created/tracks":
+			json.NewEncoder(w).Encode(map[string]any{ "snapshot_id": "snapshot" })
+		default:
+			http.Error(w, `{"error":{"status":404,"message":"not found"}}`, http.StatusNotFound)
+		}
+	}))
+
+	ctx := client.WithAccessToken(context.Background(), "token")
+	db := openDb(t)
+	q := database.New(db)
+
+	if err := Save(ctx, q, "recent", DefaultRules(), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Get(ctx, q, "recent")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Refresh(ctx, db, client.New(), p)
+
+	if err != nil {
+		t.Fatalf("refresh failed: %v, requests: %v", err, requests)
+	}
+
+	if !result.Created || result.PlaylistId != "created" {
+		t.Errorf("got %+v, want the playlist created", result)
+	}
+
+	if len(requests) == 0 || requests[0] != "GET /v1/me" {
+		t.Errorf("got requests %v, want the playlist created before replacing its items", requests)
+	}
+
+	p, err = Get(ctx, q, "recent")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.PlaylistId != "created" {
+		t.Errorf("saved playlist id %q, want created", p.PlaylistId)
+	}
+}
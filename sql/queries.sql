@@ -14,3 +14,295 @@ SET
 WHERE
     id = 1;
 
+
+-- name: InsertPlayHistory :execrows
+INSERT OR IGNORE INTO play_history (play_key, played_at, track_uri, track_name, artist_name, album_name, ms_played, source) VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetTrackUriByName :one
+SELECT track_uri FROM play_history
+WHERE track_name = ? AND artist_name = ? AND track_uri IS NOT NULL AND track_uri != ''
+LIMIT 1;
+
+-- name: CountPlayHistory :one
+SELECT COUNT(*) FROM play_history;
+
+-- name: SetPlayHistoryTrackUri :exec
+UPDATE play_history SET track_uri = ? WHERE play_key = ? AND track_uri IS NULL;
+
+-- name: UpsertPlaylist :exec
+INSERT INTO playlists (id, name, description, owner_id, owner_name, snapshot_id, collaborative, public, uri, total, position, synced_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    description = excluded.description,
+    owner_id = excluded.owner_id,
+    owner_name = excluded.owner_name,
+    snapshot_id = excluded.snapshot_id,
+    collaborative = excluded.collaborative,
+    public = excluded.public,
+    uri = excluded.uri,
+    total = excluded.total,
+    position = excluded.position,
+    synced_at = excluded.synced_at;
+
+-- name: SetPlaylistItemsSnapshot :exec
+UPDATE playlists SET items_snapshot_id = ? WHERE id = ?;
+
+-- name: GetPlaylist :one
+SELECT id, name, description, owner_id, owner_name, snapshot_id, items_snapshot_id, collaborative, public, uri, total, position, synced_at FROM playlists WHERE id = ?;
+
+-- name: ListPlaylists :many
+SELECT id, name, description, owner_id, owner_name, snapshot_id, items_snapshot_id, collaborative, public, uri, total, position, synced_at FROM playlists ORDER BY position;
+
+-- name: DeleteStalePlaylists :exec
+DELETE FROM playlists WHERE synced_at != ?;
+
+-- name: DeleteOrphanedPlaylistItems :exec
+DELETE FROM playlist_items WHERE playlist_id NOT IN (SELECT id FROM playlists);
+
+-- name: DeletePlaylistItems :exec
+DELETE FROM playlist_items WHERE playlist_id = ?;
+
+-- name: InsertPlaylistItem :exec
+INSERT INTO playlist_items (playlist_id, position, track_uri, added_at, added_by) VALUES (?, ?, ?, ?, ?);
+
+-- name: ListPlaylistItems :many
+SELECT playlist_items.position, playlist_items.added_at, playlist_items.added_by, tracks.uri, tracks.id, tracks.type, tracks.name, tracks.artist_names, tracks.album_id, tracks.album_name, tracks.album_uri, tracks.release_date, tracks.duration_ms, tracks.popularity, tracks.explicit, tracks.isrc, tracks.is_local
+FROM playlist_items
+JOIN tracks ON tracks.uri = playlist_items.track_uri
+WHERE playlist_items.playlist_id = ?
+ORDER BY playlist_items.position;
+
+-- name: UpsertTrack :exec
+INSERT INTO tracks (uri, id, type, name, artist_names, album_id, album_name, album_uri, release_date, duration_ms, popularity, explicit, isrc, is_local)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (uri) DO UPDATE SET
+    id = excluded.id,
+    type = excluded.type,
+    name = excluded.name,
+    artist_names = excluded.artist_names,
+    album_id = excluded.album_id,
+    album_name = excluded.album_name,
+    album_uri = excluded.album_uri,
+    release_date = excluded.release_date,
+    duration_ms = excluded.duration_ms,
+    popularity = excluded.popularity,
+    explicit = excluded.explicit,
+    isrc = excluded.isrc,
+    is_local = excluded.is_local;
+
+-- name: DeleteTrackArtists :exec
+DELETE FROM track_artists WHERE track_uri = ?;
+
+-- name: InsertTrackArtist :exec
+INSERT INTO track_artists (track_uri, artist_id, position) VALUES (?, ?, ?);
+
+-- name: ListTrackArtistsByPlaylist :many
+SELECT track_artists.track_uri, artists.id, artists.name, artists.uri
+FROM track_artists
+JOIN artists ON artists.id = track_artists.artist_id
+WHERE track_artists.track_uri IN (SELECT track_uri FROM playlist_items WHERE playlist_id = ?)
+ORDER BY track_artists.track_uri, track_artists.position;
+
+-- name: ListTrackArtistsBySavedTracks :many
+SELECT track_artists.track_uri, artists.id, artists.name, artists.uri
+FROM track_artists
+JOIN artists ON artists.id = track_artists.artist_id
+WHERE track_artists.track_uri IN (SELECT track_uri FROM saved_tracks)
+ORDER BY track_artists.track_uri, track_artists.position;
+
+-- name: UpsertArtist :exec
+INSERT INTO artists (id, name, uri) VALUES (?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET name = excluded.name, uri = excluded.uri;
+
+-- name: UpsertFollowedArtist :exec
+INSERT INTO artists (id, name, uri, genres, popularity, followed) VALUES (?, ?, ?, ?, ?, 1)
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    uri = excluded.uri,
+    genres = excluded.genres,
+    popularity = excluded.popularity,
+    followed = 1;
+
+-- name: ClearFollowedArtists :exec
+UPDATE artists SET followed = 0;
+
+-- name: ListFollowedArtists :many
+SELECT id, name, uri, genres, popularity, followed FROM artists WHERE followed = 1 ORDER BY name;
+
+-- name: InsertSavedTrack :exec
+INSERT OR REPLACE INTO saved_tracks (track_uri, added_at) VALUES (?, ?);
+
+-- name: GetLatestSavedTrack :one
+SELECT track_uri, added_at FROM saved_tracks ORDER BY added_at DESC LIMIT 1;
+
+-- name: CountSavedTracks :one
+SELECT COUNT(*) FROM saved_tracks;
+
+-- name: DeleteSavedTracks :exec
+DELETE FROM saved_tracks;
+
+-- name: ListSavedTracks :many
+SELECT saved_tracks.added_at, tracks.uri, tracks.id, tracks.type, tracks.name, tracks.artist_names, tracks.album_id, tracks.album_name, tracks.album_uri, tracks.release_date, tracks.duration_ms, tracks.popularity, tracks.explicit, tracks.isrc, tracks.is_local
+FROM saved_tracks
+JOIN tracks ON tracks.uri = saved_tracks.track_uri
+ORDER BY saved_tracks.added_at DESC;
+
+-- name: InsertSavedAlbum :exec
+INSERT OR REPLACE INTO saved_albums (id, name, uri, artist_names, release_date, total_tracks, added_at) VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetLatestSavedAlbum :one
+SELECT id, added_at FROM saved_albums ORDER BY added_at DESC LIMIT 1;
+
+-- name: CountSavedAlbums :one
+SELECT COUNT(*) FROM saved_albums;
+
+-- name: DeleteSavedAlbums :exec
+DELETE FROM saved_albums;
+
+-- name: ListSavedAlbums :many
+SELECT id, name, uri, artist_names, release_date, total_tracks, added_at FROM saved_albums ORDER BY added_at DESC;
+
+-- name: ClearSearchIndex :exec
+DELETE FROM library_search;
+
+-- name: IndexTracks :exec
+INSERT INTO library_search (uri, kind, name, artists, album, playlists)
+SELECT
+    tracks.uri,
+    'track',
+    tracks.name,
+    tracks.artist_names,
+    tracks.album_name,
+    COALESCE((
+        SELECT group_concat(name, char(31)) FROM (
+            SELECT DISTINCT playlists.name
+            FROM playlist_items
+            JOIN playlists ON playlists.id = playlist_items.playlist_id
+            WHERE playlist_items.track_uri = tracks.uri
+        )
+    ), '')
+FROM tracks;
+
+-- name: IndexPlaylists :exec
+INSERT INTO library_search (uri, kind, name, artists, album, playlists)
+SELECT uri, 'playlist', name, owner_name, '', name FROM playlists;
+
+-- name: SearchLibrary :many
+SELECT uri, kind, name, artists, album, playlists FROM library_search
+WHERE library_search MATCH sqlc.arg(query)
+ORDER BY rank
+LIMIT sqlc.arg(limit);
+
+-- name: GetUiState :one
+SELECT profile, default_playlist_id, expanded_sections, table_title, cursor_row, cursor_col, updated_at FROM ui_state WHERE profile = ?;
+
+-- name: UpsertUiState :exec
+INSERT INTO ui_state (profile, default_playlist_id, expanded_sections, table_title, cursor_row, cursor_col, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (profile) DO UPDATE SET
+    default_playlist_id = excluded.default_playlist_id,
+    expanded_sections = excluded.expanded_sections,
+    table_title = excluded.table_title,
+    cursor_row = excluded.cursor_row,
+    cursor_col = excluded.cursor_col,
+    updated_at = excluded.updated_at;
+
+-- name: SetDefaultPlaylistID :exec
+INSERT INTO ui_state (profile, default_playlist_id, updated_at) VALUES (?, ?, ?)
+ON CONFLICT (profile) DO UPDATE SET
+    default_playlist_id = excluded.default_playlist_id,
+    updated_at = excluded.updated_at;
+
+-- name: ListArtistsMissingGenres :many
+SELECT DISTINCT ta.artist_id FROM track_artists ta
+JOIN artists a ON a.id = ta.artist_id
+LEFT JOIN artist_genres g ON g.artist_id = ta.artist_id
+WHERE g.artist_id IS NULL AND a.genres = '';
+
+-- name: UpsertArtistGenres :exec
+INSERT INTO artist_genres (artist_id, genres, fetched_at) VALUES (?, ?, ?)
+ON CONFLICT (artist_id) DO UPDATE SET genres = excluded.genres, fetched_at = excluded.fetched_at;
+
+-- name: ListSmartCandidates :many
+SELECT
+    t.uri,
+    t.name,
+    t.artist_names,
+    t.album_name,
+    t.popularity,
+    t.explicit,
+    CAST(min(src.added_at) AS TEXT) AS added_at,
+    CAST(coalesce((
+        SELECT group_concat(coalesce(nullif(g.genres, ''), a.genres), ',')
+        FROM track_artists ta
+        JOIN artists a ON a.id = ta.artist_id
+        LEFT JOIN artist_genres g ON g.artist_id = ta.artist_id
+        WHERE ta.track_uri = t.uri
+    ), '') AS TEXT) AS genres,
+    CAST(coalesce((SELECT max(h.played_at) FROM play_history h WHERE h.track_uri = t.uri), '') AS TEXT) AS last_played_at,
+    (SELECT count(*) FROM play_history h WHERE h.track_uri = t.uri) AS plays
+FROM (
+    SELECT track_uri, added_at FROM saved_tracks WHERE sqlc.arg(saved)
+    UNION ALL
+    SELECT track_uri, added_at FROM playlist_items
+    WHERE (sqlc.arg(all_playlists) OR playlist_id = sqlc.arg(playlist_id)) AND playlist_id != sqlc.arg(exclude_playlist_id)
+) src
+JOIN tracks t ON t.uri = src.track_uri
+WHERE t.type = 'track' AND t.is_local = 0
+GROUP BY t.uri;
+
+-- name: UpsertSmartPlaylist :exec
+INSERT INTO smart_playlists (name, rules, refresh_interval, created_at) VALUES (?, ?, ?, ?)
+ON CONFLICT (name) DO UPDATE SET rules = excluded.rules, refresh_interval = excluded.refresh_interval;
+
+-- name: GetSmartPlaylist :one
+SELECT name, rules, playlist_id, refresh_interval, refreshed_at, created_at FROM smart_playlists WHERE name = ?;
+
+-- name: ListSmartPlaylists :many
+SELECT name, rules, playlist_id, refresh_interval, refreshed_at, created_at FROM smart_playlists ORDER BY name;
+
+-- name: DeleteSmartPlaylist :exec
+DELETE FROM smart_playlists WHERE name = ?;
+
+-- name: SetSmartPlaylistRefreshed :exec
+UPDATE smart_playlists SET playlist_id = ?, refreshed_at = ? WHERE name = ?;
+
+-- name: UpsertPlaylistWatch :exec
+INSERT INTO playlist_watches (playlist_id, name, created_at) VALUES (?, ?, ?)
+ON CONFLICT (playlist_id) DO UPDATE SET name = excluded.name;
+
+-- name: ListPlaylistWatches :many
+SELECT playlist_id, name, snapshot_id, entries, checked_at, created_at FROM playlist_watches ORDER BY name;
+
+-- name: DeletePlaylistWatch :execrows
+DELETE FROM playlist_watches WHERE playlist_id = ?;
+
+-- name: UpdatePlaylistWatch :exec
+UPDATE playlist_watches SET name = ?, snapshot_id = ?, entries = ?, checked_at = ? WHERE playlist_id = ?;
+
+-- name: SetPlaylistWatchChecked :exec
+UPDATE playlist_watches SET checked_at = ? WHERE playlist_id = ?;
+
+-- name: InsertPlaylistChange :exec
+INSERT INTO playlist_changes (
+    playlist_id, playlist_name, snapshot_id, detected_at, change, track_uri, track_name, artist_names, added_by
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListPlaylistChanges :many
+SELECT id, playlist_id, playlist_name, snapshot_id, detected_at, change, track_uri, track_name, artist_names, added_by
+FROM playlist_changes
+WHERE sqlc.arg(all_playlists) OR playlist_id = sqlc.arg(playlist_id)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: ListPlaylistChangesAfter :many
+SELECT id, playlist_id, playlist_name, snapshot_id, detected_at, change, track_uri, track_name, artist_names, added_by
+FROM playlist_changes
+WHERE id > ?
+ORDER BY id;
+
+-- name: ListPlayedUrisSince :many
+SELECT track_uri FROM play_history
+WHERE played_at >= ? AND track_uri IS NOT NULL AND track_uri != ''
+ORDER BY played_at;
@@ -2,6 +2,7 @@ package sql
 
 import (
 	_ "embed"
+	"strings"
 )
 
 //go:embed schema.sql
@@ -10,3 +11,50 @@ var schema string
 func Get() string {
 	return schema
 }
+
+// Statements splits the schema into individual statements since the driver
+// only executes the first statement of a multi statement string. Trigger
+// bodies are kept together with the CREATE TRIGGER they belong to.
+func Statements() []string {
+	var stmts []string
+	var cur strings.Builder
+	var inBody bool
+
+	for _, line := range strings.Split(schema, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" && cur.Len() == 0 {
+			continue
+		}
+
+		cur.WriteString(line)
+		cur.WriteByte('\n')
+
+		upper := strings.ToUpper(trimmed)
+
+		if strings.HasSuffix(upper, "BEGIN") {
+			inBody = true
+			continue
+		}
+
+		if inBody {
+			if upper == "END;" {
+				inBody = false
+				stmts = append(stmts, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, cur.String())
+			cur.Reset()
+		}
+	}
+
+	if s := strings.TrimSpace(cur.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+
+	return stmts
+}
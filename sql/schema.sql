@@ -8,3 +8,152 @@ CREATE TABLE IF NOT EXISTS config (
     refresh_token VARCHAR,
     expires_at VARCHAR
 );
+
+CREATE TABLE IF NOT EXISTS play_history (
+    id INTEGER PRIMARY KEY,
+    play_key VARCHAR NOT NULL UNIQUE,
+    played_at VARCHAR NOT NULL,
+    track_uri VARCHAR,
+    track_name VARCHAR NOT NULL,
+    artist_name VARCHAR NOT NULL,
+    album_name VARCHAR,
+    ms_played INTEGER NOT NULL DEFAULT 0,
+    source VARCHAR NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS play_history_played_at_idx ON play_history (played_at);
+
+CREATE TABLE IF NOT EXISTS playlists (
+    id VARCHAR PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    description VARCHAR NOT NULL DEFAULT '',
+    owner_id VARCHAR NOT NULL,
+    owner_name VARCHAR NOT NULL DEFAULT '',
+    snapshot_id VARCHAR NOT NULL,
+    items_snapshot_id VARCHAR NOT NULL DEFAULT '',
+    collaborative BOOLEAN NOT NULL DEFAULT 0,
+    public BOOLEAN NOT NULL DEFAULT 0,
+    uri VARCHAR NOT NULL,
+    total INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL DEFAULT 0,
+    synced_at VARCHAR NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tracks (
+    uri VARCHAR PRIMARY KEY,
+    id VARCHAR NOT NULL DEFAULT '',
+    type VARCHAR NOT NULL DEFAULT 'track',
+    name VARCHAR NOT NULL,
+    artist_names VARCHAR NOT NULL DEFAULT '',
+    album_id VARCHAR NOT NULL DEFAULT '',
+    album_name VARCHAR NOT NULL DEFAULT '',
+    album_uri VARCHAR NOT NULL DEFAULT '',
+    release_date VARCHAR NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    popularity INTEGER NOT NULL DEFAULT 0,
+    explicit BOOLEAN NOT NULL DEFAULT 0,
+    isrc VARCHAR NOT NULL DEFAULT '',
+    is_local BOOLEAN NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS artists (
+    id VARCHAR PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    uri VARCHAR NOT NULL DEFAULT '',
+    genres VARCHAR NOT NULL DEFAULT '',
+    popularity INTEGER NOT NULL DEFAULT 0,
+    followed BOOLEAN NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS track_artists (
+    track_uri VARCHAR NOT NULL,
+    artist_id VARCHAR NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (track_uri, position)
+);
+
+CREATE INDEX IF NOT EXISTS track_artists_artist_id_idx ON track_artists (artist_id);
+
+CREATE TABLE IF NOT EXISTS playlist_items (
+    playlist_id VARCHAR NOT NULL,
+    position INTEGER NOT NULL,
+    track_uri VARCHAR NOT NULL,
+    added_at VARCHAR NOT NULL DEFAULT '',
+    added_by VARCHAR NOT NULL DEFAULT '',
+    PRIMARY KEY (playlist_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS playlist_items_track_uri_idx ON playlist_items (track_uri);
+
+CREATE TABLE IF NOT EXISTS saved_tracks (
+    track_uri VARCHAR PRIMARY KEY,
+    added_at VARCHAR NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS saved_albums (
+    id VARCHAR PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    uri VARCHAR NOT NULL,
+    artist_names VARCHAR NOT NULL DEFAULT '',
+    release_date VARCHAR NOT NULL DEFAULT '',
+    total_tracks INTEGER NOT NULL DEFAULT 0,
+    added_at VARCHAR NOT NULL
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS library_search USING fts5(
+    uri UNINDEXED,
+    kind UNINDEXED,
+    name,
+    artists,
+    album,
+    playlists
+);
+
+CREATE TABLE IF NOT EXISTS ui_state (
+    profile VARCHAR PRIMARY KEY,
+    default_playlist_id VARCHAR NOT NULL DEFAULT '',
+    expanded_sections VARCHAR NOT NULL DEFAULT '',
+    table_title VARCHAR NOT NULL DEFAULT '',
+    cursor_row INTEGER NOT NULL DEFAULT 0,
+    cursor_col INTEGER NOT NULL DEFAULT 0,
+    updated_at VARCHAR NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS artist_genres (
+    artist_id VARCHAR PRIMARY KEY,
+    genres VARCHAR NOT NULL DEFAULT '',
+    fetched_at VARCHAR NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS smart_playlists (
+    name VARCHAR PRIMARY KEY,
+    rules VARCHAR NOT NULL,
+    playlist_id VARCHAR NOT NULL DEFAULT '',
+    refresh_interval VARCHAR NOT NULL DEFAULT '',
+    refreshed_at VARCHAR NOT NULL DEFAULT '',
+    created_at VARCHAR NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS playlist_watches (
+    playlist_id VARCHAR PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    snapshot_id VARCHAR NOT NULL DEFAULT '',
+    entries VARCHAR NOT NULL DEFAULT '[]',
+    checked_at VARCHAR NOT NULL DEFAULT '',
+    created_at VARCHAR NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS playlist_changes (
+    id INTEGER PRIMARY KEY,
+    playlist_id VARCHAR NOT NULL,
+    playlist_name VARCHAR NOT NULL,
+    snapshot_id VARCHAR NOT NULL,
+    detected_at VARCHAR NOT NULL,
+    change VARCHAR NOT NULL,
+    track_uri VARCHAR NOT NULL,
+    track_name VARCHAR NOT NULL,
+    artist_names VARCHAR NOT NULL DEFAULT '',
+    added_by VARCHAR NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS playlist_changes_playlist_id_idx ON playlist_changes (playlist_id);
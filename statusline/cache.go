@@ -0,0 +1,98 @@
+package statusline
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/arjunmoola/go-spotify/output"
+)
+
+// Snapshot is a playback state and when it was fetched.
+type Snapshot struct {
+	NowPlaying output.NowPlaying `json:"now_playing"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// At estimates the playback state at t by advancing the progress of a
+// playing track, so a cached state still shows a moving progress.
+func (s Snapshot) At(t time.Time) output.NowPlaying {
+	n := s.NowPlaying
+
+	if n.Playing && t.After(s.FetchedAt) {
+		n.ProgressMs += int(t.Sub(s.FetchedAt).Milliseconds())
+
+		if n.DurationMs > 0 {
+			n.ProgressMs = min(n.ProgressMs, n.DurationMs)
+		}
+	}
+
+	return n
+}
+
+// Fresh reports whether s was fetched less than maxAge before t and the
+// track it shows has not ended since.
+func (s Snapshot) Fresh(t time.Time, maxAge time.Duration) bool {
+	if s.FetchedAt.IsZero() || t.Sub(s.FetchedAt) >= maxAge {
+		return false
+	}
+
+	n := s.At(t)
+
+	return !n.Playing || n.DurationMs == 0 || n.ProgressMs < n.DurationMs
+}
+
+// Cache keeps the last snapshot in a file so separate gsp status runs can
+// share it.
+type Cache struct {
+	path string
+}
+
+func NewCache(dir string) Cache {
+	return Cache{ path: filepath.Join(dir, "status.json") }
+}
+
+func (c Cache) Load() (Snapshot, bool) {
+	var s Snapshot
+
+	data, err := os.ReadFile(c.path)
+
+	if err != nil {
+		return s, false
+	}
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, false
+	}
+
+	return s, true
+}
+
+// Save writes s to a temporary file first so a status bar never reads half
+// a snapshot.
+func (c Cache) Save(s Snapshot) error {
+	data, err := json.Marshal(s)
+
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.path)
+}
+
+// Clear forgets the snapshot, e.g. after skipping a track.
+func (c Cache) Clear() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+// Package statusline renders what is playing as a single line for tmux,
+// polybar, waybar and i3bar.
+package statusline
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+
+	"github.com/arjunmoola/go-spotify/output"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	StatePlaying = "playing"
+	StatePaused = "paused"
+	StateStopped = "stopped"
+)
+
+// Icons are the symbols used for the playback state and modes.
+type Icons struct {
+	Playing string
+	Paused string
+	Stopped string
+	Shuffle string
+	Repeat string
+	RepeatOne string
+}
+
+// Line is what a status format sees, e.g. "{{.Artist}} - {{.Title}}".
+// Shuffle and Repeat hold their icon when enabled and are empty otherwise.
+type Line struct {
+	State string
+	Icon string
+	Title string
+	Artist string
+	Artists []string
+	Album string
+	Progress string
+	Duration string
+	Percent int
+	Shuffle string
+	Repeat string
+	Device string
+	Volume int
+}
+
+func NewLine(n output.NowPlaying, icons Icons) Line {
+	l := Line{
+		State: StateStopped,
+		Icon: icons.Stopped,
+		Title: n.Title,
+		Artist: strings.Join(n.Artists, ", "),
+		Artists: n.Artists,
+		Album: n.Album,
+		Progress: output.FormatDuration(n.ProgressMs),
+		Duration: output.FormatDuration(n.DurationMs),
+		Device: n.Device.Name,
+	}
+
+	switch {
+	case n.Type == "":
+	case n.Playing:
+		l.State = StatePlaying
+		l.Icon = icons.Playing
+	default:
+		l.State = StatePaused
+		l.Icon = icons.Paused
+	}
+
+	if n.DurationMs > 0 {
+		l.Percent = min(100, n.ProgressMs*100/n.DurationMs)
+	}
+
+	if n.Shuffle {
+		l.Shuffle = icons.Shuffle
+	}
+
+	switch n.Repeat {
+	case "context":
+		l.Repeat = icons.Repeat
+	case "track":
+		l.Repeat = icons.RepeatOne
+	}
+
+	if n.Device.Volume != nil {
+		l.Volume = *n.Device.Volume
+	}
+
+	return l
+}
+
+// Tooltip is the longer description status bars show on hover.
+func (l Line) Tooltip() string {
+	if l.State == StateStopped {
+		return "nothing is playing"
+	}
+
+	lines := []string{ l.Title }
+
+	for _, s := range []string{ l.Artist, l.Album } {
+		if s != "" {
+			lines = append(lines, s)
+		}
+	}
+
+	position := l.Progress + " / " + l.Duration
+
+	if l.Device != "" {
+		position += " on " + l.Device
+	}
+
+	lines = append(lines, position)
+
+	return strings.Join(lines, "\n")
+}
+
+var funcs = template.FuncMap{
+	"join": func(sep string, s []string) string {
+		return strings.Join(s, sep)
+	},
+}
+
+func ParseFormat(format string) (*template.Template, error) {
+	return template.New("status").Funcs(funcs).Parse(format)
+}
+
+// Render executes the format for l. Nothing playing renders as the stopped
+// icon alone, so an empty icon hides the module.
+func Render(tmpl *template.Template, l Line) (string, error) {
+	if l.State == StateStopped {
+		return l.Icon, nil
+	}
+
+	var b strings.Builder
+
+	if err := tmpl.Execute(&b, l); err != nil {
+		return "", err
+	}
+
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
+
+// Fit makes s at most width columns wide. Too long lines are cut with an
+// ellipsis, or scrolled by offset columns when scroll is set. A width of 0
+// leaves s as it is.
+func Fit(s string, width int, scroll bool, offset int) string {
+	if width <= 0 || runewidth.StringWidth(s) <= width {
+		return s
+	}
+
+	if !scroll {
+		return runewidth.Truncate(s, width, "…")
+	}
+
+	runes := []rune(s + "   ")
+	offset %= len(runes)
+	rotated := string(runes[offset:]) + string(runes[:offset])
+
+	return runewidth.Truncate(rotated, width, "")
+}
+
+// Waybar is the json a waybar custom module with "return-type": "json"
+// reads, one object per line.
+type Waybar struct {
+	Text string `json:"text"`
+	Tooltip string `json:"tooltip"`
+	Class string `json:"class"`
+	Alt string `json:"alt"`
+	Percentage int `json:"percentage"`
+}
+
+func NewWaybar(text string, l Line) Waybar {
+	return Waybar{
+		Text: text,
+		Tooltip: l.Tooltip(),
+		Class: l.State,
+		Alt: l.State,
+		Percentage: l.Percent,
+	}
+}
+
+// I3bar is a block of the i3bar protocol.
+type I3bar struct {
+	Name string `json:"name"`
+	FullText string `json:"full_text"`
+	ShortText string `json:"short_text"`
+}
+
+func NewI3bar(text string, l Line) I3bar {
+	return I3bar{
+		Name: "gsp",
+		FullText: text,
+		ShortText: l.Title,
+	}
+}
+
+// I3barHeader starts an i3bar stream, each line after it is a json array of
+// blocks followed by a comma.
+const I3barHeader = "{\"version\":1}\n["
+
+func Marshal(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -30,6 +30,15 @@ type Optional[T any] struct {
 	Valid bool
 }
 
+// MarshalJSON writes null for a missing value so the result decodes back
+// into the same Optional.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
 func (o *Optional[T]) UnmarshalJSON(b []byte) error {
 	if string(b) == "null" {
 		var zero T
@@ -74,6 +83,17 @@ func (i *ItemUnion) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON writes the track or episode itself, the way spotify sends it.
+func (i ItemUnion) MarshalJSON() ([]byte, error) {
+	switch {
+	case i.Track != nil:
+		return json.Marshal(i.Track)
+	case i.Episode != nil:
+		return json.Marshal(i.Episode)
+	}
+	return []byte("null"), nil
+}
+
 func (i ItemUnion) FilterValue() string {
 	return ""
 }
@@ -86,7 +106,7 @@ func unmarshaler(b []byte) func(v any) error {
 
 type PlaylistItemUnion struct {
 	AddedAt Optional[string] `json:"added_at"`
-	AddedBy Optional[User] `json:"user"`
+	AddedBy Optional[User] `json:"added_by"`
 	IsLocal bool `json:"is_local"`
 	Track ItemUnion `json:"track"`
 }
@@ -98,10 +118,13 @@ func (i PlaylistItemUnion) FilterValue() string {
 func (i PlaylistItemUnion) Row() table.Row {
 	var row []string
 
-	if i.Track.Track.Type == "track" {
+	switch i.Track.Type {
+	case "track":
 		row = i.Track.Track.Row()
-	} else {
-		row = append(row, i.Track.Episode.Name)
+	case "episode":
+		row = i.Track.Episode.Row()
+	default:
+		row = append(row, "", "", "", "")
 	}
 
 	return row
@@ -114,6 +137,7 @@ type PlaylistSnapshot struct {
 type Page[T any] struct {
 	Href string `json:"href"`
 	Limit int `json:"limit"`
+	Offset int `json:"offset"`
 	Next Optional[string] `json:"next"`
 	Previous Optional[string] `json:"previous"`
 	Total int `json:"total"`
@@ -147,8 +171,30 @@ func (p PlayHistory) Row() table.Row {
 	return row
 }
 
+type CursorPage[T any] struct {
+	Href string `json:"href"`
+	Limit int `json:"limit"`
+	Next Optional[string] `json:"next"`
+	Cursors Cursors `json:"cursors"`
+	Total int `json:"total"`
+	Items []T `json:"items"`
+}
+
+type FollowedArtists struct {
+	Artists CursorPage[Artist] `json:"artists"`
+}
+
+type SavedAlbum struct {
+	AddedAt string `json:"added_at"`
+	Album Album `json:"album"`
+}
+
+func (s SavedAlbum) FilterValue() string {
+	return ""
+}
+
 type SavedTrack struct {
-	AddedAt string `json:"addedAt"`
+	AddedAt string `json:"added_at"`
 	Track Track `json:"track"`
 }
 
@@ -168,6 +214,9 @@ type SimplifiedPlaylistObject struct {
 	Name string `json:"name"`
 	SnapshotId string `json:"snapshot_id"`
 	Items []SimplifiedPlaylistTrack `json:"items"`
+	Tracks SimplifiedPlaylistTrack `json:"tracks"`
+	Owner Owner `json:"owner"`
+	Public bool `json:"public"`
 	Type string `json:"type"`
 	Uri string `json:"uri"`
 }
@@ -307,6 +356,16 @@ type Album struct {
 	Name string `json:"name"`
 	ReleaseDate string `json:"release_date"`
 	ReleaseDatePrecision string `json:"release_date_precision"`
+	Uri string `json:"uri"`
+	Artists []SimplifiedArtist `json:"artists"`
+	Images []Image `json:"images"`
+}
+
+// Image is cover art. Spotify lists the largest first.
+type Image struct {
+	Url string `json:"url"`
+	Height Optional[int] `json:"height"`
+	Width Optional[int] `json:"width"`
 }
 
 type Playlist struct {
@@ -345,12 +404,19 @@ type SimplifiedArtist struct {
 	Uri string `json:"uri"`
 }
 
+type ExternalIds struct {
+	Isrc string `json:"isrc,omitempty"`
+	Ean string `json:"ean,omitempty"`
+	Upc string `json:"upc,omitempty"`
+}
+
 type Track struct {
 	Album Album `json:"album"`
 	Artists []SimplifiedArtist `json:"artists"`
 	DiscNumber int `json:"disc_number"`
 	DurationMs int `json:"duration_ms"`
 	Explicit bool `json:"explicit"`
+	ExternalIds ExternalIds `json:"external_ids"`
 	Href string `json:"href"`
 	Id string `json:"id"`
 	IsPlayable bool `json:"is_playable"`
@@ -410,6 +476,7 @@ type Episode struct {
 	Type string `json:"type"`
 	Uri string `json:"uri"`
 	Restrictions Restrictions `json:"restrictions"`
+	Images []Image `json:"images"`
 }
 
 func (e Episode) FilterValue() string {
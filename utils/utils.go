@@ -18,7 +18,7 @@ var configDir string
 var dbUrl string
 var logFilePath string
 
-var ddl string
+var ddl []string
 
 func init() {
 	homeDir, _ := os.UserHomeDir()
@@ -27,7 +27,20 @@ func init() {
 	dbUrl = "file:" + filepath.Join(configDir, defaultDbName)
 	logFilePath = filepath.Join(configDir, "log")
 
-	ddl = schema.Get()
+	ddl = schema.Statements()
+}
+
+// SetProfile moves the config dir, database and log of gsp into the
+// directory of the profile. The empty profile is the default one.
+func SetProfile(name string) {
+	configDir = filepath.Join(userHomeDir, configDirName)
+
+	if name != "" {
+		configDir = filepath.Join(configDir, "profiles", name)
+	}
+
+	dbUrl = "file:" + filepath.Join(configDir, defaultDbName)
+	logFilePath = filepath.Join(configDir, "log")
 }
 
 func UserHomeDir() string {
@@ -47,14 +60,6 @@ func LogFilePath() string {
 }
 
 func InitializeConfigDir() error {
-	userHomeDir, err := os.UserHomeDir()
-
-	if err != nil {
-		return err
-	}
-
-	configDir := filepath.Join(userHomeDir, ".go-spotify")
-
 	if err := checkOrCreateDir(configDir); err != nil {
 		return err
 	}
@@ -76,9 +81,11 @@ func InitializeDB() (*sql.DB, error) {
 		return nil, err
 	}
 
-	if _, err := db.Exec(ddl); err != nil {
-		db.Close()
-		return nil, err
+	for _, stmt := range ddl {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
 	}
 
 	return db, nil
@@ -115,7 +122,7 @@ func checkOrCreateDir(dir string) error {
 	}
 
 	if dirNotFound {
-		if err := os.Mkdir(dir, 0766); err != nil {
+		if err := os.MkdirAll(dir, 0766); err != nil {
 			return err
 		}
 	}